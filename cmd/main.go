@@ -2,6 +2,7 @@ package main
 
 import (
 	"TG_BOT_GO/internal/config"
+	"TG_BOT_GO/internal/exporter"
 	"TG_BOT_GO/internal/telegram"
 	"log"
 )
@@ -10,6 +11,15 @@ func main() {
 	// Загружаем конфигурацию
 	config.LoadConfig()
 
+	// Запускаем экспортёр метрик Prometheus, если задан адрес
+	if addr := config.GetEnv("METRICS_ADDR"); addr != "" {
+		go func() {
+			if err := exporter.Start(addr, telegram.PollerHealth); err != nil {
+				log.Printf("Ошибка экспортёра метрик: %v", err)
+			}
+		}()
+	}
+
 	// Запускаем бота
 	if err := telegram.StartBot(); err != nil {
 		log.Fatalf("Ошибка при запуске бота: %v", err)
@@ -0,0 +1,267 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"time"
+)
+
+// Point — точка графика
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series — линия графика
+type Series struct {
+	Name   string
+	Points []Point
+}
+
+// Chart описывает линейный график по времени
+type Chart struct {
+	Title  string
+	Unit   string
+	Series []Series
+	Width  int       // Ширина изображения (по умолчанию 800)
+	Height int       // Высота изображения (по умолчанию 400)
+	MinY   float64   // Нижняя граница оси Y, если FixedY
+	MaxY   float64   // Верхняя граница оси Y, если FixedY
+	FixedY bool      // Использовать MinY/MaxY вместо автоматического диапазона
+	From   time.Time // Начало оси времени (по умолчанию — первая точка)
+	To     time.Time // Конец оси времени (по умолчанию — последняя точка)
+}
+
+// Отступы области построения
+const (
+	marginLeft   = 80
+	marginRight  = 20
+	marginTop    = 50
+	marginBottom = 40
+	textScale    = 2
+	gridLines    = 5
+	timeTicks    = 6
+)
+
+var (
+	colorBackground = color.RGBA{255, 255, 255, 255}
+	colorAxis       = color.RGBA{60, 60, 60, 255}
+	colorGrid       = color.RGBA{225, 225, 225, 255}
+	colorText       = color.RGBA{30, 30, 30, 255}
+
+	// palette — цвета линий серий
+	palette = []color.RGBA{
+		{33, 150, 243, 255},
+		{244, 67, 54, 255},
+		{76, 175, 80, 255},
+		{255, 152, 0, 255},
+		{156, 39, 176, 255},
+	}
+)
+
+// PNG рисует график и возвращает его в формате PNG
+func (c Chart) PNG() ([]byte, error) {
+	img, err := c.Render()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Render рисует график в изображение
+func (c Chart) Render() (*image.RGBA, error) {
+	width, height := c.Width, c.Height
+	if width == 0 {
+		width = 800
+	}
+	if height == 0 {
+		height = 400
+	}
+
+	tMin, tMax, yMin, yMax, ok := c.bounds()
+	if !ok {
+		return nil, fmt.Errorf("нет данных для графика")
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{colorBackground}, image.Point{}, draw.Src)
+
+	plot := image.Rect(marginLeft, marginTop, width-marginRight, height-marginBottom)
+	toX := func(t time.Time) int {
+		span := tMax.Sub(tMin)
+		if span <= 0 {
+			return plot.Min.X
+		}
+		return plot.Min.X + int(float64(plot.Dx())*float64(t.Sub(tMin))/float64(span))
+	}
+	toY := func(v float64) int {
+		return plot.Max.Y - int(float64(plot.Dy())*(v-yMin)/(yMax-yMin))
+	}
+
+	// Заголовок
+	drawText(img, marginLeft, 12, c.Title, colorText, textScale)
+
+	// Горизонтальная сетка и подписи оси Y
+	for i := 0; i <= gridLines; i++ {
+		v := yMin + (yMax-yMin)*float64(i)/gridLines
+		y := toY(v)
+		drawLine(img, plot.Min.X, y, plot.Max.X, y, colorGrid)
+		label := formatValue(v) + c.Unit
+		drawText(img, plot.Min.X-8-textWidth(label, 1), y-glyphHeight/2, label, colorText, 1)
+	}
+
+	// Вертикальная сетка и подписи оси времени
+	layout := "15:04"
+	if tMax.Sub(tMin) > 24*time.Hour {
+		layout = "02.01 15:04"
+	}
+	for i := 0; i <= timeTicks; i++ {
+		t := tMin.Add(time.Duration(float64(tMax.Sub(tMin)) * float64(i) / timeTicks))
+		x := toX(t)
+		drawLine(img, x, plot.Min.Y, x, plot.Max.Y, colorGrid)
+		label := t.Format(layout)
+		drawText(img, x-textWidth(label, 1)/2, plot.Max.Y+8, label, colorText, 1)
+	}
+
+	// Оси
+	drawLine(img, plot.Min.X, plot.Min.Y, plot.Min.X, plot.Max.Y, colorAxis)
+	drawLine(img, plot.Min.X, plot.Max.Y, plot.Max.X, plot.Max.Y, colorAxis)
+
+	// Линии серий и легенда
+	legendX := plot.Max.X
+	for i := len(c.Series) - 1; i >= 0; i-- {
+		series := c.Series[i]
+		col := palette[i%len(palette)]
+
+		// Разрывы в данных (бот был выключен) не соединяем линией
+		maxGap := time.Duration(math.MaxInt64)
+		if n := len(series.Points); n > 2 {
+			maxGap = 5 * series.Points[n-1].Time.Sub(series.Points[0].Time) / time.Duration(n-1)
+		}
+
+		for j := 1; j < len(series.Points); j++ {
+			p0, p1 := series.Points[j-1], series.Points[j]
+			if p1.Time.Sub(p0.Time) > maxGap {
+				continue
+			}
+			x0, y0, x1, y1 := toX(p0.Time), toY(p0.Value), toX(p1.Time), toY(p1.Value)
+			drawLine(img, x0, y0, x1, y1, col)
+			drawLine(img, x0, y0+1, x1, y1+1, col) // Толщина линии 2 пикселя
+		}
+		if len(series.Points) == 1 {
+			p := series.Points[0]
+			fillRect(img, toX(p.Time)-2, toY(p.Value)-2, 5, 5, col)
+		}
+
+		legendX -= textWidth(series.Name, 1) + 20
+		fillRect(img, legendX, 32, 10, 7, col)
+		drawText(img, legendX+14, 32, series.Name, colorText, 1)
+	}
+
+	return img, nil
+}
+
+// bounds вычисляет диапазоны времени и значений по всем сериям
+func (c Chart) bounds() (tMin, tMax time.Time, yMin, yMax float64, ok bool) {
+	yMin, yMax = math.Inf(1), math.Inf(-1)
+	for _, series := range c.Series {
+		for _, p := range series.Points {
+			if !ok || p.Time.Before(tMin) {
+				tMin = p.Time
+			}
+			if !ok || p.Time.After(tMax) {
+				tMax = p.Time
+			}
+			yMin = math.Min(yMin, p.Value)
+			yMax = math.Max(yMax, p.Value)
+			ok = true
+		}
+	}
+	if !ok {
+		return
+	}
+
+	if !c.From.IsZero() && !c.To.IsZero() && c.To.After(c.From) {
+		tMin, tMax = c.From, c.To
+	}
+
+	if c.FixedY {
+		return tMin, tMax, c.MinY, c.MaxY, true
+	}
+
+	// Добавляем запас сверху и снизу, чтобы линия не прилипала к краям
+	if yMax == yMin {
+		yMax = yMin + 1
+	}
+	pad := (yMax - yMin) * 0.1
+	yMax += pad
+	if yMin >= 0 && yMin-pad < 0 {
+		yMin = 0
+	} else {
+		yMin -= pad
+	}
+	return tMin, tMax, yMin, yMax, true
+}
+
+// formatValue форматирует подпись оси Y
+func formatValue(v float64) string {
+	switch {
+	case math.Abs(v) >= 100:
+		return fmt.Sprintf("%.0f", v)
+	case math.Abs(v) >= 10:
+		return fmt.Sprintf("%.1f", v)
+	default:
+		return fmt.Sprintf("%.2f", v)
+	}
+}
+
+// drawLine рисует отрезок алгоритмом Брезенхэма
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// fillRect закрашивает прямоугольник
+func fillRect(img *image.RGBA, x, y, w, h int, c color.Color) {
+	draw.Draw(img, image.Rect(x, y, x+w, y+h), &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+// abs возвращает модуль целого числа
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Executor запускает системные команды. Интерфейс позволяет подменить запуск в тестах
+type Executor interface {
+	Run(name string, args ...string) error
+}
+
+// SystemExecutor запускает команды через os/exec
+type SystemExecutor struct{}
+
+// Run выполняет команду и возвращает ошибку вместе с её выводом
+func (SystemExecutor) Run(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		output := strings.TrimSpace(string(out))
+		if output == "" {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		return fmt.Errorf("%s: %v: %s", name, err, output)
+	}
+	return nil
+}
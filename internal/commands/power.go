@@ -0,0 +1,250 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PowerAction описывает действие управления питанием
+type PowerAction string
+
+const (
+	ActionShutdown PowerAction = "shutdown" // Выключение
+	ActionReboot   PowerAction = "reboot"   // Перезагрузка
+	ActionSuspend  PowerAction = "suspend"  // Спящий режим
+	ActionLock     PowerAction = "lock"     // Блокировка экрана
+	ActionCancel   PowerAction = "cancel"   // Отмена запланированного выключения
+)
+
+// ErrUnsupported возвращается, если действие не поддерживается на текущей ОС
+var ErrUnsupported = errors.New("действие не поддерживается на этой ОС")
+
+// ErrConfirmationExpired возвращается при нажатии кнопки подтверждения, которая старше ConfirmationTTL
+var ErrConfirmationExpired = errors.New("подтверждение устарело, выберите действие заново")
+
+// ConfirmationTTL — сколько действует кнопка подтверждения действия питания.
+// Без ограничения случайное нажатие в старом сообщении выключило бы компьютер спустя дни
+const ConfirmationTTL = 2 * time.Minute
+
+// ParsePowerAction преобразует строку в действие питания
+func ParsePowerAction(s string) (PowerAction, bool) {
+	switch action := PowerAction(s); action {
+	case ActionShutdown, ActionReboot, ActionSuspend, ActionLock, ActionCancel:
+		return action, true
+	}
+	return "", false
+}
+
+// Title возвращает название действия для пользователя
+func (a PowerAction) Title() string {
+	switch a {
+	case ActionShutdown:
+		return "Выключение"
+	case ActionReboot:
+		return "Перезагрузка"
+	case ActionSuspend:
+		return "Спящий режим"
+	case ActionLock:
+		return "Блокировка экрана"
+	case ActionCancel:
+		return "Отмена выключения"
+	default:
+		return string(a)
+	}
+}
+
+// ConfirmationData кодирует действие, задержку и время запроса подтверждения
+// в строку "<действие>_<секунды>_<unix-время>" для callback-данных кнопки
+func ConfirmationData(action PowerAction, delay time.Duration, now time.Time) string {
+	return fmt.Sprintf("%s_%d_%d", action, int(delay.Seconds()), now.Unix())
+}
+
+// ParseConfirmation разбирает строку ConfirmationData и проверяет, что подтверждение не устарело
+func ParseConfirmation(data string, now time.Time) (PowerAction, time.Duration, error) {
+	parts := strings.Split(data, "_")
+	if len(parts) != 3 {
+		return "", 0, fmt.Errorf("неверные данные подтверждения %q", data)
+	}
+	action, ok := ParsePowerAction(parts[0])
+	if !ok {
+		return "", 0, fmt.Errorf("неизвестное действие %q", parts[0])
+	}
+	seconds, err := strconv.Atoi(parts[1])
+	if err != nil || seconds < 0 {
+		return "", 0, fmt.Errorf("неверная задержка %q", parts[1])
+	}
+	issued, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("неверное время подтверждения %q", parts[2])
+	}
+	if age := now.Sub(time.Unix(issued, 0)); age < 0 || age > ConfirmationTTL {
+		return "", 0, ErrConfirmationExpired
+	}
+	return action, time.Duration(seconds) * time.Second, nil
+}
+
+// PowerController выполняет действия питания с учётом ОС и задержки
+type PowerController struct {
+	executor Executor
+	goos     string
+
+	mutex         sync.Mutex
+	timer         *time.Timer // Таймер для действий без встроенной задержки (сон, блокировка)
+	pendingAction PowerAction
+	pendingAt     time.Time
+}
+
+// Power — контроллер питания, используемый ботом
+var Power = NewPowerController(SystemExecutor{}, runtime.GOOS)
+
+// NewPowerController создаёт контроллер питания для указанной ОС
+func NewPowerController(executor Executor, goos string) *PowerController {
+	return &PowerController{executor: executor, goos: goos}
+}
+
+// Execute выполняет действие сразу или планирует его через delay
+func (c *PowerController) Execute(action PowerAction, delay time.Duration) error {
+	if action == ActionCancel {
+		return c.cancel()
+	}
+
+	// Выключение и перезагрузка планируются средствами ОС, чтобы пережить перезапуск бота
+	if action == ActionShutdown || action == ActionReboot {
+		name, args, err := c.command(action, delay)
+		if err != nil {
+			return err
+		}
+		if err := c.executor.Run(name, args...); err != nil {
+			return err
+		}
+		c.setPending(action, delay, nil)
+		return nil
+	}
+
+	name, args, err := c.command(action, 0)
+	if err != nil {
+		return err
+	}
+
+	if delay <= 0 {
+		return c.executor.Run(name, args...)
+	}
+
+	timer := time.AfterFunc(delay, func() {
+		c.mutex.Lock()
+		c.timer = nil
+		c.pendingAction = ""
+		c.mutex.Unlock()
+
+		if err := c.executor.Run(name, args...); err != nil {
+			log.Printf("Ошибка при выполнении действия %s: %v", action, err)
+		}
+	})
+	c.setPending(action, delay, timer)
+	return nil
+}
+
+// Pending возвращает запланированное ботом действие и время его выполнения
+func (c *PowerController) Pending() (PowerAction, time.Time, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.pendingAction == "" || (c.timer == nil && time.Now().After(c.pendingAt)) {
+		return "", time.Time{}, false
+	}
+	return c.pendingAction, c.pendingAt, true
+}
+
+// setPending запоминает запланированное действие, отменяя предыдущий таймер
+func (c *PowerController) setPending(action PowerAction, delay time.Duration, timer *time.Timer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.timer = timer
+	c.pendingAction = action
+	c.pendingAt = time.Now().Add(delay)
+}
+
+// cancel отменяет таймер бота и запланированное средствами ОС выключение
+func (c *PowerController) cancel() error {
+	c.mutex.Lock()
+	stopped := c.timer != nil && c.timer.Stop()
+	c.timer = nil
+	c.pendingAction = ""
+	c.mutex.Unlock()
+
+	name, args, err := c.command(ActionCancel, 0)
+	if err != nil {
+		return err
+	}
+	if err := c.executor.Run(name, args...); err != nil && !stopped {
+		// Ошибку ОС показываем, только если отменять было больше нечего
+		return err
+	}
+	return nil
+}
+
+// command возвращает системную команду для действия на текущей ОС
+func (c *PowerController) command(action PowerAction, delay time.Duration) (string, []string, error) {
+	switch c.goos {
+	case "linux":
+		switch action {
+		case ActionShutdown:
+			return "shutdown", []string{"-h", unixShutdownTime(delay)}, nil
+		case ActionReboot:
+			return "shutdown", []string{"-r", unixShutdownTime(delay)}, nil
+		case ActionSuspend:
+			return "systemctl", []string{"suspend"}, nil
+		case ActionLock:
+			return "loginctl", []string{"lock-sessions"}, nil
+		case ActionCancel:
+			return "shutdown", []string{"-c"}, nil
+		}
+	case "darwin":
+		switch action {
+		case ActionShutdown:
+			return "shutdown", []string{"-h", unixShutdownTime(delay)}, nil
+		case ActionReboot:
+			return "shutdown", []string{"-r", unixShutdownTime(delay)}, nil
+		case ActionSuspend:
+			return "pmset", []string{"sleepnow"}, nil
+		case ActionLock:
+			return "pmset", []string{"displaysleepnow"}, nil
+		case ActionCancel:
+			return "killall", []string{"shutdown"}, nil
+		}
+	case "windows":
+		seconds := strconv.Itoa(int(delay.Seconds()))
+		switch action {
+		case ActionShutdown:
+			return "shutdown", []string{"/s", "/t", seconds}, nil
+		case ActionReboot:
+			return "shutdown", []string{"/r", "/t", seconds}, nil
+		case ActionSuspend:
+			return "rundll32.exe", []string{"powrprof.dll,SetSuspendState", "0,1,0"}, nil
+		case ActionLock:
+			return "rundll32.exe", []string{"user32.dll,LockWorkStation"}, nil
+		case ActionCancel:
+			return "shutdown", []string{"/a"}, nil
+		}
+	}
+	return "", nil, fmt.Errorf("%s на %s: %w", action, c.goos, ErrUnsupported)
+}
+
+// unixShutdownTime переводит задержку в формат shutdown: "now" или "+минуты" с округлением вверх
+func unixShutdownTime(delay time.Duration) string {
+	if delay <= 0 {
+		return "now"
+	}
+	minutes := int((delay + time.Minute - 1) / time.Minute)
+	return "+" + strconv.Itoa(minutes)
+}
@@ -0,0 +1,235 @@
+package commands
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeExecutor запоминает запущенные команды вместо их выполнения
+type fakeExecutor struct {
+	mutex sync.Mutex
+	calls []string
+	err   error         // Ошибка, которую возвращает Run
+	ran   chan struct{} // Сигнал о каждом запуске
+}
+
+func newFakeExecutor() *fakeExecutor {
+	return &fakeExecutor{ran: make(chan struct{}, 10)}
+}
+
+func (e *fakeExecutor) Run(name string, args ...string) error {
+	e.mutex.Lock()
+	e.calls = append(e.calls, strings.Join(append([]string{name}, args...), " "))
+	err := e.err
+	e.mutex.Unlock()
+	e.ran <- struct{}{}
+	return err
+}
+
+// Fail задаёт ошибку для следующих запусков
+func (e *fakeExecutor) Fail(err error) {
+	e.mutex.Lock()
+	e.err = err
+	e.mutex.Unlock()
+}
+
+func (e *fakeExecutor) Calls() []string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]string(nil), e.calls...)
+}
+
+func TestPowerCommands(t *testing.T) {
+	tests := []struct {
+		goos   string
+		action PowerAction
+		delay  time.Duration
+		want   string
+	}{
+		{"linux", ActionShutdown, 0, "shutdown -h now"},
+		{"linux", ActionShutdown, 90 * time.Second, "shutdown -h +2"},
+		{"linux", ActionReboot, time.Hour, "shutdown -r +60"},
+		{"linux", ActionSuspend, 0, "systemctl suspend"},
+		{"linux", ActionLock, 0, "loginctl lock-sessions"},
+		{"linux", ActionCancel, 0, "shutdown -c"},
+		{"darwin", ActionShutdown, 5 * time.Minute, "shutdown -h +5"},
+		{"darwin", ActionReboot, 0, "shutdown -r now"},
+		{"darwin", ActionSuspend, 0, "pmset sleepnow"},
+		{"darwin", ActionLock, 0, "pmset displaysleepnow"},
+		{"darwin", ActionCancel, 0, "killall shutdown"},
+		{"windows", ActionShutdown, 90 * time.Second, "shutdown /s /t 90"},
+		{"windows", ActionReboot, 0, "shutdown /r /t 0"},
+		{"windows", ActionSuspend, 0, "rundll32.exe powrprof.dll,SetSuspendState 0,1,0"},
+		{"windows", ActionLock, 0, "rundll32.exe user32.dll,LockWorkStation"},
+		{"windows", ActionCancel, 0, "shutdown /a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goos+"/"+string(tt.action), func(t *testing.T) {
+			name, args, err := NewPowerController(newFakeExecutor(), tt.goos).command(tt.action, tt.delay)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(append([]string{name}, args...), " "); got != tt.want {
+				t.Errorf("command() = %q, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+
+	err := NewPowerController(newFakeExecutor(), "plan9").Execute(ActionShutdown, 0)
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Execute() на неизвестной ОС = %v, ожидалось ErrUnsupported", err)
+	}
+}
+
+func TestExecuteShutdownScheduledByOS(t *testing.T) {
+	executor := newFakeExecutor()
+	c := NewPowerController(executor, "linux")
+
+	if err := c.Execute(ActionShutdown, 10*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if got := executor.Calls(); !reflect.DeepEqual(got, []string{"shutdown -h +10"}) {
+		t.Errorf("команды = %q", got)
+	}
+	action, at, ok := c.Pending()
+	if !ok || action != ActionShutdown || time.Until(at) < 9*time.Minute {
+		t.Errorf("Pending() = %s, %s, %v", action, at, ok)
+	}
+
+	// Ошибка ОС не должна оставлять действие запланированным
+	executor.Fail(errors.New("shutdown: permission denied"))
+	c = NewPowerController(executor, "linux")
+	if err := c.Execute(ActionReboot, time.Minute); err == nil {
+		t.Error("ожидалась ошибка исполнителя")
+	}
+	if _, _, ok := c.Pending(); ok {
+		t.Error("действие запланировано, хотя команда завершилась ошибкой")
+	}
+}
+
+func TestPendingExpiresAfterScheduledTime(t *testing.T) {
+	c := NewPowerController(newFakeExecutor(), "linux")
+	if err := c.Execute(ActionShutdown, 0); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	// Выключение «сейчас» уже передано ОС — ожидающим оно больше не считается
+	if action, _, ok := c.Pending(); ok {
+		t.Errorf("Pending() = %s после наступления времени выполнения", action)
+	}
+}
+
+func TestDelayedSuspendRunsOnTimer(t *testing.T) {
+	executor := newFakeExecutor()
+	c := NewPowerController(executor, "linux")
+
+	if err := c.Execute(ActionSuspend, 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if action, _, ok := c.Pending(); !ok || action != ActionSuspend {
+		t.Errorf("Pending() = %s, %v до срабатывания таймера", action, ok)
+	}
+	if len(executor.Calls()) != 0 {
+		t.Errorf("команда выполнена до срабатывания таймера: %q", executor.Calls())
+	}
+
+	select {
+	case <-executor.ran:
+	case <-time.After(time.Second):
+		t.Fatal("таймер не сработал")
+	}
+	if got := executor.Calls(); !reflect.DeepEqual(got, []string{"systemctl suspend"}) {
+		t.Errorf("команды = %q", got)
+	}
+	if _, _, ok := c.Pending(); ok {
+		t.Error("действие осталось запланированным после выполнения")
+	}
+}
+
+func TestCancelStopsTimer(t *testing.T) {
+	executor := newFakeExecutor()
+	c := NewPowerController(executor, "linux")
+
+	if err := c.Execute(ActionLock, 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	// Отмена ОС завершается ошибкой (выключение не запланировано), но таймер бота остановлен
+	executor.Fail(errors.New("shutdown: no scheduled shutdown"))
+	if err := c.Execute(ActionCancel, 0); err != nil {
+		t.Errorf("Execute(cancel) = %v", err)
+	}
+	if _, _, ok := c.Pending(); ok {
+		t.Error("действие осталось запланированным после отмены")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if got := executor.Calls(); !reflect.DeepEqual(got, []string{"shutdown -c"}) {
+		t.Errorf("команды = %q, блокировка не должна была выполниться", got)
+	}
+
+	// Отменять нечего — ошибка ОС возвращается пользователю
+	if err := c.Execute(ActionCancel, 0); err == nil {
+		t.Error("ожидалась ошибка отмены, когда ничего не запланировано")
+	}
+}
+
+func TestNewActionReplacesPendingTimer(t *testing.T) {
+	executor := newFakeExecutor()
+	c := NewPowerController(executor, "linux")
+
+	if err := c.Execute(ActionLock, 30*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Execute(ActionSuspend, 30*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	<-executor.ran
+	time.Sleep(60 * time.Millisecond)
+	if got := executor.Calls(); !reflect.DeepEqual(got, []string{"systemctl suspend"}) {
+		t.Errorf("команды = %q, выполниться должно только последнее действие", got)
+	}
+}
+
+func TestConfirmation(t *testing.T) {
+	issued := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	data := ConfirmationData(ActionReboot, 5*time.Minute, issued)
+	if data != "reboot_300_1714564800" {
+		t.Errorf("ConfirmationData() = %q", data)
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		now     time.Time
+		wantErr error
+	}{
+		{"сразу", data, issued, nil},
+		{"в пределах срока", data, issued.Add(ConfirmationTTL), nil},
+		{"устарело", data, issued.Add(ConfirmationTTL + time.Second), ErrConfirmationExpired},
+		{"из будущего", data, issued.Add(-time.Minute), ErrConfirmationExpired},
+		{"старый формат без времени", "reboot_300", issued, errors.New("")},
+		{"неизвестное действие", "format_0_1714564800", issued, errors.New("")},
+		{"отрицательная задержка", "reboot_-5_1714564800", issued, errors.New("")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, delay, err := ParseConfirmation(tt.data, tt.now)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("ParseConfirmation() = %v", err)
+			case tt.wantErr == nil:
+				if action != ActionReboot || delay != 5*time.Minute {
+					t.Errorf("ParseConfirmation() = %s, %s", action, delay)
+				}
+			case err == nil:
+				t.Errorf("ParseConfirmation() без ошибки, ожидалась %v", tt.wantErr)
+			case errors.Is(tt.wantErr, ErrConfirmationExpired) && !errors.Is(err, ErrConfirmationExpired):
+				t.Errorf("ParseConfirmation() = %v, ожидалось ErrConfirmationExpired", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+)
+
+// Signal описывает сигнал, который можно отправить процессу
+type Signal string
+
+const (
+	SignalTerm Signal = "term" // Мягкое завершение (SIGTERM)
+	SignalKill Signal = "kill" // Принудительное завершение (SIGKILL)
+	SignalStop Signal = "stop" // Приостановка (SIGSTOP)
+	SignalCont Signal = "cont" // Возобновление (SIGCONT)
+)
+
+// ErrProtected возвращается при попытке управлять защищённым процессом
+var ErrProtected = errors.New("процесс защищён от управления")
+
+// protectedNames содержит имена системных процессов, которые нельзя трогать
+var protectedNames = map[string]bool{
+	"init":         true,
+	"systemd":      true,
+	"kthreadd":     true,
+	"launchd":      true,
+	"kernel_task":  true,
+	"system":       true,
+	"idle":         true,
+	"smss.exe":     true,
+	"csrss.exe":    true,
+	"wininit.exe":  true,
+	"winlogon.exe": true,
+	"services.exe": true,
+	"lsass.exe":    true,
+}
+
+// ParseSignal преобразует строку в сигнал
+func ParseSignal(s string) (Signal, bool) {
+	switch sig := Signal(strings.ToLower(s)); sig {
+	case SignalTerm, SignalKill, SignalStop, SignalCont:
+		return sig, true
+	}
+	return "", false
+}
+
+// Title возвращает название сигнала для пользователя
+func (s Signal) Title() string {
+	switch s {
+	case SignalTerm:
+		return "SIGTERM"
+	case SignalKill:
+		return "SIGKILL"
+	case SignalStop:
+		return "SIGSTOP"
+	case SignalCont:
+		return "SIGCONT"
+	default:
+		return string(s)
+	}
+}
+
+// SignalResult содержит результат отправки сигнала процессу
+type SignalResult struct {
+	PID    int32
+	Name   string
+	Exited bool   // Процесс завершился после сигнала
+	Status string // Состояние процесса после сигнала, если он жив
+}
+
+// ProcessManager отправляет сигналы процессам и меняет их приоритет
+type ProcessManager struct {
+	executor Executor
+	goos     string
+	selfPID  int32
+	exitWait time.Duration // Сколько ждать завершения процесса после SIGTERM/SIGKILL
+}
+
+// Processes — менеджер процессов, используемый ботом
+var Processes = NewProcessManager(SystemExecutor{}, runtime.GOOS)
+
+// NewProcessManager создаёт менеджер процессов для указанной ОС
+func NewProcessManager(executor Executor, goos string) *ProcessManager {
+	return &ProcessManager{
+		executor: executor,
+		goos:     goos,
+		selfPID:  int32(os.Getpid()),
+		exitWait: 5 * time.Second,
+	}
+}
+
+// CheckProtected возвращает ErrProtected, если процесс нельзя трогать
+func (m *ProcessManager) CheckProtected(pid int32) error {
+	if pid <= 2 {
+		return fmt.Errorf("PID %d: %w", pid, ErrProtected)
+	}
+	if pid == m.selfPID {
+		return fmt.Errorf("PID %d — это сам бот: %w", pid, ErrProtected)
+	}
+
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return err
+	}
+	name, _ := p.Name()
+	if protectedNames[strings.ToLower(name)] {
+		return fmt.Errorf("%s: %w", name, ErrProtected)
+	}
+	return nil
+}
+
+// SendSignal отправляет сигнал процессу и для завершающих сигналов ждёт его выхода
+func (m *ProcessManager) SendSignal(pid int32, sig Signal) (SignalResult, error) {
+	if err := m.CheckProtected(pid); err != nil {
+		return SignalResult{}, err
+	}
+
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return SignalResult{}, err
+	}
+	name, _ := p.Name()
+	result := SignalResult{PID: pid, Name: name}
+
+	switch sig {
+	case SignalTerm:
+		err = p.Terminate()
+	case SignalKill:
+		err = p.Kill()
+	case SignalStop:
+		err = p.Suspend()
+	case SignalCont:
+		err = p.Resume()
+	default:
+		return result, fmt.Errorf("неизвестный сигнал %q", sig)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if sig == SignalTerm || sig == SignalKill {
+		result.Exited = m.waitExit(pid)
+	}
+	if !result.Exited {
+		result.Status, _ = p.Status()
+	}
+	return result, nil
+}
+
+// waitExit ждёт завершения процесса не дольше exitWait
+func (m *ProcessManager) waitExit(pid int32) bool {
+	deadline := time.Now().Add(m.exitWait)
+	for {
+		exists, err := process.PidExists(pid)
+		if err == nil && !exists {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// Renice меняет приоритет (nice) процесса
+func (m *ProcessManager) Renice(pid int32, nice int) error {
+	if err := m.CheckProtected(pid); err != nil {
+		return err
+	}
+	if nice < -20 || nice > 19 {
+		return fmt.Errorf("значение nice должно быть от -20 до 19")
+	}
+
+	switch m.goos {
+	case "linux", "darwin", "freebsd":
+		return m.executor.Run("renice", "-n", strconv.Itoa(nice), "-p", strconv.Itoa(int(pid)))
+	}
+	return fmt.Errorf("renice на %s: %w", m.goos, ErrUnsupported)
+}
+
+// ErrProcessChanged возвращается, если за время подтверждения процесс завершился и его PID занят другим процессом
+var ErrProcessChanged = errors.New("процесс уже завершился, а его PID мог достаться другому процессу, выберите действие заново")
+
+// ProcessStartTime возвращает время запуска процесса (мс с начала эпохи). Вместе с PID оно
+// однозначно определяет процесс: переиспользованный PID получит другое время запуска
+func ProcessStartTime(pid int32) (int64, error) {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return 0, err
+	}
+	return p.CreateTime()
+}
+
+// ProcessConfirmationData кодирует PID, параметр действия (сигнал или nice), время запуска процесса
+// и время запроса подтверждения в строку "<pid>_<параметр>_<запуск>_<unix-время>" для callback-данных
+func ProcessConfirmationData(pid int32, arg string, started int64, now time.Time) string {
+	return fmt.Sprintf("%d_%s_%d_%d", pid, arg, started, now.Unix())
+}
+
+// ParseProcessConfirmation разбирает строку ProcessConfirmationData и проверяет, что подтверждение
+// не старше ConfirmationTTL и что под этим PID работает тот же процесс. Возвращает PID и параметр
+func ParseProcessConfirmation(data string, now time.Time) (int32, string, error) {
+	parts := strings.Split(data, "_")
+	if len(parts) != 4 {
+		return 0, "", fmt.Errorf("неверные данные подтверждения %q", data)
+	}
+	pid, err := strconv.ParseInt(parts[0], 10, 32)
+	if err != nil {
+		return 0, "", fmt.Errorf("неверный PID %q", parts[0])
+	}
+	started, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("неверное время запуска процесса %q", parts[2])
+	}
+	issued, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("неверное время подтверждения %q", parts[3])
+	}
+	if age := now.Sub(time.Unix(issued, 0)); age < 0 || age > ConfirmationTTL {
+		return 0, "", ErrConfirmationExpired
+	}
+	if current, err := ProcessStartTime(int32(pid)); err != nil || current != started {
+		return 0, "", ErrProcessChanged
+	}
+	return int32(pid), parts[1], nil
+}
+
+// FindProcesses ищет процессы по PID или по имени (без учёта регистра)
+func FindProcesses(query string) ([]*process.Process, error) {
+	if pid, err := strconv.ParseInt(query, 10, 32); err == nil {
+		p, err := process.NewProcess(int32(pid))
+		if err != nil {
+			return nil, err
+		}
+		return []*process.Process{p}, nil
+	}
+
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+
+	var found []*process.Process
+	for _, p := range procs {
+		name, err := p.Name()
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(name, query) || strings.EqualFold(strings.TrimSuffix(name, ".exe"), query) {
+			found = append(found, p)
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestProcessConfirmation(t *testing.T) {
+	pid := int32(os.Getpid())
+	started, err := ProcessStartTime(pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issued := time.Now().Truncate(time.Second) // В данных кнопки хранятся целые секунды
+	data := ProcessConfirmationData(pid, "-10", started, issued)
+	if want := fmt.Sprintf("%d_-10_%d_%d", pid, started, issued.Unix()); data != want {
+		t.Errorf("ProcessConfirmationData() = %q, ожидалось %q", data, want)
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		now     time.Time
+		wantErr error
+	}{
+		{"сразу", data, issued, nil},
+		{"в пределах срока", data, issued.Add(ConfirmationTTL), nil},
+		{"устарело", data, issued.Add(ConfirmationTTL + time.Second), ErrConfirmationExpired},
+		{"из будущего", data, issued.Add(-time.Minute), ErrConfirmationExpired},
+		// PID тот же, но время запуска другое — под этим PID уже другой процесс
+		{"PID переиспользован", ProcessConfirmationData(pid, "-10", started-1000, issued), issued, ErrProcessChanged},
+		{"старый формат без времени", fmt.Sprintf("%d_-10", pid), issued, errors.New("")},
+		{"неверный PID", fmt.Sprintf("x_-10_%d_%d", started, issued.Unix()), issued, errors.New("")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPID, arg, err := ParseProcessConfirmation(tt.data, tt.now)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("ParseProcessConfirmation() = %v", err)
+			case tt.wantErr == nil:
+				if gotPID != pid || arg != "-10" {
+					t.Errorf("ParseProcessConfirmation() = %d, %q", gotPID, arg)
+				}
+			case err == nil:
+				t.Errorf("ParseProcessConfirmation() без ошибки, ожидалась %v", tt.wantErr)
+			case errors.Is(tt.wantErr, ErrConfirmationExpired) && !errors.Is(err, ErrConfirmationExpired),
+				errors.Is(tt.wantErr, ErrProcessChanged) && !errors.Is(err, ErrProcessChanged):
+				t.Errorf("ParseProcessConfirmation() = %v, ожидалось %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Role определяет уровень доступа пользователя к боту
+type Role int
+
+const (
+	RoleNone   Role = iota // Доступ запрещён
+	RoleViewer             // Только просмотр информации
+	RoleAdmin              // Полный доступ, включая управление компьютером
+)
+
+// String возвращает название роли
+func (r Role) String() string {
+	switch r {
+	case RoleViewer:
+		return "viewer"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+var (
+	userRoles     map[int64]Role
+	userRolesOnce sync.Once
+)
+
+// loadUserRoles читает списки пользователей из TELEGRAM_ADMIN_IDS и TELEGRAM_VIEWER_IDS
+func loadUserRoles() {
+	userRoles = make(map[int64]Role)
+
+	for _, id := range parseIDList("TELEGRAM_VIEWER_IDS") {
+		userRoles[id] = RoleViewer
+	}
+	// Администраторы перекрывают роль наблюдателя, если ID указан в обоих списках
+	for _, id := range parseIDList("TELEGRAM_ADMIN_IDS") {
+		userRoles[id] = RoleAdmin
+	}
+
+	if len(userRoles) == 0 {
+		log.Println("TELEGRAM_ADMIN_IDS и TELEGRAM_VIEWER_IDS не заданы: доступ к боту запрещён всем пользователям")
+	}
+}
+
+// parseIDList разбирает список Telegram ID, разделённых запятыми или пробелами
+func parseIDList(key string) []int64 {
+	var ids []int64
+	for _, field := range strings.FieldsFunc(GetEnv(key), func(r rune) bool {
+		return r == ',' || r == ' ' || r == ';'
+	}) {
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			log.Printf("Некорректный ID пользователя %q в %s", field, key)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// GetUserRole возвращает роль пользователя Telegram
+func GetUserRole(userID int64) Role {
+	userRolesOnce.Do(loadUserRoles)
+	return userRoles[userID]
+}
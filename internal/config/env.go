@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,9 +16,77 @@ func LoadConfig() {
 	if err != nil {
 		log.Fatal("Ошибка загрузки .env файла")
 	}
+
+	// Загружаем списки пользователей, которым разрешён доступ к боту
+	userRolesOnce.Do(loadUserRoles)
 }
 
 // GetEnv возвращает значение переменной окружения
 func GetEnv(key string) string {
 	return os.Getenv(key)
 }
+
+// GetEnvInt возвращает целочисленную переменную окружения или значение по умолчанию
+func GetEnvInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %d", key, value, def)
+		return def
+	}
+	return n
+}
+
+// GetEnvDuration возвращает длительность из переменной окружения (например "10s", "24h") или значение по умолчанию
+func GetEnvDuration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Некорректное значение %s=%q, используется %s", key, value, def)
+		return def
+	}
+	return d
+}
+
+// GetGeoIPPaths возвращает пути к локальным базам GeoIP в формате MaxMind (mmdb):
+// страны или города (GEOIP_COUNTRY_DB) и автономных систем (GEOIP_ASN_DB)
+func GetGeoIPPaths() (countryDB, asnDB string) {
+	return os.Getenv("GEOIP_COUNTRY_DB"), os.Getenv("GEOIP_ASN_DB")
+}
+
+// GetSysfsRoot возвращает корень sysfs (SYSFS_ROOT, по умолчанию /sys). Позволяет читать показания
+// из каталога хоста, смонтированного в контейнер, или из подготовленного дерева файлов
+func GetSysfsRoot() string {
+	if root := os.Getenv("SYSFS_ROOT"); root != "" {
+		return root
+	}
+	return "/sys"
+}
+
+// GetProcfsRoot возвращает корень procfs (PROCFS_ROOT, по умолчанию /proc). Как и SYSFS_ROOT,
+// позволяет читать процессы хоста из контейнера или из подготовленного дерева файлов
+func GetProcfsRoot() string {
+	if root := os.Getenv("PROCFS_ROOT"); root != "" {
+		return root
+	}
+	return "/proc"
+}
+
+// GetEnvWithPrefix возвращает переменные окружения с префиксом prefix. Ключи — остаток
+// имени переменной в нижнем регистре: для WATCHDOG_CMD_NGINX и префикса WATCHDOG_CMD_ это "nginx"
+func GetEnvWithPrefix(prefix string) map[string]string {
+	values := make(map[string]string)
+	for _, entry := range os.Environ() {
+		key, value, _ := strings.Cut(entry, "=")
+		if name, ok := strings.CutPrefix(key, prefix); ok && name != "" && value != "" {
+			values[strings.ToLower(name)] = value
+		}
+	}
+	return values
+}
@@ -0,0 +1,81 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// metricWriter формирует ответ в текстовом формате Prometheus
+type metricWriter struct {
+	w        io.Writer
+	declared map[string]bool
+}
+
+// newMetricWriter создаёт writer поверх w
+func newMetricWriter(w io.Writer) *metricWriter {
+	return &metricWriter{w: w, declared: make(map[string]bool)}
+}
+
+// family объявляет семейство метрик (HELP и TYPE выводятся один раз)
+func (m *metricWriter) family(name, kind, help string) {
+	if m.declared[name] {
+		return
+	}
+	m.declared[name] = true
+	fmt.Fprintf(m.w, "# HELP %s %s\n", name, escapeHelp(help))
+	fmt.Fprintf(m.w, "# TYPE %s %s\n", name, kind)
+}
+
+// gauge выводит значение gauge-метрики
+func (m *metricWriter) gauge(name, help string, value float64, labels map[string]string) {
+	m.family(name, "gauge", help)
+	m.sample(name, value, labels)
+}
+
+// counter выводит значение counter-метрики
+func (m *metricWriter) counter(name, help string, value float64, labels map[string]string) {
+	m.family(name, "counter", help)
+	m.sample(name, value, labels)
+}
+
+// sample выводит одну строку метрики с метками
+func (m *metricWriter) sample(name string, value float64, labels map[string]string) {
+	fmt.Fprintf(m.w, "%s%s %s\n", name, formatLabels(labels), strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// formatLabels форматирует метки в виде {a="1",b="2"} в стабильном порядке
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=\"%s\"", k, escapeLabel(labels[k])))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// labelEscaper экранирует значения меток по правилам формата Prometheus
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel экранирует значение метки
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+// helpEscaper экранирует текст HELP
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// escapeHelp экранирует текст HELP
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
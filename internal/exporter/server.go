@@ -0,0 +1,186 @@
+package exporter
+
+import (
+	"TG_BOT_GO/internal/monitor"
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/host"
+	"github.com/shirou/gopsutil/mem"
+	"github.com/shirou/gopsutil/net"
+)
+
+// HealthFunc сообщает, жив ли опрос Telegram, и время последнего успешного запроса
+type HealthFunc func() (bool, time.Time)
+
+// Start запускает HTTP-сервер с /metrics и /healthz. Блокирует до ошибки сервера
+func Start(addr string, health HealthFunc) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", handleMetrics)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		handleHealthz(w, health)
+	})
+
+	log.Printf("Экспортёр метрик Prometheus слушает %s", addr)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return server.ListenAndServe()
+}
+
+// handleHealthz отвечает 200, если опрос Telegram жив, и 503 в противном случае
+func handleHealthz(w http.ResponseWriter, health HealthFunc) {
+	alive, last := health()
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	lastText := "never"
+	if !last.IsZero() {
+		lastText = last.Format(time.RFC3339)
+	}
+	if !alive {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "telegram poller: down (last poll: %s)\n", lastText)
+		return
+	}
+	fmt.Fprintf(w, "telegram poller: ok (last poll: %s)\n", lastText)
+}
+
+// handleMetrics отдаёт текущие показания в текстовом формате Prometheus
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	m := newMetricWriter(&buf)
+
+	m.gauge("pcbot_cpu_usage_percent", "CPU usage in percent.", monitor.CurrentValue(monitor.MetricCPU), nil)
+
+	if memInfo, err := mem.VirtualMemory(); err == nil {
+		m.gauge("pcbot_memory_usage_percent", "Memory usage in percent.", memInfo.UsedPercent, nil)
+		m.gauge("pcbot_memory_used_bytes", "Used memory in bytes.", float64(memInfo.Used), nil)
+		m.gauge("pcbot_memory_total_bytes", "Total memory in bytes.", float64(memInfo.Total), nil)
+	}
+
+	writeDiskMetrics(m)
+	writeNetworkMetrics(m)
+	writeTemperatureMetrics(m)
+
+	// Без видеокарт или их датчиков метрик нет вовсе, а не 0
+	if value, ok := monitor.LookupValue(monitor.MetricGPU); ok {
+		m.gauge("pcbot_gpu_usage_percent", "GPU utilization in percent (maximum across GPUs).", value, nil)
+	}
+	if value, ok := monitor.LookupValue(monitor.MetricGPUTemp); ok {
+		m.gauge("pcbot_gpu_temperature_celsius", "GPU temperature in degrees Celsius (maximum across GPUs).", value, nil)
+	}
+	writeGPUMetrics(m)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// writeGPUMetrics выводит показания каждой видеокарты
+func writeGPUMetrics(m *metricWriter) {
+	stats := monitor.GetGPUStats()
+	labels := func(s monitor.GPUStats) map[string]string {
+		return map[string]string{"gpu": strconv.Itoa(s.Index), "vendor": s.Vendor, "name": s.Name}
+	}
+	for _, s := range stats {
+		m.gauge("pcbot_gpu_device_usage_percent", "GPU utilization per device in percent.", s.Utilization, labels(s))
+	}
+	// Нулевые температура и объём видеопамяти означают, что видеокарта их не сообщает
+	for _, s := range stats {
+		if s.Temperature > 0 {
+			m.gauge("pcbot_gpu_device_temperature_celsius", "GPU temperature per device in degrees Celsius.", s.Temperature, labels(s))
+		}
+	}
+	for _, s := range stats {
+		if s.MemTotalMB > 0 {
+			m.gauge("pcbot_gpu_memory_used_bytes", "Used GPU memory per device in bytes.", s.MemUsedMB*1024*1024, labels(s))
+		}
+	}
+	for _, s := range stats {
+		if s.MemTotalMB > 0 {
+			m.gauge("pcbot_gpu_memory_total_bytes", "Total GPU memory per device in bytes.", s.MemTotalMB*1024*1024, labels(s))
+		}
+	}
+	for _, s := range stats {
+		m.gauge("pcbot_gpu_power_watts", "GPU power draw per device in watts.", s.PowerW, labels(s))
+	}
+}
+
+// writeDiskMetrics выводит использование каждого раздела
+func writeDiskMetrics(m *metricWriter) {
+	partitions, err := disk.Partitions(false)
+	if err != nil {
+		return
+	}
+
+	var usages []*disk.UsageStat
+	for _, partition := range partitions {
+		if usage, err := disk.Usage(partition.Mountpoint); err == nil {
+			usages = append(usages, usage)
+		}
+	}
+
+	for _, u := range usages {
+		m.gauge("pcbot_disk_usage_percent", "Disk usage per mountpoint in percent.", u.UsedPercent,
+			map[string]string{"mountpoint": u.Path, "fstype": u.Fstype})
+	}
+	for _, u := range usages {
+		m.gauge("pcbot_disk_used_bytes", "Used disk space per mountpoint in bytes.", float64(u.Used),
+			map[string]string{"mountpoint": u.Path, "fstype": u.Fstype})
+	}
+	for _, u := range usages {
+		m.gauge("pcbot_disk_total_bytes", "Total disk space per mountpoint in bytes.", float64(u.Total),
+			map[string]string{"mountpoint": u.Path, "fstype": u.Fstype})
+	}
+}
+
+// writeNetworkMetrics выводит счётчики каждого сетевого интерфейса
+func writeNetworkMetrics(m *metricWriter) {
+	counters, err := net.IOCounters(true)
+	if err != nil {
+		return
+	}
+
+	families := []struct {
+		name  string
+		help  string
+		value func(net.IOCountersStat) uint64
+	}{
+		{"pcbot_network_receive_bytes_total", "Received bytes per interface.", func(s net.IOCountersStat) uint64 { return s.BytesRecv }},
+		{"pcbot_network_transmit_bytes_total", "Transmitted bytes per interface.", func(s net.IOCountersStat) uint64 { return s.BytesSent }},
+		{"pcbot_network_receive_packets_total", "Received packets per interface.", func(s net.IOCountersStat) uint64 { return s.PacketsRecv }},
+		{"pcbot_network_transmit_packets_total", "Transmitted packets per interface.", func(s net.IOCountersStat) uint64 { return s.PacketsSent }},
+		{"pcbot_network_receive_errors_total", "Receive errors per interface.", func(s net.IOCountersStat) uint64 { return s.Errin }},
+		{"pcbot_network_transmit_errors_total", "Transmit errors per interface.", func(s net.IOCountersStat) uint64 { return s.Errout }},
+	}
+	for _, family := range families {
+		for _, stats := range counters {
+			m.counter(family.name, family.help, float64(family.value(stats)), map[string]string{"interface": stats.Name})
+		}
+	}
+}
+
+// writeTemperatureMetrics выводит показания всех датчиков температуры
+func writeTemperatureMetrics(m *metricWriter) {
+	temps, err := host.SensorsTemperatures()
+	if err != nil && len(temps) == 0 {
+		return
+	}
+	// Одинаковые ключи датчиков различаем порядковым номером, иначе Prometheus отвергнет дубликаты
+	seen := make(map[string]int)
+	for _, t := range temps {
+		sensor := t.SensorKey
+		seen[sensor]++
+		if n := seen[sensor]; n > 1 {
+			sensor = fmt.Sprintf("%s_%d", sensor, n)
+		}
+		m.gauge("pcbot_temperature_celsius", "Sensor temperature in degrees Celsius.", t.Temperature,
+			map[string]string{"sensor": sensor})
+	}
+}
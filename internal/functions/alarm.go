@@ -5,131 +5,239 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-// HandleAlarmCommandOutput возвращает результат команды /alarm в виде строки
-func HandleAlarmCommandOutput() string {
+// HandleAlarmCommandOutput возвращает результат команды /alarm для чата в виде строки
+func HandleAlarmCommandOutput(chatID int64) string {
+	sub, subscribed := monitor.GetSubscription(chatID)
+	if !subscribed {
+		return "🚨 Уведомления: чат не подписан\n\n⚠️ Используйте /rule add или /alarm_set для настройки правил и /alarm_on для подписки."
+	}
+
 	output := "🚨 Уведомления: "
-	if monitor.AlarmEnabled {
+	if sub.Enabled {
 		output += "Включены\n"
 	} else {
 		output += "Выключены\n"
 	}
-
-	// Проверяем, установлены ли пороговые значения
-	thresholdsSet := false
-	if monitor.AlarmThresholds.CPUTemp > 0 {
-		output += fmt.Sprintf("🌡️ Температура CPU: порог %.1f°C\n", monitor.AlarmThresholds.CPUTemp)
-		thresholdsSet = true
-	}
-	if monitor.AlarmThresholds.GPUTemp > 0 {
-		output += fmt.Sprintf("🌡️ Температура GPU: порог %.1f°C\n", monitor.AlarmThresholds.GPUTemp)
-		thresholdsSet = true
-	}
-	if monitor.AlarmThresholds.CPUUsage > 0 {
-		output += fmt.Sprintf("⚙️ Нагрузка CPU: порог %.1f%%\n", monitor.AlarmThresholds.CPUUsage)
-		thresholdsSet = true
-	}
-	if monitor.AlarmThresholds.GPUUsage > 0 {
-		output += fmt.Sprintf("🎮 Нагрузка GPU: порог %.1f%%\n", monitor.AlarmThresholds.GPUUsage)
-		thresholdsSet = true
-	}
-	if monitor.AlarmThresholds.MemoryUsage > 0 {
-		output += fmt.Sprintf("🧠 Память: порог %.1f%%\n", monitor.AlarmThresholds.MemoryUsage)
-		thresholdsSet = true
-	}
-	if monitor.AlarmThresholds.NetworkUsage > 0 {
-		output += fmt.Sprintf("🌐 Сеть: порог %.1f МБ/с\n", monitor.AlarmThresholds.NetworkUsage)
-		thresholdsSet = true
-	}
-	if monitor.AlarmThresholds.DiskUsage > 0 {
-		output += fmt.Sprintf("💽 Диски: порог %.1f%%\n", monitor.AlarmThresholds.DiskUsage)
-		thresholdsSet = true
+	if sub.Interval > 0 {
+		output += fmt.Sprintf("⏱️ Напоминание о продолжающемся превышении: каждые %d мин.\n", sub.Interval)
+	} else {
+		output += "⏱️ Напоминания о продолжающемся превышении выключены\n"
 	}
 
-	if !thresholdsSet {
-		output += "\n⚠️ Ни одно пороговое значение не установлено. Используйте /alarm_set для настройки."
+	if len(sub.Rules) == 0 {
+		return output + "\n⚠️ Ни одно правило не задано. Используйте /rule add или /alarm_set для настройки."
 	}
-
+	output += "\n📋 Правила:\n" + formatRules(sub, time.Now())
 	return output
 }
 
+// formatRules выводит правила подписки с состоянием и отметкой о заглушении
+func formatRules(sub monitor.Subscription, now time.Time) string {
+	states := monitor.AlarmStates(sub.ChatID)
+
+	var sb strings.Builder
+	for _, rule := range sub.Rules {
+		sb.WriteString(fmt.Sprintf("%s %s: %s", monitor.SeverityIcon(rule.Severity), rule.Name, rule))
+		if state, ok := states[rule.Name]; ok {
+			sb.WriteString(" — " + state.String())
+		}
+		if rule.Muted(now) {
+			sb.WriteString(fmt.Sprintf(" 🔇 до %s", rule.MutedUntil.Format("02.01 15:04")))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
 // HandleAlarmCommand обрабатывает команду /alarm
 func HandleAlarmCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
-	output := HandleAlarmCommandOutput()
+	output := HandleAlarmCommandOutput(update.Message.Chat.ID)
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, output)
 	bot.Send(msg)
 }
 
 // HandleAlarmOnCommand обрабатывает команду /alarm_on
 func HandleAlarmOnCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
-	if monitor.AlarmThresholds.CPUTemp == 0 && monitor.AlarmThresholds.GPUTemp == 0 &&
-		monitor.AlarmThresholds.CPUUsage == 0 && monitor.AlarmThresholds.GPUUsage == 0 &&
-		monitor.AlarmThresholds.MemoryUsage == 0 && monitor.AlarmThresholds.NetworkUsage == 0 &&
-		monitor.AlarmThresholds.DiskUsage == 0 {
-		msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Нельзя включить уведомления: пороговые значения не заданы.")
+	chatID := update.Message.Chat.ID
+
+	rules := monitor.DefaultRules
+	if sub, ok := monitor.GetSubscription(chatID); ok {
+		rules = sub.Rules
+	}
+	if len(rules) == 0 {
+		msg := tgbotapi.NewMessage(chatID, "Нельзя включить уведомления: правила не заданы.")
+		bot.Send(msg)
+		return
+	}
+
+	if _, err := monitor.UpdateSubscription(chatID, func(sub *monitor.Subscription) {
+		sub.Enabled = true
+	}); err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка при сохранении подписки.")
 		bot.Send(msg)
 		return
 	}
 
-	monitor.AlarmEnabled = true
-	msg := tgbotapi.NewMessage(update.Message.Chat.ID, "🚨 Уведомления включены.")
+	msg := tgbotapi.NewMessage(chatID, "🚨 Уведомления включены.")
 	bot.Send(msg)
 }
 
 // HandleAlarmOffCommand обрабатывает команду /alarm_off
 func HandleAlarmOffCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
-	monitor.AlarmEnabled = false
-	msg := tgbotapi.NewMessage(update.Message.Chat.ID, "🚨 Уведомления выключены.")
+	chatID := update.Message.Chat.ID
+
+	if _, ok := monitor.GetSubscription(chatID); ok {
+		if _, err := monitor.UpdateSubscription(chatID, func(sub *monitor.Subscription) {
+			sub.Enabled = false
+		}); err != nil {
+			msg := tgbotapi.NewMessage(chatID, "Ошибка при сохранении подписки.")
+			bot.Send(msg)
+			return
+		}
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "🚨 Уведомления выключены.")
+	bot.Send(msg)
+}
+
+// HandleAlarmUnsubscribeCommand обрабатывает команду /alarm_unsubscribe
+func HandleAlarmUnsubscribeCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
+	chatID := update.Message.Chat.ID
+
+	removed, err := monitor.Unsubscribe(chatID)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка при сохранении подписок.")
+		bot.Send(msg)
+		return
+	}
+	if !removed {
+		msg := tgbotapi.NewMessage(chatID, "Чат не подписан на уведомления.")
+		bot.Send(msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "🚨 Подписка на уведомления удалена, настройки чата сброшены.")
 	bot.Send(msg)
 }
 
-// HandleAlarmSetCommand обрабатывает команду /alarm_set
+// HandleAlarmIntervalCommand обрабатывает команду /alarm_interval
+func HandleAlarmIntervalCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
+	chatID := update.Message.Chat.ID
+
+	minutes, err := strconv.Atoi(strings.TrimSpace(update.Message.CommandArguments()))
+	if err != nil || minutes < 0 {
+		msg := tgbotapi.NewMessage(chatID, "Использование: /alarm_interval <минуты> — как часто напоминать о продолжающемся превышении (0 — не напоминать)")
+		bot.Send(msg)
+		return
+	}
+
+	if _, err := monitor.UpdateSubscription(chatID, func(sub *monitor.Subscription) {
+		sub.Interval = minutes
+	}); err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка при сохранении подписки.")
+		bot.Send(msg)
+		return
+	}
+
+	text := fmt.Sprintf("⏱️ Напоминание о продолжающемся превышении: каждые %d мин.", minutes)
+	if minutes == 0 {
+		text = "⏱️ Напоминания о продолжающемся превышении выключены."
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	bot.Send(msg)
+}
+
+// HandleAlarmSetCommand обрабатывает команду /alarm_set <параметр> <порог> [сброс] [длительность].
+// Это упрощённая форма /rule add: создаёт правило "больше порога" с именем параметра
 func HandleAlarmSetCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
+	chatID := update.Message.Chat.ID
 	args := strings.Fields(update.Message.CommandArguments())
-	if len(args) != 2 {
-		msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Использование: /alarm_set <параметр> <значение>")
+	if len(args) < 2 || len(args) > 4 {
+		msg := tgbotapi.NewMessage(chatID, "Использование: /alarm_set <параметр> <порог> [сброс] [длительность]\n"+
+			"Например: /alarm_set cpu_usage 90 80 60s — уведомить, если CPU выше 90% дольше минуты, и сообщить о восстановлении, когда станет не выше 80%.\n"+
+			"Параметры: cpu_temp, gpu_temp, cpu_usage, gpu_usage, memory_usage, network_usage, disk_usage. Порог 0 удаляет правило.\n"+
+			"Для других метрик и условий используйте /rule.")
 		bot.Send(msg)
 		return
 	}
 
-	param := args[0]
+	name, metric, ok := monitor.LegacyParam(args[0])
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, "Некорректный параметр.")
+		bot.Send(msg)
+		return
+	}
 	value, err := strconv.ParseFloat(args[1], 64)
 	if err != nil {
-		msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Некорректное значение.")
+		msg := tgbotapi.NewMessage(chatID, "Некорректное значение.")
 		bot.Send(msg)
 		return
 	}
 
-	switch param {
-	case "cpu_temp":
-		monitor.AlarmThresholds.CPUTemp = value
-	case "gpu_tmp":
-		monitor.AlarmThresholds.GPUTemp = value
-	case "cpu_usage":
-		monitor.AlarmThresholds.CPUUsage = value
-	case "gpu_usage":
-		monitor.AlarmThresholds.GPUUsage = value
-	case "memory_usage":
-		monitor.AlarmThresholds.MemoryUsage = value
-	case "network_usage":
-		monitor.AlarmThresholds.NetworkUsage = value
-	case "disk_usage":
-		monitor.AlarmThresholds.DiskUsage = value
-	default:
-		msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Некорректный параметр.")
+	rule := monitor.Rule{Name: name, Metric: metric, Op: ">", Threshold: value}
+	if len(args) > 2 {
+		clear, err := strconv.ParseFloat(args[2], 64)
+		if err != nil {
+			msg := tgbotapi.NewMessage(chatID, "Некорректный порог сброса.")
+			bot.Send(msg)
+			return
+		}
+		rule.Clear = &clear
+	}
+	if len(args) > 3 {
+		hold, err := parseHold(args[3])
+		if err != nil {
+			msg := tgbotapi.NewMessage(chatID, "Некорректная длительность: укажите секунды или, например, 30s, 5m.")
+			bot.Send(msg)
+			return
+		}
+		rule.Hold = int(hold / time.Second)
+	}
+
+	// Нулевой порог, как и раньше, отключает проверку параметра
+	if value == 0 {
+		if _, err := monitor.UpdateSubscription(chatID, func(sub *monitor.Subscription) {
+			sub.DeleteRule(name)
+		}); err != nil {
+			msg := tgbotapi.NewMessage(chatID, "Ошибка при сохранении правил.")
+			bot.Send(msg)
+			return
+		}
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🚨 Порог для %s отключён.", name))
 		bot.Send(msg)
 		return
 	}
 
-	if err := monitor.SaveThresholds(); err != nil {
-		msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Ошибка при сохранении пороговых значений.")
+	if err := rule.Validate(); err != nil {
+		msg := tgbotapi.NewMessage(chatID, "❌ "+err.Error())
+		bot.Send(msg)
+		return
+	}
+	if _, err := monitor.UpdateSubscription(chatID, func(sub *monitor.Subscription) {
+		sub.SetRule(rule)
+	}); err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка при сохранении правил.")
 		bot.Send(msg)
 		return
 	}
 
-	msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("🚨 Порог для %s установлен на %.1f.", param, value))
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🚨 Правило %s: %s.", rule.Name, rule))
 	bot.Send(msg)
 }
+
+// parseHold разбирает длительность превышения: число секунд или длительность Go ("30s", "5m")
+func parseHold(s string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(s); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("некорректная длительность %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,133 @@
+package functions
+
+import (
+	"TG_BOT_GO/internal/chart"
+	"TG_BOT_GO/internal/monitor"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// chartPoints — примерное количество точек на графике
+const chartPoints = 300
+
+// chartGroups задаёт наборы метрик для коротких названий графиков
+var chartGroups = map[string][]string{
+	"cpu":    {monitor.MetricCPU},
+	"memory": {monitor.MetricMemory},
+	"gpu":    {monitor.MetricGPU},
+	"disk":   {monitor.MetricDisk},
+	"net":    {monitor.MetricNetDown, monitor.MetricNetUp},
+	"temp":   {monitor.MetricCPUTemp, monitor.MetricGPUTemp},
+}
+
+// RenderChart строит PNG-график метрики (или группы метрик) за окно
+func RenderChart(name string, window time.Duration) ([]byte, error) {
+	metricNames, ok := chartGroups[name]
+	if !ok {
+		metricNames = []string{name}
+	}
+
+	now := time.Now()
+	step := window / chartPoints
+	if step < time.Second {
+		step = time.Second
+	}
+
+	c := chart.Chart{
+		Title: fmt.Sprintf("%s, %s", name, formatWindow(window)),
+		Unit:  monitor.MetricUnit(metricNames[0]),
+		From:  now.Add(-window),
+		To:    now,
+	}
+	if c.Unit == "%" {
+		c.FixedY, c.MinY, c.MaxY = true, 0, 100
+	}
+
+	for _, metric := range metricNames {
+		points, err := monitor.QueryHistory(metric, now.Add(-window), now, step)
+		if err != nil {
+			return nil, err
+		}
+		series := chart.Series{Name: metric}
+		for _, p := range points {
+			series.Points = append(series.Points, chart.Point{Time: p.Time, Value: p.Avg})
+		}
+		c.Series = append(c.Series, series)
+	}
+
+	return c.PNG()
+}
+
+// formatWindow форматирует окно коротко: 30m, 6h, 7d
+func formatWindow(window time.Duration) string {
+	switch {
+	case window%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", window/(24*time.Hour))
+	case window%time.Hour == 0:
+		return fmt.Sprintf("%dh", window/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", window/time.Minute)
+	}
+}
+
+// SendChart строит график и отправляет его в чат фотографией
+func SendChart(chatID int64, name string, window time.Duration, bot *tgbotapi.BotAPI) {
+	data, err := RenderChart(name, window)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Не удалось построить график %s: %v", name, err))
+		bot.Send(msg)
+		return
+	}
+
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "chart.png", Bytes: data})
+	photo.Caption = fmt.Sprintf("📈 %s за %s", name, formatWindow(window))
+	bot.Send(photo)
+}
+
+// ChartMenu возвращает текст и клавиатуру выбора графика
+func ChartMenu() (string, tgbotapi.InlineKeyboardMarkup) {
+	rows := [][2]string{
+		{"⚙️ CPU", "cpu"},
+		{"🧠 Память", "memory"},
+		{"🌐 Сеть", "net"},
+		{"🌡️ Температура", "temp"},
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup()
+	for _, row := range rows {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(row[0]+" 1ч", "chart_"+row[1]+"_1h"),
+			tgbotapi.NewInlineKeyboardButtonData("24ч", "chart_"+row[1]+"_24h"),
+			tgbotapi.NewInlineKeyboardButtonData("7д", "chart_"+row[1]+"_7d"),
+		))
+	}
+	return "📈 Выберите график:", keyboard
+}
+
+// HandleChartCommand обрабатывает команду /chart <метрика> [окно]
+func HandleChartCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
+	chatID := update.Message.Chat.ID
+	args := strings.Fields(update.Message.CommandArguments())
+	if len(args) == 0 {
+		text, keyboard := ChartMenu()
+		msg := tgbotapi.NewMessage(chatID, text+"\n\nИли: /chart <cpu|memory|net|temp|gpu|disk|метрика> [окно], например /chart cpu 24h")
+		msg.ReplyMarkup = keyboard
+		bot.Send(msg)
+		return
+	}
+
+	window := time.Hour
+	if len(args) > 1 {
+		var err error
+		if window, err = ParseWindow(args[1]); err != nil {
+			msg := tgbotapi.NewMessage(chatID, "❌ "+err.Error())
+			bot.Send(msg)
+			return
+		}
+	}
+
+	SendChart(chatID, args[0], window, bot)
+}
@@ -0,0 +1,170 @@
+package functions
+
+import (
+	"TG_BOT_GO/internal/geoip"
+	"TG_BOT_GO/internal/monitor"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Ограничения вывода /connections
+const (
+	connectionsMaxProcesses = 15
+	connectionsMaxHosts     = 20
+	connectionsMaxNames     = 3 // Процессов в строке удалённого узла
+)
+
+// connectionsHiddenFormat — строка о группах соединений, не поместившихся в сообщение
+const connectionsHiddenFormat = "\n… и ещё %d строк не поместилось в сообщение\n"
+
+// connectionGroup — соединения, сгруппированные по процессу или удалённому узлу
+type connectionGroup struct {
+	title     string
+	total     int
+	states    map[string]int
+	processes map[string]bool // Для групп по узлу — процессы, открывшие соединения
+}
+
+// add учитывает соединение в группе
+func (g *connectionGroup) add(c monitor.Connection) {
+	g.total++
+	state := c.Status
+	if state == "" {
+		state = strings.ToUpper(c.Proto)
+	}
+	g.states[state]++
+}
+
+// formatStates выводит счётчики состояний: "ESTABLISHED 10, TIME_WAIT 2"
+func formatStates(states map[string]int) string {
+	keys := make([]string, 0, len(states))
+	for state := range states {
+		keys = append(keys, state)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if states[keys[i]] != states[keys[j]] {
+			return states[keys[i]] > states[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	parts := make([]string, len(keys))
+	for i, state := range keys {
+		parts[i] = fmt.Sprintf("%s %d", state, states[state])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortedGroups упорядочивает группы по числу соединений
+func sortedGroups(groups map[string]*connectionGroup) []*connectionGroup {
+	list := make([]*connectionGroup, 0, len(groups))
+	for _, g := range groups {
+		list = append(list, g)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].total != list[j].total {
+			return list[i].total > list[j].total
+		}
+		return list[i].title < list[j].title
+	})
+	return list
+}
+
+// HandleConnectionsCommandOutput возвращает активные соединения, сгруппированные по процессам и удалённым узлам
+func HandleConnectionsCommandOutput() string {
+	conns, err := monitor.ActiveConnections()
+	if err != nil {
+		return "❌ Ошибка при получении соединений: " + err.Error()
+	}
+	if len(conns) == 0 {
+		return "🔗 Активных соединений нет (или недостаточно прав, чтобы их увидеть)"
+	}
+	return formatConnections(conns)
+}
+
+// formatConnections группирует соединения и выводит их, укладываясь в одно сообщение Telegram
+func formatConnections(conns []monitor.Connection) string {
+	totalStates := make(map[string]int)
+	byProcess := make(map[string]*connectionGroup)
+	byHost := make(map[string]*connectionGroup)
+	for _, c := range conns {
+		state := c.Status
+		if state == "" {
+			state = strings.ToUpper(c.Proto)
+		}
+		totalStates[state]++
+
+		owner := "без процесса"
+		if c.PID > 0 {
+			owner = fmt.Sprintf("%s [PID: %d]", c.Process, c.PID)
+		}
+		if byProcess[owner] == nil {
+			byProcess[owner] = &connectionGroup{title: owner, states: make(map[string]int)}
+		}
+		byProcess[owner].add(c)
+
+		if byHost[c.RemoteIP] == nil {
+			byHost[c.RemoteIP] = &connectionGroup{title: c.RemoteIP, states: make(map[string]int), processes: make(map[string]bool)}
+		}
+		byHost[c.RemoteIP].add(c)
+		if c.Process != "" {
+			byHost[c.RemoteIP].processes[c.Process] = true
+		}
+	}
+
+	footer := "+------------------------------+"
+	if !geoip.Available() {
+		footer = "\nℹ️ Страна и провайдер не определяются: укажите базы mmdb в GEOIP_COUNTRY_DB и GEOIP_ASN_DB\n" + footer
+	}
+	header := "+------------------------------+\n" +
+		"| 🔗 Соединения:                \n" +
+		"+------------------------------+\n" +
+		fmt.Sprintf("Всего: %d (%s)\n", len(conns), formatStates(totalStates))
+	// Место под подвал и строку о скрытых строках
+	m := newLimitedMessage(header, utf16Len(footer)+utf16Len(fmt.Sprintf(connectionsHiddenFormat, len(byProcess)+len(byHost))))
+
+	m.line("\n🖥️ По процессам:", false)
+	for i, g := range sortedGroups(byProcess) {
+		if i >= connectionsMaxProcesses {
+			m.line(fmt.Sprintf("  ... и ещё %d", len(byProcess)-connectionsMaxProcesses), false)
+			break
+		}
+		m.line(fmt.Sprintf("  %s: %d (%s)", g.title, g.total, formatStates(g.states)), true)
+	}
+
+	m.line("\n🌍 По удалённым узлам:", false)
+	for i, g := range sortedGroups(byHost) {
+		if i >= connectionsMaxHosts {
+			m.line(fmt.Sprintf("  ... и ещё %d", len(byHost)-connectionsMaxHosts), false)
+			break
+		}
+		line := "  " + g.title
+		if info := geoip.Lookup(net.ParseIP(g.title)).String(); info != "" {
+			line += " (" + info + ")"
+		}
+		line += fmt.Sprintf(": %d (%s)", g.total, formatStates(g.states))
+
+		names := make([]string, 0, len(g.processes))
+		for name := range g.processes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) > connectionsMaxNames {
+			names = append(names[:connectionsMaxNames], "…")
+		}
+		if len(names) > 0 {
+			line += " — " + strings.Join(names, ", ")
+		}
+		m.line(line, true)
+	}
+	return m.finish(connectionsHiddenFormat, footer)
+}
+
+// HandleConnectionsCommand обрабатывает команду /connections
+func HandleConnectionsCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, HandleConnectionsCommandOutput())
+	bot.Send(msg)
+}
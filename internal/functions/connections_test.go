@@ -0,0 +1,42 @@
+package functions
+
+import (
+	"TG_BOT_GO/internal/monitor"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFormatConnectionsFitsTelegramLimit(t *testing.T) {
+	states := []string{"ESTABLISHED", "TIME_WAIT", "CLOSE_WAIT", "FIN_WAIT1", "FIN_WAIT2", "SYN_SENT", "LAST_ACK", "CLOSING"}
+	var conns []monitor.Connection
+	for host := 0; host < 30; host++ {
+		for i, state := range states {
+			pid := int32(1000 + (host+i)%20)
+			conns = append(conns, monitor.Connection{
+				Proto:    "tcp",
+				RemoteIP: fmt.Sprintf("2001:db8:ffff:ffff:ffff:ffff:ffff:%x", host),
+				Status:   state,
+				PID:      pid,
+				Process:  fmt.Sprintf("очень-длинное-имя-рабочего-процесса-сервиса-%d", pid),
+			})
+		}
+	}
+
+	text := formatConnections(conns)
+	if n := utf16Len(text); n > telegramMessageLimit {
+		t.Fatalf("длина сообщения %d, ожидалось не больше %d", n, telegramMessageLimit)
+	}
+	if !strings.Contains(text, "не поместилось в сообщение") || !strings.HasSuffix(text, "+------------------------------+") {
+		t.Errorf("нет строки о скрытых строках или подвала:\n%s", text)
+	}
+	if !strings.HasPrefix(text, "+------------------------------+\n| 🔗 Соединения:") || !strings.Contains(text, "Всего: 240") {
+		t.Errorf("нет заголовка:\n%s", text)
+	}
+
+	// Несколько соединений выводятся целиком
+	text = formatConnections(conns[:3])
+	if strings.Contains(text, "не поместилось") || !strings.Contains(text, "2001:db8:ffff:ffff:ffff:ffff:ffff:0: 3") {
+		t.Errorf("короткий список обрезан:\n%s", text)
+	}
+}
@@ -0,0 +1,163 @@
+package functions
+
+import (
+	"TG_BOT_GO/internal/monitor"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// historyBuckets — количество строк в таблице истории
+const historyBuckets = 12
+
+// sparkChars — символы для мини-графика
+var sparkChars = []rune("▁▂▃▄▅▆▇█")
+
+// ParseWindow разбирает длительность окна: поддерживает единицы Go ("90m", "1h30m") а также дни и недели ("7d", "2w")
+func ParseWindow(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("пустое окно")
+	}
+
+	var multiplier time.Duration
+	switch s[len(s)-1] {
+	case 'd':
+		multiplier = 24 * time.Hour
+	case 'w':
+		multiplier = 7 * 24 * time.Hour
+	}
+	if multiplier > 0 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("некорректное окно %q", s)
+		}
+		return time.Duration(n) * multiplier, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("некорректное окно %q", s)
+	}
+	return d, nil
+}
+
+// parseMoment разбирает момент времени: "03:00" (последнее наступившее), "2026-10-17 03:00" или "2026-10-17T03:00"
+func parseMoment(args []string, now time.Time) (time.Time, error) {
+	value := strings.Join(args, " ")
+	if t, err := time.ParseInLocation("15:04", value, time.Local); err == nil {
+		moment := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.Local)
+		if moment.After(now) {
+			moment = moment.AddDate(0, 0, -1)
+		}
+		return moment, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", "02.01.2006 15:04"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("некорректное время %q", value)
+}
+
+// HandleHistoryCommandOutput возвращает результат команды /history в виде строки
+func HandleHistoryCommandOutput(args []string) string {
+	if len(args) == 0 {
+		return "Использование:\n/history <метрика> [окно] — например /history cpu 24h\n" +
+			"/history <метрика> at <время> — например /history cpu_temp at 03:00\n\n" +
+			"Метрики: " + strings.Join(monitor.MetricNames(), ", ")
+	}
+	metric := args[0]
+	unit := monitor.MetricUnit(metric)
+	now := time.Now()
+
+	// Значение в конкретный момент времени
+	if len(args) > 1 && args[1] == "at" {
+		moment, err := parseMoment(args[2:], now)
+		if err != nil {
+			return "❌ " + err.Error()
+		}
+
+		// Сначала ищем минутные данные, затем часовые агрегаты
+		for _, step := range []time.Duration{time.Minute, time.Hour} {
+			points, err := monitor.QueryHistory(metric, moment.Add(-step/2), moment.Add(step/2), step)
+			if err != nil {
+				return "❌ Ошибка при чтении истории: " + err.Error()
+			}
+			if len(points) > 0 {
+				p := points[0]
+				return fmt.Sprintf("🕒 %s в %s: %.1f%s (мин %.1f, макс %.1f, измерений: %d)",
+					metric, moment.Format("02.01 15:04"), p.Avg, unit, p.Min, p.Max, p.Count)
+			}
+		}
+		return fmt.Sprintf("❌ Нет данных %s на %s", metric, moment.Format("02.01 15:04"))
+	}
+
+	window := 24 * time.Hour
+	if len(args) > 1 {
+		var err error
+		if window, err = ParseWindow(args[1]); err != nil {
+			return "❌ " + err.Error()
+		}
+	}
+
+	step := (window / historyBuckets).Truncate(time.Minute)
+	if step < time.Minute {
+		step = time.Minute
+	}
+	points, err := monitor.QueryHistory(metric, now.Add(-window), now, step)
+	if err != nil {
+		return "❌ Ошибка при чтении истории: " + err.Error()
+	}
+	if len(points) == 0 {
+		return fmt.Sprintf("❌ Нет данных для метрики %s за %s", metric, window)
+	}
+
+	// Общие минимум, среднее и максимум по окну
+	minValue, maxValue := points[0].Min, points[0].Max
+	var sum float64
+	var count int
+	for _, p := range points {
+		if p.Min < minValue {
+			minValue = p.Min
+		}
+		if p.Max > maxValue {
+			maxValue = p.Max
+		}
+		sum += p.Avg * float64(p.Count)
+		count += p.Count
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📜 История %s за %s\n", metric, window))
+	sb.WriteString(fmt.Sprintf("📉 Мин %.1f%s, сред %.1f%s, макс %.1f%s\n\n",
+		minValue, unit, sum/float64(count), unit, maxValue, unit))
+
+	timeLayout := "15:04"
+	if window > 24*time.Hour {
+		timeLayout = "02.01 15:04"
+	}
+	for _, p := range points {
+		sb.WriteString(fmt.Sprintf("%s %c %.1f%s (%.1f–%.1f)\n",
+			p.Time.Format(timeLayout), sparkChar(p.Avg, minValue, maxValue), p.Avg, unit, p.Min, p.Max))
+	}
+	return sb.String()
+}
+
+// sparkChar подбирает символ мини-графика для значения в диапазоне [lo, hi]
+func sparkChar(value, lo, hi float64) rune {
+	if hi <= lo {
+		return sparkChars[0]
+	}
+	idx := int((value - lo) / (hi - lo) * float64(len(sparkChars)-1))
+	return sparkChars[max(0, min(idx, len(sparkChars)-1))]
+}
+
+// HandleHistoryCommand обрабатывает команду /history
+func HandleHistoryCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
+	output := HandleHistoryCommandOutput(strings.Fields(update.Message.CommandArguments()))
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, output)
+	bot.Send(msg)
+}
@@ -0,0 +1,61 @@
+package functions
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// telegramMessageLimit — максимальная длина сообщения Telegram в UTF-16 символах
+const telegramMessageLimit = 4096
+
+// utf16Len возвращает длину строки в UTF-16 символах, как её считает Telegram
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// limitedMessage собирает текст из строк, укладываясь в одно сообщение Telegram. Строки после
+// первой не поместившейся отбрасываются, а в конце сообщается, сколько их не вошло
+type limitedMessage struct {
+	sb     strings.Builder
+	used   int
+	budget int
+	full   bool
+	hidden int // Отброшенные строки, учитываемые в итоговой строке
+}
+
+// newLimitedMessage начинает сообщение с заголовка header, оставляя reserve символов под окончание
+func newLimitedMessage(header string, reserve int) *limitedMessage {
+	m := &limitedMessage{budget: telegramMessageLimit - reserve}
+	m.sb.WriteString(header)
+	m.used = utf16Len(header)
+	return m
+}
+
+// line добавляет строку, если она помещается. counted — учитывать ли строку в числе
+// непоместившихся (заголовки разделов обычно не учитываются)
+func (m *limitedMessage) line(text string, counted bool) {
+	size := utf16Len(text) + 1
+	if m.full || m.used+size > m.budget {
+		m.full = true
+		if counted {
+			m.hidden++
+		}
+		return
+	}
+	m.sb.WriteString(text + "\n")
+	m.used += size
+}
+
+// finish дописывает строку о непоместившихся строках по формату hiddenFormat и подвал footer
+func (m *limitedMessage) finish(hiddenFormat, footer string) string {
+	if m.hidden > 0 {
+		m.sb.WriteString(fmt.Sprintf(hiddenFormat, m.hidden))
+	}
+	m.sb.WriteString(footer)
+	return m.sb.String()
+}
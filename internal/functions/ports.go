@@ -0,0 +1,77 @@
+package functions
+
+import (
+	"TG_BOT_GO/internal/monitor"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// portsHiddenFormat — строка о портах, не поместившихся в сообщение
+const portsHiddenFormat = "\n… и ещё %d портов не поместилось в сообщение\n"
+
+// HandlePortsCommandOutput возвращает список слушающих портов, разделённый на доступные извне и локальные
+func HandlePortsCommandOutput() string {
+	ports, err := monitor.ListeningPorts()
+	if err != nil {
+		return "❌ Ошибка при получении списка портов: " + err.Error()
+	}
+	if len(ports) == 0 {
+		return "🔌 Слушающих портов нет (или недостаточно прав, чтобы их увидеть)"
+	}
+	return formatPorts(ports, monitor.LoadPortAllowList())
+}
+
+// formatPorts выводит порты, укладываясь в одно сообщение Telegram. Доступные извне порты идут
+// первыми, поэтому при обрезке пропадают прежде всего локальные
+func formatPorts(ports []monitor.ListeningPort, allow monitor.PortAllowList) string {
+	var exposed, local []string
+	for _, p := range ports {
+		owner := "?"
+		if p.PID > 0 {
+			owner = fmt.Sprintf("%s [PID: %d]", p.Process, p.PID)
+		}
+		line := fmt.Sprintf("%s — %s", p.Key(), owner)
+		if !p.Exposed {
+			local = append(local, "  "+line)
+			continue
+		}
+		// Порты извне, которых нет в списке ожидаемых, выделяем
+		mark := "⚠️"
+		if allow.Allowed(p) {
+			mark = "✅"
+		}
+		exposed = append(exposed, "  "+mark+" "+line)
+	}
+
+	var footer strings.Builder
+	if len(allow) == 0 {
+		footer.WriteString("\nОжидаемые порты можно перечислить в PORTS_ALLOW, например 22,80,443/tcp\n")
+	} else {
+		footer.WriteString("\n✅ — порт из PORTS_ALLOW, ⚠️ — неожиданный порт\n")
+	}
+	footer.WriteString("+------------------------------+")
+
+	header := "+------------------------------+\n" +
+		"| 🔌 Слушающие порты:           \n" +
+		"+------------------------------+\n"
+	// Место под подвал и строку о скрытых портах
+	m := newLimitedMessage(header, utf16Len(footer.String())+utf16Len(fmt.Sprintf(portsHiddenFormat, len(ports))))
+
+	m.line(fmt.Sprintf("🌐 Доступны извне (%d):", len(exposed)), false)
+	for _, line := range exposed {
+		m.line(line, true)
+	}
+	m.line(fmt.Sprintf("\n🏠 Только локально (%d):", len(local)), false)
+	for _, line := range local {
+		m.line(line, true)
+	}
+	return m.finish(portsHiddenFormat, footer.String())
+}
+
+// HandlePortsCommand обрабатывает команду /ports
+func HandlePortsCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, HandlePortsCommandOutput())
+	bot.Send(msg)
+}
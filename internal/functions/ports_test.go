@@ -0,0 +1,41 @@
+package functions
+
+import (
+	"TG_BOT_GO/internal/monitor"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFormatPortsFitsTelegramLimit(t *testing.T) {
+	var ports []monitor.ListeningPort
+	for i := 0; i < 400; i++ {
+		ports = append(ports, monitor.ListeningPort{
+			Proto: "tcp", Addr: "127.0.0.1", Port: uint32(20000 + i), PID: int32(1000 + i),
+			Process: "очень-длинное-имя-процесса", Exposed: i < 5,
+		})
+	}
+	allow := monitor.PortAllowList{"20000/": true}
+
+	text := formatPorts(ports, allow)
+	if n := utf16Len(text); n > telegramMessageLimit {
+		t.Fatalf("длина сообщения %d, ожидалось не больше %d", n, telegramMessageLimit)
+	}
+	// Порты извне показываются первыми и не теряются
+	if !strings.Contains(text, "✅ tcp 127.0.0.1:20000") || !strings.Contains(text, "⚠️ tcp 127.0.0.1:20004") {
+		t.Errorf("нет портов, доступных извне:\n%s", text)
+	}
+	if !strings.Contains(text, "не поместилось в сообщение") || !strings.HasSuffix(text, "+------------------------------+") {
+		t.Errorf("нет строки о скрытых портах или подвала:\n%s", text)
+	}
+
+	shown := strings.Count(text, "\n  ")
+	if !strings.Contains(text, fmt.Sprintf("и ещё %d портов", len(ports)-shown)) {
+		t.Errorf("показано %d портов, но число скрытых не сходится:\n%s", shown, text)
+	}
+
+	// Короткий список выводится целиком
+	if text := formatPorts(ports[:3], allow); strings.Contains(text, "не поместилось") {
+		t.Errorf("короткий список обрезан:\n%s", text)
+	}
+}
@@ -0,0 +1,185 @@
+package functions
+
+import (
+	"TG_BOT_GO/internal/commands"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/shirou/gopsutil/process"
+)
+
+// niceValues содержит значения nice, предлагаемые кнопками
+var niceValues = []int{-10, -5, 0, 5, 10, 19}
+
+// processActionsKeyboard возвращает клавиатуру действий над процессом и переход к родителю
+func processActionsKeyboard(pid, ppid int32) tgbotapi.InlineKeyboardMarkup {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🛑 SIGTERM", fmt.Sprintf("procsig_%d_%s", pid, commands.SignalTerm)),
+			tgbotapi.NewInlineKeyboardButtonData("💀 SIGKILL", fmt.Sprintf("procsig_%d_%s", pid, commands.SignalKill)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⏸️ SIGSTOP", fmt.Sprintf("procsig_%d_%s", pid, commands.SignalStop)),
+			tgbotapi.NewInlineKeyboardButtonData("▶️ SIGCONT", fmt.Sprintf("procsig_%d_%s", pid, commands.SignalCont)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⚖️ Изменить nice", fmt.Sprintf("procnice_%d", pid)),
+			tgbotapi.NewInlineKeyboardButtonData("🔄 Обновить", fmt.Sprintf("procinfo_%d", pid)),
+		),
+	)
+	if ppid > 0 {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("⬆️ Родитель [%d]", ppid), fmt.Sprintf("procinfo_%d", ppid)),
+		))
+	}
+	return keyboard
+}
+
+// FormatSignalConfirmation формирует запрос подтверждения отправки сигнала. Кнопка подтверждения
+// действует ConfirmationTTL и только для того же процесса (PID и время запуска)
+func FormatSignalConfirmation(pid int32, sig commands.Signal) (string, tgbotapi.InlineKeyboardMarkup) {
+	started, err := commands.ProcessStartTime(pid)
+	if err != nil {
+		return fmt.Sprintf("❌ Процесс с PID %d не найден", pid), tgbotapi.NewInlineKeyboardMarkup()
+	}
+	name := processName(pid)
+	text := fmt.Sprintf("⚠️ Отправить %s процессу %s [PID: %d]?", sig.Title(), name, pid)
+	data := commands.ProcessConfirmationData(pid, string(sig), started, time.Now())
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Подтвердить", "procsigok_"+data),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", fmt.Sprintf("procinfo_%d", pid)),
+		),
+	)
+	return text, keyboard
+}
+
+// ExecuteSignal отправляет подтверждённый сигнал и формирует сообщение о результате
+func ExecuteSignal(pid int32, sig commands.Signal) string {
+	result, err := commands.Processes.SendSignal(pid, sig)
+	if errors.Is(err, commands.ErrProtected) {
+		return fmt.Sprintf("🛡️ %v", err)
+	}
+	if err != nil {
+		return fmt.Sprintf("❌ Не удалось отправить %s процессу %d: %v", sig.Title(), pid, err)
+	}
+
+	switch {
+	case result.Exited:
+		return fmt.Sprintf("✅ Процесс %s [PID: %d] завершился после %s.", result.Name, pid, sig.Title())
+	case sig == commands.SignalTerm || sig == commands.SignalKill:
+		return fmt.Sprintf("⚠️ %s отправлен, но процесс %s [PID: %d] всё ещё работает (состояние: %s).",
+			sig.Title(), result.Name, pid, result.Status)
+	default:
+		return fmt.Sprintf("✅ %s отправлен процессу %s [PID: %d], состояние: %s.",
+			sig.Title(), result.Name, pid, result.Status)
+	}
+}
+
+// FormatNiceMenu формирует меню выбора значения nice
+func FormatNiceMenu(pid int32) (string, tgbotapi.InlineKeyboardMarkup) {
+	text := fmt.Sprintf("⚖️ Выберите новое значение nice для %s [PID: %d].\nМеньше — выше приоритет.", processName(pid), pid)
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, n := range niceValues {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d", n), fmt.Sprintf("procniceset_%d_%d", pid, n)))
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		row,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", fmt.Sprintf("procinfo_%d", pid)),
+		),
+	)
+	return text, keyboard
+}
+
+// FormatNiceConfirmation формирует запрос подтверждения изменения nice. Кнопка подтверждения
+// действует так же, как у сигналов
+func FormatNiceConfirmation(pid int32, nice int) (string, tgbotapi.InlineKeyboardMarkup) {
+	started, err := commands.ProcessStartTime(pid)
+	if err != nil {
+		return fmt.Sprintf("❌ Процесс с PID %d не найден", pid), tgbotapi.NewInlineKeyboardMarkup()
+	}
+	text := fmt.Sprintf("⚠️ Установить nice %d для процесса %s [PID: %d]?", nice, processName(pid), pid)
+	data := commands.ProcessConfirmationData(pid, strconv.Itoa(nice), started, time.Now())
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Подтвердить", "procniceok_"+data),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", fmt.Sprintf("procinfo_%d", pid)),
+		),
+	)
+	return text, keyboard
+}
+
+// ExecuteRenice меняет nice процесса и формирует сообщение о результате
+func ExecuteRenice(pid int32, nice int) string {
+	if err := commands.Processes.Renice(pid, nice); err != nil {
+		return fmt.Sprintf("❌ Не удалось изменить nice процесса %d: %v", pid, err)
+	}
+	return fmt.Sprintf("✅ Nice процесса %s [PID: %d] изменён на %d.", processName(pid), pid, nice)
+}
+
+// HandleKillCommand обрабатывает команду /kill <pid|имя>
+func HandleKillCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
+	chatID := update.Message.Chat.ID
+	query := strings.TrimSpace(update.Message.CommandArguments())
+	if query == "" {
+		msg := tgbotapi.NewMessage(chatID, "Использование: /kill <pid|имя процесса>")
+		bot.Send(msg)
+		return
+	}
+
+	procs, err := commands.FindProcesses(query)
+	if err != nil || len(procs) == 0 {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Процесс %q не найден", query))
+		bot.Send(msg)
+		return
+	}
+
+	// Один процесс — сразу спрашиваем подтверждение
+	if len(procs) == 1 {
+		text, keyboard := FormatSignalConfirmation(procs[0].Pid, commands.SignalTerm)
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ReplyMarkup = keyboard
+		bot.Send(msg)
+		return
+	}
+
+	// Несколько процессов — предлагаем выбрать нужный
+	const maxButtons = 20
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔎 Найдено процессов: %d. Выберите, какой завершить:\n", len(procs)))
+	keyboard := tgbotapi.NewInlineKeyboardMarkup()
+	for i, p := range procs {
+		if i >= maxButtons {
+			sb.WriteString(fmt.Sprintf("... и ещё %d\n", len(procs)-maxButtons))
+			break
+		}
+		name, _ := p.Name()
+		sb.WriteString(fmt.Sprintf("%d. %s [PID: %d]\n", i+1, name, p.Pid))
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🛑 %s [%d]", name, p.Pid), fmt.Sprintf("procsig_%d_%s", p.Pid, commands.SignalTerm)),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ReplyMarkup = keyboard
+	bot.Send(msg)
+}
+
+// processName возвращает имя процесса или "unknown"
+func processName(pid int32) string {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return "unknown"
+	}
+	name, err := p.Name()
+	if err != nil {
+		return "unknown"
+	}
+	return name
+}
@@ -1,94 +1,44 @@
 package functions
 
 import (
+	"TG_BOT_GO/internal/config"
 	"TG_BOT_GO/internal/monitor"
 	"fmt"
-	"sort"
+	"runtime"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"github.com/shirou/gopsutil/process"
 )
 
 // ProcessInfo содержит информацию о процессе
 type ProcessInfo struct {
+	PID        int32   // Идентификатор процесса
 	Name       string  // Название процесса
-	CPUUsage   float64 // Нагрузка на CPU (%)
-	GPULoad    float64 // Нагрузка на GPU (%)
+	User       string  // Владелец процесса
+	Cmdline    string  // Командная строка
+	CPUUsage   float64 // Текущая нагрузка на CPU (% от всех ядер)
+	GPUMemMB   float64 // Занятая видеопамять (МБ)
 	MemoryMB   float64 // Использование памяти (МБ)
-	DownloadMB float64 // Входящий трафик (МБ)
-	UploadMB   float64 // Исходящий трафик (МБ)
+	DownSpeed  float64 // Входящая скорость (МБ/с)
+	UpSpeed    float64 // Исходящая скорость (МБ/с)
+	DownloadMB float64 // Входящий трафик с момента запуска бота (МБ)
+	UploadMB   float64 // Исходящий трафик с момента запуска бота (МБ)
 }
 
-// HandleProcessesCommand обрабатывает команду /processes
+// HandleProcessesCommand обрабатывает команду /processes [sort=<ключ>]
 func HandleProcessesCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
-	// Отправляем сообщение "Пожалуйста, подождите..."
-	waitMsg := tgbotapi.NewMessage(update.Message.Chat.ID, "Пожалуйста, подождите пару секунд...")
-	sentMsg, _ := bot.Send(waitMsg)
-
-	// Получаем список процессов
-	processes, err := process.Processes()
+	sortKey, err := parseProcessesArgs(update.Message.CommandArguments())
 	if err != nil {
-		msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Ошибка при получении списка процессов")
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, "❌ "+err.Error())
 		bot.Send(msg)
 		return
 	}
 
-	// Собираем информацию о каждом процессе
-	var processInfoList []ProcessInfo
-	for _, p := range processes {
-		name, err := p.Name()
-		if err != nil {
-			continue // Пропускаем процесс, если не удалось получить имя
-		}
-
-		cpuPercent, err := p.CPUPercent()
-		if err != nil {
-			continue // Пропускаем процесс, если не удалось получить нагрузку на CPU
-		}
-
-		memInfo, err := p.MemoryInfo()
-		if err != nil || memInfo == nil {
-			continue // Пропускаем процесс, если не удалось получить информацию о памяти
-		}
-
-		gpuLoad := monitor.GetGPUUsageForProcess(p.Pid) // Нагрузка на GPU
-		networkInfo, err := monitor.GetNetworkUsageForProcess(p.Pid)
-		if err != nil {
-			continue // Пропускаем процесс, если не удалось получить сетевую активность
-		}
-
-		processInfo := ProcessInfo{
-			Name:       name,
-			CPUUsage:   cpuPercent,
-			GPULoad:    gpuLoad,
-			MemoryMB:   float64(memInfo.RSS) / 1024 / 1024, // RSS в МБ
-			DownloadMB: networkInfo.DownloadMB,
-			UploadMB:   networkInfo.UploadMB,
-		}
-		processInfoList = append(processInfoList, processInfo)
-	}
-
-	// Сортируем процессы по убыванию нагрузки на CPU
-	sort.Slice(processInfoList, func(i, j int) bool {
-		return processInfoList[i].CPUUsage > processInfoList[j].CPUUsage
-	})
+	// Отправляем сообщение "Пожалуйста, подождите..."
+	waitMsg := tgbotapi.NewMessage(update.Message.Chat.ID, "Пожалуйста, подождите пару секунд...")
+	sentMsg, _ := bot.Send(waitMsg)
 
-	// Формируем вывод
-	output := "+------------------------------+\n"
-	output += "| 🖥️ Топ-10 процессов:          \n"
-	output += "+------------------------------+\n"
-	for i, p := range processInfoList {
-		if i >= 10 { // Ограничиваемся 10 процессами
-			break
-		}
-		output += fmt.Sprintf("%d. %s:\n", i+1, p.Name)
-		output += fmt.Sprintf("  ⚙️ CPU: %.1f%%\n", p.CPUUsage)
-		output += fmt.Sprintf("  🎮 GPU: %.1f%%\n", p.GPULoad)
-		output += fmt.Sprintf("  🧠 Память: %.1f МБ\n", p.MemoryMB)
-		output += fmt.Sprintf("  🌐 Сеть: ⬇️ %.1f МБ, ⬆️ %.1f МБ\n", p.DownloadMB, p.UploadMB)
-		output += "\n" // Добавляем отступ между процессами
-	}
-	output += "+------------------------------+"
+	output, keyboard := HandleProcessesCommandOutput(sortKey)
 
 	// Удаляем сообщение "Пожалуйста, подождите..."
 	deleteMsg := tgbotapi.NewDeleteMessage(update.Message.Chat.ID, sentMsg.MessageID)
@@ -96,69 +46,60 @@ func HandleProcessesCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
 
 	// Отправляем результат
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, output)
+	msg.ReplyMarkup = keyboard
 	bot.Send(msg)
 }
 
-// HandleProcessesCommandOutput возвращает результат команды /processes в виде строки
-func HandleProcessesCommandOutput() string {
-	processes, err := process.Processes()
+// parseProcessesArgs разбирает аргументы /processes. Топ процессов не фильтруется: кнопки сортировки
+// не сохраняют фильтр, поэтому поиск по имени и пользователю отправляется в /find
+func parseProcessesArgs(args string) (string, error) {
+	filter, sortKey, err := ParseProcessQuery(args, SortByCPU)
 	if err != nil {
-		return "Ошибка при получении списка процессов"
+		return "", err
 	}
+	if filter.Pattern != "" || filter.User != "" {
+		return "", fmt.Errorf("/processes принимает только sort=<ключ>. Для поиска по имени или пользователю используйте " +
+			"/find <подстрока|/регулярное выражение/> [user=<имя>] [sort=<ключ>]")
+	}
+	return sortKey, nil
+}
 
-	var processInfoList []ProcessInfo
-	for _, p := range processes {
-		name, err := p.Name()
-		if err != nil {
-			continue
-		}
-
-		cpuPercent, err := p.CPUPercent()
-		if err != nil {
-			continue
-		}
-
-		memInfo, err := p.MemoryInfo()
-		if err != nil || memInfo == nil {
-			continue
-		}
-
-		gpuLoad := monitor.GetGPUUsageForProcess(p.Pid)
-		networkInfo, err := monitor.GetNetworkUsageForProcess(p.Pid)
-		if err != nil {
-			continue
-		}
+// HandleProcessesCommandOutput возвращает топ-10 процессов по выбранной метрике и кнопки сортировки
+func HandleProcessesCommandOutput(sortKey string) (string, tgbotapi.InlineKeyboardMarkup) {
+	keyboard := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: processSortKeyboard("procsort_", sortKey)}
 
-		processInfo := ProcessInfo{
-			Name:       name,
-			CPUUsage:   cpuPercent,
-			GPULoad:    gpuLoad,
-			MemoryMB:   float64(memInfo.RSS) / 1024 / 1024,
-			DownloadMB: networkInfo.DownloadMB,
-			UploadMB:   networkInfo.UploadMB,
-		}
-		processInfoList = append(processInfoList, processInfo)
+	// Окно замера загрузки CPU, как у top: чем длиннее, тем точнее, но дольше ответ
+	window := config.GetEnvDuration("PROCESSES_CPU_WINDOW", time.Second)
+	cpuUsage, err := monitor.SampleProcessCPU(window)
+	if err != nil {
+		return "Ошибка при получении списка процессов", keyboard
+	}
+	processInfoList, err := CollectProcesses(ProcessFilter{}, cpuUsage)
+	if err != nil {
+		return "Ошибка при получении списка процессов", keyboard
 	}
 
-	sort.Slice(processInfoList, func(i, j int) bool {
-		return processInfoList[i].CPUUsage > processInfoList[j].CPUUsage
-	})
+	SortProcesses(processInfoList, sortKey)
+	if len(processInfoList) > 10 { // Ограничиваемся 10 процессами
+		processInfoList = processInfoList[:10]
+	}
 
+	// Формируем вывод
 	output := "+------------------------------+\n"
 	output += "| 🖥️ Топ-10 процессов:          \n"
 	output += "+------------------------------+\n"
+	output += fmt.Sprintf("↕️ Сортировка: %s\n", processSortTitle(sortKey))
+	output += fmt.Sprintf("⏱️ CPU за %s, %% от всех ядер (%d)\n\n", window, runtime.NumCPU())
 	for i, p := range processInfoList {
-		if i >= 10 {
-			break
-		}
-		output += fmt.Sprintf("%d. %s:\n", i+1, p.Name)
+		output += fmt.Sprintf("%d. %s [PID: %d]:\n", i+1, p.Name, p.PID)
 		output += fmt.Sprintf("  ⚙️ CPU: %.1f%%\n", p.CPUUsage)
-		output += fmt.Sprintf("  🎮 GPU: %.1f%%\n", p.GPULoad)
+		output += fmt.Sprintf("  🎮 Видеопамять: %.0f МБ\n", p.GPUMemMB)
 		output += fmt.Sprintf("  🧠 Память: %.1f МБ\n", p.MemoryMB)
-		output += fmt.Sprintf("  🌐 Сеть: ⬇️ %.1f МБ, ⬆️ %.1f МБ\n", p.DownloadMB, p.UploadMB)
-		output += "\n"
+		output += fmt.Sprintf("  🌐 Сеть: ⬇️ %.2f МБ/с, ⬆️ %.2f МБ/с (всего ⬇️ %.1f МБ, ⬆️ %.1f МБ)\n",
+			p.DownSpeed, p.UpSpeed, p.DownloadMB, p.UploadMB)
+		output += "\n" // Добавляем отступ между процессами
 	}
 	output += "+------------------------------+"
 
-	return output
+	return output, keyboard
 }
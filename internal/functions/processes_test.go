@@ -0,0 +1,30 @@
+package functions
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseProcessesArgs(t *testing.T) {
+	tests := []struct {
+		args    string
+		want    string
+		wantErr string
+	}{
+		{"", SortByCPU, ""},
+		{"sort=mem", SortByMem, ""},
+		{"sort=bogus", "", "неизвестная сортировка"},
+		{"nginx", "", "/find"},
+		{"user=root sort=cpu", "", "/find"},
+		{"/ngin.*/", "", "/find"},
+	}
+	for _, tt := range tests {
+		got, err := parseProcessesArgs(tt.args)
+		switch {
+		case tt.wantErr == "" && (err != nil || got != tt.want):
+			t.Errorf("parseProcessesArgs(%q) = %q, %v, ожидалось %q", tt.args, got, err, tt.want)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("parseProcessesArgs(%q) = %q, %v, ожидалась ошибка с %q", tt.args, got, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package functions
+
+import (
+	"TG_BOT_GO/internal/commands"
+	"TG_BOT_GO/internal/config"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/shirou/gopsutil/process"
+)
+
+// Ограничения размера карточки процесса (сообщение Telegram — не больше 4096 символов)
+const (
+	cardMaxCmdline     = 400
+	cardMaxChildren    = 10
+	cardMaxConnections = 10
+)
+
+// FormatProcessCard формирует подробную карточку процесса с кнопками действий
+func FormatProcessCard(pid int32) (string, tgbotapi.InlineKeyboardMarkup) {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return fmt.Sprintf("❌ Процесс с PID %d не найден", pid), tgbotapi.NewInlineKeyboardMarkup()
+	}
+
+	name, _ := p.Name()
+	status, _ := p.Status()
+	user, _ := p.Username()
+	nice, _ := p.Nice()
+	threads, _ := p.NumThreads()
+	ppid, _ := p.Ppid()
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🖥️ %s [PID: %d]\n", name, pid))
+	sb.WriteString(fmt.Sprintf("📌 Состояние: %s\n", status))
+	sb.WriteString(fmt.Sprintf("👤 Пользователь: %s\n", user))
+	sb.WriteString(fmt.Sprintf("⚖️ Nice: %d, 🧵 потоков: %d\n", nice, threads))
+
+	if createTime, err := p.CreateTime(); err == nil {
+		started := time.UnixMilli(createTime)
+		sb.WriteString(fmt.Sprintf("🕒 Запущен: %s (работает %s)\n",
+			started.Format("02.01.2006 15:04:05"), formatUptime(time.Since(started))))
+	}
+	if times, err := p.Times(); err == nil {
+		sb.WriteString(fmt.Sprintf("⚙️ Время CPU: user %.1f с, system %.1f с\n", times.User, times.System))
+	}
+
+	// Родитель и дочерние процессы
+	if ppid > 0 {
+		sb.WriteString(fmt.Sprintf("⬆️ Родитель: %s [PID: %d]\n", processName(ppid), ppid))
+	}
+	if children, err := p.Children(); err == nil && len(children) > 0 {
+		sb.WriteString(fmt.Sprintf("⬇️ Дочерние (%d):", len(children)))
+		for i, child := range children {
+			if i >= cardMaxChildren {
+				sb.WriteString(fmt.Sprintf(" и ещё %d", len(children)-cardMaxChildren))
+				break
+			}
+			childName, _ := child.Name()
+			sb.WriteString(fmt.Sprintf(" %s [%d]", childName, child.Pid))
+		}
+		sb.WriteString("\n")
+	}
+
+	// Память и ввод-вывод
+	if memInfo, err := p.MemoryInfo(); err == nil && memInfo != nil {
+		sb.WriteString(fmt.Sprintf("🧠 Память: RSS %.1f МБ, VMS %.1f МБ, swap %.1f МБ\n",
+			float64(memInfo.RSS)/1024/1024, float64(memInfo.VMS)/1024/1024, float64(memInfo.Swap)/1024/1024))
+	}
+	if io, err := p.IOCounters(); err == nil && io != nil {
+		sb.WriteString(fmt.Sprintf("💾 Ввод-вывод: прочитано %.1f МБ (%d оп.), записано %.1f МБ (%d оп.)\n",
+			float64(io.ReadBytes)/1024/1024, io.ReadCount, float64(io.WriteBytes)/1024/1024, io.WriteCount))
+	}
+	if fds, err := p.NumFDs(); err == nil {
+		sb.WriteString(fmt.Sprintf("📂 Открытых файлов: %d\n", fds))
+	} else if files, err := p.OpenFiles(); err == nil {
+		sb.WriteString(fmt.Sprintf("📂 Открытых файлов: %d\n", len(files)))
+	}
+	if cgroup := processCgroup(pid); cgroup != "" {
+		sb.WriteString(fmt.Sprintf("📦 Cgroup: %s\n", cgroup))
+	}
+
+	// Сетевые соединения
+	if conns, err := p.Connections(); err == nil && len(conns) > 0 {
+		sb.WriteString(fmt.Sprintf("🌐 Соединения (%d):\n", len(conns)))
+		for i, conn := range conns {
+			if i >= cardMaxConnections {
+				sb.WriteString(fmt.Sprintf("  ... и ещё %d\n", len(conns)-cardMaxConnections))
+				break
+			}
+			proto := "TCP"
+			if conn.Type == syscall.SOCK_DGRAM {
+				proto = "UDP"
+			}
+			line := fmt.Sprintf("  %s %s:%d", proto, conn.Laddr.IP, conn.Laddr.Port)
+			if conn.Raddr.IP != "" {
+				line += fmt.Sprintf(" → %s:%d", conn.Raddr.IP, conn.Raddr.Port)
+			}
+			if conn.Status != "" && conn.Status != "NONE" {
+				line += " " + conn.Status
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	// Путь и командная строка
+	if exe, err := p.Exe(); err == nil && exe != "" {
+		sb.WriteString(fmt.Sprintf("📍 Файл: %s\n", exe))
+	}
+	if cmdline, _ := p.Cmdline(); cmdline != "" {
+		if len([]rune(cmdline)) > cardMaxCmdline {
+			cmdline = string([]rune(cmdline)[:cardMaxCmdline]) + "…"
+		}
+		sb.WriteString(fmt.Sprintf("💻 Команда: %s\n", cmdline))
+	}
+
+	if err := commands.Processes.CheckProtected(pid); err != nil {
+		sb.WriteString("\n🛡️ Процесс защищён от управления")
+	}
+
+	return sb.String(), processActionsKeyboard(pid, ppid)
+}
+
+// formatUptime форматирует длительность работы: "3д 4ч 12м", "5м 10с"
+func formatUptime(d time.Duration) string {
+	d = d.Round(time.Second)
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	seconds := (d - minutes*time.Minute) / time.Second
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dд %dч %dм", days, hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dч %dм", hours, minutes)
+	default:
+		return fmt.Sprintf("%dм %dс", minutes, seconds)
+	}
+}
+
+// processCgroup возвращает cgroup процесса из <PROCFS_ROOT>/<pid>/cgroup (только Linux)
+func processCgroup(pid int32) string {
+	data, err := os.ReadFile(filepath.Join(config.GetProcfsRoot(), strconv.Itoa(int(pid)), "cgroup"))
+	if err != nil {
+		return ""
+	}
+
+	// Формат строк: "иерархия:контроллеры:путь". В cgroup v2 одна строка "0::/путь"
+	var paths []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" {
+			return parts[2]
+		}
+		if strings.Contains(parts[1], "cpu") || strings.Contains(parts[1], "name=systemd") {
+			paths = append(paths, parts[2])
+		}
+	}
+	if len(paths) > 0 {
+		return paths[0]
+	}
+	return ""
+}
+
+// HandleProcCommand обрабатывает команду /proc <pid|имя>
+func HandleProcCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
+	chatID := update.Message.Chat.ID
+	query := strings.TrimSpace(update.Message.CommandArguments())
+	if query == "" {
+		msg := tgbotapi.NewMessage(chatID, "Использование: /proc <pid|имя процесса>")
+		bot.Send(msg)
+		return
+	}
+
+	procs, err := commands.FindProcesses(query)
+	if err != nil || len(procs) == 0 {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Процесс %q не найден", query))
+		bot.Send(msg)
+		return
+	}
+
+	// Один процесс — сразу показываем карточку
+	if len(procs) == 1 {
+		text, keyboard := FormatProcessCard(procs[0].Pid)
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ReplyMarkup = keyboard
+		bot.Send(msg)
+		return
+	}
+
+	// Несколько процессов — предлагаем выбрать нужный
+	const maxButtons = 20
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔎 Найдено процессов: %d. Выберите процесс:\n", len(procs)))
+	keyboard := tgbotapi.NewInlineKeyboardMarkup()
+	for i, p := range procs {
+		if i >= maxButtons {
+			sb.WriteString(fmt.Sprintf("... и ещё %d\n", len(procs)-maxButtons))
+			break
+		}
+		name, _ := p.Name()
+		sb.WriteString(fmt.Sprintf("%d. %s [PID: %d]\n", i+1, name, p.Pid))
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🔍 %s [%d]", name, p.Pid), fmt.Sprintf("procinfo_%d", p.Pid)),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ReplyMarkup = keyboard
+	bot.Send(msg)
+}
@@ -0,0 +1,42 @@
+package functions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProcessCgroupReadsProcfsRoot(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("PROCFS_ROOT", root)
+
+	files := map[string]string{
+		"100/cgroup": "0::/system.slice/nginx.service\n",
+		"200/cgroup": "12:pids:/user.slice\n4:cpu,cpuacct:/docker/abc123\n1:name=systemd:/docker/abc123\n",
+		"300/cgroup": "12:pids:/user.slice\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		pid  int32
+		want string
+	}{
+		{100, "/system.slice/nginx.service"}, // cgroup v2
+		{200, "/docker/abc123"},              // cgroup v1: контроллер cpu
+		{300, ""},                            // Нет ни cpu, ни systemd
+		{400, ""},                            // Процесса нет в PROCFS_ROOT
+	}
+	for _, tt := range tests {
+		if got := processCgroup(tt.pid); got != tt.want {
+			t.Errorf("processCgroup(%d) = %q, ожидалось %q", tt.pid, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package functions
+
+import (
+	"TG_BOT_GO/internal/monitor"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/shirou/gopsutil/process"
+)
+
+// Ключи сортировки списков процессов
+const (
+	SortByCPU  = "cpu"
+	SortByMem  = "mem"
+	SortByGPU  = "gpu"
+	SortByNet  = "net"
+	SortByPID  = "pid"
+	SortByName = "name"
+)
+
+// processSorts — доступные сортировки в порядке кнопок
+var processSorts = []struct {
+	key   string
+	title string
+}{
+	{SortByCPU, "⚙️ CPU"},
+	{SortByMem, "🧠 Память"},
+	{SortByGPU, "🎮 Видеопамять"},
+	{SortByNet, "🌐 Сеть"},
+	{SortByPID, "🔢 PID"},
+	{SortByName, "🔤 Имя"},
+}
+
+// ValidProcessSort проверяет, что ключ сортировки поддерживается
+func ValidProcessSort(key string) bool {
+	for _, s := range processSorts {
+		if s.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// processSortTitle возвращает название сортировки для вывода
+func processSortTitle(key string) string {
+	for _, s := range processSorts {
+		if s.key == key {
+			return s.title
+		}
+	}
+	return key
+}
+
+// ProcessFilter отбирает процессы по имени/командной строке и пользователю
+type ProcessFilter struct {
+	Pattern string         // Подстрока (без учёта регистра) или исходный текст регулярного выражения
+	Regexp  *regexp.Regexp // Регулярное выражение, если шаблон задан как /.../
+	User    string         // Имя пользователя-владельца
+}
+
+// Empty сообщает, что фильтр пропускает все процессы
+func (f ProcessFilter) Empty() bool {
+	return f.Pattern == "" && f.User == ""
+}
+
+// Match проверяет процесс по фильтру
+func (f ProcessFilter) Match(info ProcessInfo) bool {
+	if f.User != "" && !strings.EqualFold(info.User, f.User) {
+		return false
+	}
+	if f.Regexp != nil {
+		return f.Regexp.MatchString(info.Name) || f.Regexp.MatchString(info.Cmdline)
+	}
+	if f.Pattern != "" {
+		pattern := strings.ToLower(f.Pattern)
+		return strings.Contains(strings.ToLower(info.Name), pattern) ||
+			strings.Contains(strings.ToLower(info.Cmdline), pattern)
+	}
+	return true
+}
+
+// String описывает фильтр для заголовка списка
+func (f ProcessFilter) String() string {
+	var parts []string
+	if f.Regexp != nil {
+		parts = append(parts, "regex /"+f.Pattern+"/")
+	} else if f.Pattern != "" {
+		parts = append(parts, fmt.Sprintf("%q", f.Pattern))
+	}
+	if f.User != "" {
+		parts = append(parts, "пользователь "+f.User)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseProcessQuery разбирает аргументы вида "<шаблон> [user=<имя>] [sort=<ключ>]".
+// Шаблон в слешах (/^nginx.*/) считается регулярным выражением, иначе — подстрокой
+func ParseProcessQuery(args string, defaultSort string) (ProcessFilter, string, error) {
+	var filter ProcessFilter
+	sortKey := defaultSort
+
+	var pattern []string
+	for _, field := range strings.Fields(args) {
+		switch {
+		case strings.HasPrefix(field, "user="):
+			filter.User = strings.TrimPrefix(field, "user=")
+		case strings.HasPrefix(field, "sort="):
+			sortKey = strings.ToLower(strings.TrimPrefix(field, "sort="))
+			if !ValidProcessSort(sortKey) {
+				return filter, "", fmt.Errorf("неизвестная сортировка %q (доступны: cpu, mem, gpu, net, pid, name)", sortKey)
+			}
+		default:
+			pattern = append(pattern, field)
+		}
+	}
+
+	filter.Pattern = strings.Join(pattern, " ")
+	if len(filter.Pattern) >= 2 && strings.HasPrefix(filter.Pattern, "/") && strings.HasSuffix(filter.Pattern, "/") {
+		filter.Pattern = filter.Pattern[1 : len(filter.Pattern)-1]
+		re, err := regexp.Compile(filter.Pattern)
+		if err != nil {
+			return filter, "", fmt.Errorf("некорректное регулярное выражение: %v", err)
+		}
+		filter.Regexp = re
+	}
+	return filter, sortKey, nil
+}
+
+// CollectProcesses собирает сведения о процессах, подходящих под фильтр.
+// Загрузка CPU заполняется только при переданном замере cpuUsage
+func CollectProcesses(filter ProcessFilter, cpuUsage map[int32]float64) ([]ProcessInfo, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+
+	var list []ProcessInfo
+	for _, p := range procs {
+		name, err := p.Name()
+		if err != nil {
+			continue // Процесс завершился или недоступен
+		}
+		info := ProcessInfo{PID: p.Pid, Name: name, CPUUsage: cpuUsage[p.Pid]}
+		info.User, _ = p.Username()
+		info.Cmdline, _ = p.Cmdline()
+		if !filter.Match(info) {
+			continue
+		}
+		if memInfo, err := p.MemoryInfo(); err == nil && memInfo != nil {
+			info.MemoryMB = float64(memInfo.RSS) / 1024 / 1024 // RSS в МБ
+		}
+		list = append(list, info)
+	}
+
+	// GPU запрашиваем одним вызовом утилиты на весь список, сеть — из общего учёта трафика
+	gpuMemory := monitor.GetGPUMemoryByProcess()
+	netUsage, _ := monitor.GetNetworkUsageByProcess() // Без поддержки ОС трафик нулевой
+	for i := range list {
+		p := &list[i]
+		p.GPUMemMB = gpuMemory[p.PID]
+		networkInfo := netUsage[p.PID]
+		p.DownSpeed, p.UpSpeed = networkInfo.DownSpeed, networkInfo.UpSpeed
+		p.DownloadMB, p.UploadMB = networkInfo.DownloadMB, networkInfo.UploadMB
+	}
+	return list, nil
+}
+
+// SortProcesses сортирует процессы: метрики — по убыванию, PID и имя — по возрастанию
+func SortProcesses(list []ProcessInfo, key string) {
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		var x, y float64
+		switch key {
+		case SortByCPU:
+			// При равной загрузке CPU выше процесс, занимающий больше памяти
+			if a.CPUUsage != b.CPUUsage {
+				return a.CPUUsage > b.CPUUsage
+			}
+			x, y = a.MemoryMB, b.MemoryMB
+		case SortByMem:
+			x, y = a.MemoryMB, b.MemoryMB
+		case SortByGPU:
+			x, y = a.GPUMemMB, b.GPUMemMB
+		case SortByNet:
+			x, y = a.DownSpeed+a.UpSpeed, b.DownSpeed+b.UpSpeed
+		case SortByName:
+			if !strings.EqualFold(a.Name, b.Name) {
+				return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+			}
+		}
+		if x != y {
+			return x > y
+		}
+		return a.PID < b.PID
+	})
+}
+
+// processSortValue возвращает значение метрики сортировки для строки списка
+func processSortValue(info ProcessInfo, key string) string {
+	switch key {
+	case SortByCPU:
+		return fmt.Sprintf("%.1f%%", info.CPUUsage)
+	case SortByMem:
+		return fmt.Sprintf("%.1f МБ", info.MemoryMB)
+	case SortByGPU:
+		return fmt.Sprintf("%.0f МБ", info.GPUMemMB)
+	case SortByNet:
+		return fmt.Sprintf("⬇️ %.2f ⬆️ %.2f МБ/с", info.DownSpeed, info.UpSpeed)
+	}
+	return ""
+}
+
+// processSortKeyboard возвращает ряды кнопок сортировки с префиксом callback-данных prefix
+func processSortKeyboard(prefix, current string) [][]tgbotapi.InlineKeyboardButton {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, s := range processSorts {
+		title := s.title
+		if s.key == current {
+			title = "✅ " + title
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(title, prefix+s.key))
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -0,0 +1,314 @@
+package functions
+
+import (
+	"TG_BOT_GO/internal/commands"
+	"TG_BOT_GO/internal/config"
+	"TG_BOT_GO/internal/monitor"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/shirou/gopsutil/process"
+)
+
+// Параметры отображения дерева процессов
+const (
+	pstreePageSize      = 40 // Строк дерева на странице
+	pstreeMaxChildren   = 8  // Сколько дочерних процессов показывать, прежде чем свернуть остальные
+	pstreeShownChildren = 5  // Сколько дочерних процессов остаётся видимыми в свёрнутом поддереве
+	pstreeMaxName       = 32 // Максимальная длина имени процесса в строке
+)
+
+// treeNode — узел дерева процессов с суммарной нагрузкой поддерева
+type treeNode struct {
+	pid      int32
+	ppid     int32
+	name     string
+	cpu      float64 // Загрузка CPU самим процессом (% от всех ядер)
+	memMB    float64 // RSS самого процесса (МБ)
+	parent   *treeNode
+	children []*treeNode
+
+	totalCPU   float64 // Суммарно по поддереву
+	totalMemMB float64
+	count      int // Процессов в поддереве, включая сам узел
+}
+
+// buildProcessTree строит дерево процессов и возвращает его корни
+func buildProcessTree() (map[int32]*treeNode, []*treeNode, error) {
+	window := config.GetEnvDuration("PROCESSES_CPU_WINDOW", time.Second)
+	cpuUsage, err := monitor.SampleProcessCPU(window)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nodes := make(map[int32]*treeNode, len(procs))
+	for _, p := range procs {
+		name, err := p.Name()
+		if err != nil {
+			name = "unknown"
+		}
+		ppid, _ := p.Ppid()
+		node := &treeNode{pid: p.Pid, ppid: ppid, name: name, cpu: cpuUsage[p.Pid]}
+		if memInfo, err := p.MemoryInfo(); err == nil && memInfo != nil {
+			node.memMB = float64(memInfo.RSS) / 1024 / 1024
+		}
+		nodes[p.Pid] = node
+	}
+
+	return nodes, linkProcessTree(nodes), nil
+}
+
+// linkProcessTree связывает узлы с родителями, считает нагрузку поддеревьев и возвращает корни
+func linkProcessTree(nodes map[int32]*treeNode) []*treeNode {
+	var roots []*treeNode
+	for _, node := range nodes {
+		parent, ok := nodes[node.ppid]
+		if !ok || inPPIDCycle(nodes, node) {
+			roots = append(roots, node)
+			continue
+		}
+		node.parent = parent
+		parent.children = append(parent.children, node)
+	}
+
+	for _, root := range roots {
+		aggregateTree(root)
+	}
+	sortTreeNodes(roots)
+	return roots
+}
+
+// inPPIDCycle сообщает, возвращается ли цепочка родителей процесса к нему самому. Помимо
+// процессов-собственных родителей, циклы возникают, если PID переиспользуется, пока читаются PPID.
+// Процессы такого цикла становятся корнями, иначе дерево не имело бы конца
+func inPPIDCycle(nodes map[int32]*treeNode, node *treeNode) bool {
+	visited := map[int32]bool{}
+	for parent, ok := nodes[node.ppid]; ok; parent, ok = nodes[parent.ppid] {
+		if parent.pid == node.pid {
+			return true
+		}
+		if visited[parent.pid] {
+			return false // Цикл выше по цепочке, этот процесс в него не входит
+		}
+		visited[parent.pid] = true
+	}
+	return false
+}
+
+// aggregateTree считает суммарные CPU и память поддерева и сортирует дочерние узлы
+func aggregateTree(node *treeNode) {
+	node.totalCPU, node.totalMemMB, node.count = node.cpu, node.memMB, 1
+	for _, child := range node.children {
+		aggregateTree(child)
+		node.totalCPU += child.totalCPU
+		node.totalMemMB += child.totalMemMB
+		node.count += child.count
+	}
+	sortTreeNodes(node.children)
+}
+
+// sortTreeNodes упорядочивает узлы по нагрузке поддерева, затем по PID
+func sortTreeNodes(list []*treeNode) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].totalCPU != list[j].totalCPU {
+			return list[i].totalCPU > list[j].totalCPU
+		}
+		if list[i].totalMemMB != list[j].totalMemMB {
+			return list[i].totalMemMB > list[j].totalMemMB
+		}
+		return list[i].pid < list[j].pid
+	})
+}
+
+// renderTree выводит поддерево в строки с отступами, сворачивая большие списки дочерних процессов
+func renderTree(node *treeNode, prefix, branch string, lines *[]string) {
+	name := node.name
+	if len([]rune(name)) > pstreeMaxName {
+		name = string([]rune(name)[:pstreeMaxName]) + "…"
+	}
+	line := fmt.Sprintf("%s%s%s [%d] %.1f%% %.0f МБ", prefix, branch, name, node.pid, node.cpu, node.memMB)
+	if node.count > 1 {
+		line += fmt.Sprintf(" (Σ %.1f%%, %.0f МБ, %d проц.)", node.totalCPU, node.totalMemMB, node.count)
+	}
+	*lines = append(*lines, line)
+
+	// Отступ для потомков продолжает вертикальную линию родителя
+	switch branch {
+	case "├─ ":
+		prefix += "│  "
+	case "└─ ":
+		prefix += "   "
+	}
+
+	children := node.children
+	var hidden []*treeNode
+	if len(children) > pstreeMaxChildren {
+		children, hidden = children[:pstreeShownChildren], children[pstreeShownChildren:]
+	}
+	for i, child := range children {
+		childBranch := "├─ "
+		if i == len(children)-1 && len(hidden) == 0 {
+			childBranch = "└─ "
+		}
+		renderTree(child, prefix, childBranch, lines)
+	}
+
+	if len(hidden) > 0 {
+		var cpu, mem float64
+		var count int
+		for _, child := range hidden {
+			cpu += child.totalCPU
+			mem += child.totalMemMB
+			count += child.count
+		}
+		*lines = append(*lines, fmt.Sprintf("%s└─ … скрыто веток: %d, процессов: %d (Σ %.1f%%, %.0f МБ)",
+			prefix, len(hidden), count, cpu, mem))
+	}
+}
+
+// BuildProcessTreeLines строит дерево процессов (целиком или от процессов, найденных по PID/имени)
+func BuildProcessTreeLines(query string) ([]string, error) {
+	nodes, roots, err := buildProcessTree()
+	if err != nil {
+		return nil, err
+	}
+
+	if query != "" {
+		procs, err := commands.FindProcesses(query)
+		if err != nil || len(procs) == 0 {
+			return nil, fmt.Errorf("процесс %q не найден", query)
+		}
+
+		pids := make([]int32, 0, len(procs))
+		for _, p := range procs {
+			pids = append(pids, p.Pid)
+		}
+		roots = matchedTreeRoots(nodes, pids)
+	}
+
+	var lines []string
+	for _, root := range roots {
+		renderTree(root, "", "", &lines)
+	}
+	return lines, nil
+}
+
+// matchedTreeRoots возвращает узлы найденных процессов, кроме вложенных в другие найденные.
+// Подъём идёт по уже построенному дереву, в котором циклы PPID разорваны
+func matchedTreeRoots(nodes map[int32]*treeNode, pids []int32) []*treeNode {
+	matched := make(map[int32]bool, len(pids))
+	for _, pid := range pids {
+		matched[pid] = true
+	}
+	var roots []*treeNode
+	for _, pid := range pids {
+		node, ok := nodes[pid]
+		if !ok {
+			continue
+		}
+		nested := false
+		for parent := node.parent; parent != nil; parent = parent.parent {
+			if matched[parent.pid] {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			roots = append(roots, node)
+		}
+	}
+	sortTreeNodes(roots)
+	return roots
+}
+
+// NewProcessTreeSnapshot строит дерево процессов и сохраняет его как снимок чата.
+// Снимки дерева хранятся вместе со снимками /showproc и живут столько же (SHOWPROC_TTL)
+func NewProcessTreeSnapshot(chatID int64, query string) (*ProcessSnapshot, error) {
+	lines, err := BuildProcessTreeLines(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("нет данных для отображения")
+	}
+	return storeSnapshot(&ProcessSnapshot{ChatID: chatID, Created: time.Now(), Tree: lines})
+}
+
+// GetProcessTreeSnapshot возвращает снимок дерева процессов чата, если он ещё не устарел
+func GetProcessTreeSnapshot(chatID int64, id string) (*ProcessSnapshot, bool) {
+	snapshot, ok := getSnapshot(chatID, id)
+	if !ok || snapshot.Tree == nil {
+		return nil, false
+	}
+	return snapshot, true
+}
+
+// ExpiredTreeMessage — ответ на нажатие кнопки устаревшего дерева процессов
+const ExpiredTreeMessage = "⌛ Это дерево процессов устарело и больше не хранится.\n" +
+	"Отправьте /pstree, чтобы получить актуальное дерево."
+
+// FormatProcessTreePage формирует страницу снимка дерева процессов и клавиатуру пагинации.
+// Кнопки передают ID снимка: pstree_page_<id>_<страница>
+func FormatProcessTreePage(s *ProcessSnapshot, page int) (string, tgbotapi.InlineKeyboardMarkup, bool) {
+	lines := s.Tree
+
+	totalPages := (len(lines) + pstreePageSize - 1) / pstreePageSize // Округление вверх
+	if page < 0 || page >= totalPages {
+		return "", tgbotapi.InlineKeyboardMarkup{}, false
+	}
+	start := page * pstreePageSize
+	end := min(start+pstreePageSize, len(lines))
+
+	var sb strings.Builder
+	sb.WriteString("🌳 Дерево процессов (CPU % от всех ядер, память RSS, Σ — по поддереву):\n\n")
+	for _, line := range lines[start:end] {
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString(fmt.Sprintf("\nСтраница %d/%d, снимок от %s", page+1, totalPages, s.Created.Format("15:04:05")))
+
+	// Кнопки перехода между страницами
+	keyboard := tgbotapi.NewInlineKeyboardMarkup()
+	var row []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад", fmt.Sprintf("pstree_page_%s_%d", s.ID, page-1)))
+	}
+	if page+1 < totalPages {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("Вывести ещё", fmt.Sprintf("pstree_page_%s_%d", s.ID, page+1)))
+	}
+	if len(row) > 0 {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, row)
+	}
+	return sb.String(), keyboard, true
+}
+
+// HandlePsTreeCommand обрабатывает команду /pstree [pid|имя]
+func HandlePsTreeCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
+	chatID := update.Message.Chat.ID
+
+	snapshot, err := NewProcessTreeSnapshot(chatID, strings.TrimSpace(update.Message.CommandArguments()))
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "❌ "+err.Error())
+		bot.Send(msg)
+		return
+	}
+
+	text, keyboard, ok := FormatProcessTreePage(snapshot, 0)
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, "❌ Нет данных для отображения")
+		bot.Send(msg)
+		return
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	if len(keyboard.InlineKeyboard) > 0 {
+		msg.ReplyMarkup = keyboard
+	}
+	bot.Send(msg)
+}
@@ -0,0 +1,126 @@
+package functions
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// treeNodes строит узлы дерева из пар PID → PPID
+func treeNodes(ppids map[int32]int32) map[int32]*treeNode {
+	nodes := make(map[int32]*treeNode, len(ppids))
+	for pid, ppid := range ppids {
+		nodes[pid] = &treeNode{pid: pid, ppid: ppid, name: "proc", cpu: 1}
+	}
+	return nodes
+}
+
+// rootPIDs возвращает PID корней по возрастанию
+func rootPIDs(roots []*treeNode) []int32 {
+	var pids []int32
+	for _, root := range roots {
+		pids = append(pids, root.pid)
+	}
+	sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+	return pids
+}
+
+func TestLinkProcessTreeCycles(t *testing.T) {
+	nodes := treeNodes(map[int32]int32{
+		1:  0,  // init
+		2:  1,  // потомок init
+		5:  5,  // сам себе родитель
+		10: 12, // цикл 10 → 12 → 11 → 10
+		11: 10,
+		12: 11,
+		20: 10, // ветка, растущая из цикла
+		21: 20,
+	})
+
+	roots := linkProcessTree(nodes)
+	if got, want := rootPIDs(roots), []int32{1, 5, 10, 11, 12}; !reflect.DeepEqual(got, want) {
+		t.Errorf("корни = %v, ожидалось %v", got, want)
+	}
+	if nodes[10].count != 3 || nodes[1].count != 2 {
+		t.Errorf("размеры поддеревьев: 10 — %d, 1 — %d", nodes[10].count, nodes[1].count)
+	}
+
+	var lines []string
+	for _, root := range roots {
+		renderTree(root, "", "", &lines)
+	}
+	if len(lines) != len(nodes) {
+		t.Errorf("в дереве %d строк, ожидалось %d:\n%v", len(lines), len(nodes), lines)
+	}
+}
+
+func TestMatchedTreeRoots(t *testing.T) {
+	nodes := treeNodes(map[int32]int32{
+		1:  0,
+		2:  1,
+		3:  2,
+		4:  1,
+		10: 11, // цикл из двух найденных процессов
+		11: 10,
+	})
+	linkProcessTree(nodes)
+
+	tests := []struct {
+		name string
+		pids []int32
+		want []int32
+	}{
+		{"вложенные отбрасываются", []int32{3, 2, 4}, []int32{2, 4}},
+		{"корень поглощает всё", []int32{1, 3}, []int32{1}},
+		{"цикл не зацикливает подъём", []int32{10, 11}, []int32{10, 11}},
+		{"неизвестные PID пропускаются", []int32{99, 3}, []int32{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rootPIDs(matchedTreeRoots(nodes, tt.pids)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matchedTreeRoots(%v) = %v, ожидалось %v", tt.pids, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessTreeSnapshot(t *testing.T) {
+	t.Setenv("SHOWPROC_TTL", "1m")
+	const chatID = 42
+
+	lines := make([]string, pstreePageSize+1)
+	for i := range lines {
+		lines[i] = "proc"
+	}
+	snapshot, err := storeSnapshot(&ProcessSnapshot{ChatID: chatID, Created: time.Now(), Tree: lines})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := GetProcessTreeSnapshot(chatID, snapshot.ID); !ok {
+		t.Fatal("снимок дерева не найден")
+	}
+	if _, ok := GetProcessTreeSnapshot(chatID+1, snapshot.ID); ok {
+		t.Error("снимок дерева доступен другому чату")
+	}
+	if _, ok := GetProcessSnapshot(chatID, snapshot.ID); ok {
+		t.Error("снимок дерева выдан как список процессов")
+	}
+
+	_, keyboard, ok := FormatProcessTreePage(snapshot, 0)
+	if !ok || len(keyboard.InlineKeyboard) != 1 {
+		t.Fatalf("первая страница: ok=%v, клавиатура %+v", ok, keyboard)
+	}
+	if data := *keyboard.InlineKeyboard[0][0].CallbackData; data != "pstree_page_"+snapshot.ID+"_1" {
+		t.Errorf("callback-данные = %q", data)
+	}
+	if _, _, ok := FormatProcessTreePage(snapshot, 2); ok {
+		t.Error("страница за пределами дерева")
+	}
+
+	snapshot.Created = time.Now().Add(-2 * time.Minute)
+	if _, ok := GetProcessTreeSnapshot(chatID, snapshot.ID); ok {
+		t.Error("устаревший снимок дерева всё ещё доступен")
+	}
+}
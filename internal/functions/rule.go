@@ -0,0 +1,172 @@
+package functions
+
+import (
+	"TG_BOT_GO/internal/monitor"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ruleUsage — справка по команде /rule
+const ruleUsage = "Использование:\n" +
+	"/rule list — список правил\n" +
+	"/rule add <имя> <метрика> <оператор> <порог> [for=60s] [clear=75] [severity=info|warning|critical]\n" +
+	"/rule del <имя> — удалить правило\n" +
+	"/rule mute <имя|all> <длительность|off> — заглушить уведомления\n\n" +
+	"Примеры:\n" +
+	"/rule add hot_cpu cpu_temp > 85 for=2m clear=75 severity=critical\n" +
+	"/rule add home_full disk:/home >= 95\n" +
+	"/rule add wan_busy net:eth0 > 50 for=5m\n" +
+	"/rule add gpu1_hot gpu_temp:1 > 80 for=1m\n" +
+	"/rule add nginx_down proc_count:nginx < 1 severity=critical\n\n" +
+	"Метрики: cpu, cpu_temp, gpu, gpu_temp, gpu_mem, gpu:<номер>, gpu_temp:<номер>, gpu_mem:<номер>, memory, disk, disk:<раздел>, net_down, net_up, " +
+	"net:<интерфейс>, net_down:<интерфейс>, net_up:<интерфейс>, proc_cpu:<имя>, proc_mem:<имя>, proc_count:<имя>\n" +
+	"Операторы: > >= < <= == !="
+
+// ruleExpression разбирает условие вида "cpu_temp > 85" или "cpu_temp>85"
+var ruleExpression = regexp.MustCompile(`^(\S+?)\s*(>=|<=|==|!=|>|<)\s*(-?[0-9]+(?:\.[0-9]+)?)$`)
+
+// ParseRule разбирает аргументы /rule add: имя, условие и необязательные параметры key=value
+func ParseRule(args []string) (monitor.Rule, error) {
+	if len(args) < 2 {
+		return monitor.Rule{}, fmt.Errorf("укажите имя правила и условие")
+	}
+	rule := monitor.Rule{Name: args[0]}
+
+	// Всё, что не похоже на параметр key=value, относится к условию
+	var expression []string
+	for _, arg := range args[1:] {
+		key, value, isOption := strings.Cut(arg, "=")
+		switch {
+		case isOption && key == "for":
+			hold, err := parseHold(value)
+			if err != nil {
+				return rule, err
+			}
+			rule.Hold = int(hold / time.Second)
+		case isOption && key == "clear":
+			clear, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return rule, fmt.Errorf("некорректный порог сброса %q", value)
+			}
+			rule.Clear = &clear
+		case isOption && key == "severity":
+			rule.Severity = value
+		default:
+			expression = append(expression, arg)
+		}
+	}
+
+	match := ruleExpression.FindStringSubmatch(strings.Join(expression, " "))
+	if match == nil {
+		return rule, fmt.Errorf("некорректное условие %q: ожидается <метрика> <оператор> <порог>", strings.Join(expression, " "))
+	}
+	rule.Metric, rule.Op = match[1], match[2]
+	rule.Threshold, _ = strconv.ParseFloat(match[3], 64)
+
+	return rule, rule.Validate()
+}
+
+// HandleRuleCommandOutput выполняет команду /rule для чата и возвращает ответ
+func HandleRuleCommandOutput(chatID int64, args []string) string {
+	if len(args) == 0 {
+		return ruleUsage
+	}
+
+	switch args[0] {
+	case "list":
+		sub, ok := monitor.GetSubscription(chatID)
+		if !ok || len(sub.Rules) == 0 {
+			return "📋 Правил нет. Добавьте правило: /rule add <имя> <метрика> <оператор> <порог>"
+		}
+		return "📋 Правила уведомлений:\n" + formatRules(sub, time.Now())
+
+	case "add":
+		rule, err := ParseRule(args[1:])
+		if err != nil {
+			return "❌ " + err.Error()
+		}
+		if _, err := monitor.UpdateSubscription(chatID, func(sub *monitor.Subscription) {
+			sub.SetRule(rule)
+		}); err != nil {
+			return "Ошибка при сохранении правил."
+		}
+		return fmt.Sprintf("✅ Правило %s сохранено: %s %s.\nВключите уведомления командой /alarm_on, если они выключены.",
+			rule.Name, monitor.SeverityIcon(rule.Severity), rule)
+
+	case "del":
+		if len(args) != 2 {
+			return "Использование: /rule del <имя>"
+		}
+		deleted := false
+		if _, ok := monitor.GetSubscription(chatID); ok {
+			if _, err := monitor.UpdateSubscription(chatID, func(sub *monitor.Subscription) {
+				deleted = sub.DeleteRule(args[1])
+			}); err != nil {
+				return "Ошибка при сохранении правил."
+			}
+		}
+		if !deleted {
+			return fmt.Sprintf("❌ Правило %s не найдено.", args[1])
+		}
+		return fmt.Sprintf("🗑️ Правило %s удалено.", args[1])
+
+	case "mute":
+		if len(args) != 3 {
+			return "Использование: /rule mute <имя|all> <длительность|off>, например /rule mute hot_cpu 2h"
+		}
+		return muteRules(chatID, args[1], args[2])
+	}
+
+	return ruleUsage
+}
+
+// muteRules заглушает правило (или все правила) чата на время либо снимает заглушение
+func muteRules(chatID int64, name, duration string) string {
+	var until *time.Time
+	if duration != "off" {
+		d, err := ParseWindow(duration)
+		if err != nil {
+			return "❌ " + err.Error()
+		}
+		t := time.Now().Add(d)
+		until = &t
+	}
+
+	found := false
+	if _, ok := monitor.GetSubscription(chatID); ok {
+		if _, err := monitor.UpdateSubscription(chatID, func(sub *monitor.Subscription) {
+			for i := range sub.Rules {
+				if name == "all" || sub.Rules[i].Name == name {
+					sub.Rules[i].MutedUntil = until
+					found = true
+				}
+			}
+		}); err != nil {
+			return "Ошибка при сохранении правил."
+		}
+	}
+	if !found {
+		return fmt.Sprintf("❌ Правило %s не найдено.", name)
+	}
+
+	label := "правила " + name
+	if name == "all" {
+		label = "всех правил"
+	}
+	if until == nil {
+		return fmt.Sprintf("🔔 Уведомления %s снова включены.", label)
+	}
+	return fmt.Sprintf("🔇 Уведомления %s заглушены до %s.", label, until.Format("02.01 15:04"))
+}
+
+// HandleRuleCommand обрабатывает команду /rule
+func HandleRuleCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
+	output := HandleRuleCommandOutput(update.Message.Chat.ID, strings.Fields(update.Message.CommandArguments()))
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, output)
+	bot.Send(msg)
+}
@@ -0,0 +1,85 @@
+package functions
+
+import (
+	"TG_BOT_GO/internal/config"
+	"TG_BOT_GO/internal/monitor"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// HandleSensorsCommandOutput возвращает показания всех датчиков hwmon: температуры, вентиляторы и напряжения
+func HandleSensorsCommandOutput() string {
+	chips := monitor.HwmonChips()
+	if len(chips) == 0 {
+		return "🌡️ Датчики hwmon не найдены (команда работает только в Linux)"
+	}
+	cpuSensor, hasCPU := monitor.CPUTempSensor(chips)
+
+	var sb strings.Builder
+	sb.WriteString("+------------------------------+\n")
+	sb.WriteString("| 🌡️ Датчики:                   \n")
+	sb.WriteString("+------------------------------+\n")
+	for _, chip := range chips {
+		sb.WriteString(fmt.Sprintf("🔧 %s:\n", chip.Name))
+		for _, t := range chip.Temps {
+			sb.WriteString(fmt.Sprintf("  %s %s: %.1f°C", tempIcon(t), t.Label, t.Current))
+			var limits []string
+			if t.High > 0 {
+				limits = append(limits, fmt.Sprintf("высокая %.0f°C", t.High))
+			}
+			if t.Critical > 0 {
+				limits = append(limits, fmt.Sprintf("критическая %.0f°C", t.Critical))
+			}
+			if len(limits) > 0 {
+				sb.WriteString(" (" + strings.Join(limits, ", ") + ")")
+			}
+			if hasCPU && t.ID == cpuSensor.ID {
+				sb.WriteString(" ⬅️ CPU")
+			}
+			sb.WriteString("\n")
+		}
+		for _, f := range chip.Fans {
+			icon := "🌀"
+			if f.Min > 0 && f.RPM < f.Min {
+				icon = "⚠️"
+			}
+			sb.WriteString(fmt.Sprintf("  %s %s: %.0f об/мин\n", icon, f.Label, f.RPM))
+		}
+		for _, v := range chip.Voltages {
+			icon := "⚡"
+			if v.Max > 0 && (v.Volts < v.Min || v.Volts > v.Max) {
+				icon = "⚠️"
+			}
+			sb.WriteString(fmt.Sprintf("  %s %s: %.3f В\n", icon, v.Label, v.Volts))
+		}
+	}
+	if hasCPU {
+		sb.WriteString(fmt.Sprintf("\n⬅️ Температура CPU для уведомлений: %s\n", cpuSensor.ID))
+	} else if selector := config.GetEnv("CPU_TEMP_SENSOR"); selector != "" {
+		sb.WriteString(fmt.Sprintf("\n⚠️ Датчик CPU_TEMP_SENSOR=%s не найден, температура CPU недоступна\n", selector))
+	} else {
+		sb.WriteString("\n⚠️ Датчик температуры CPU не определён\n")
+	}
+	sb.WriteString("Датчик можно выбрать в CPU_TEMP_SENSOR, например k10temp/Tctl\n")
+	sb.WriteString("+------------------------------+")
+	return sb.String()
+}
+
+// tempIcon выделяет датчики, превысившие порог высокой или критической температуры
+func tempIcon(t monitor.TempSensor) string {
+	switch {
+	case t.Critical > 0 && t.Current >= t.Critical:
+		return "🔥"
+	case t.High > 0 && t.Current >= t.High:
+		return "⚠️"
+	}
+	return "🌡️"
+}
+
+// HandleSensorsCommand обрабатывает команду /sensors
+func HandleSensorsCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, HandleSensorsCommandOutput())
+	bot.Send(msg)
+}
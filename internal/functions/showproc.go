@@ -1,111 +1,276 @@
 package functions
 
 import (
+	"TG_BOT_GO/internal/config"
+	"TG_BOT_GO/internal/monitor"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"github.com/shirou/gopsutil/process"
 )
 
-// processCache хранит процессы для каждого чата (ключ - chatID)
+// Параметры хранения снимков списка процессов
+const (
+	showprocPageSize   = 40 // Процессов на странице
+	showprocMaxPerChat = 5  // Сколько последних снимков хранить для одного чата
+)
+
+// ProcessSnapshot — неизменяемый снимок списка процессов, построенный по фильтру и сортировке.
+// ID снимка передаётся в callback-данных кнопок, так что страницы разных сообщений не смешиваются
+type ProcessSnapshot struct {
+	ID      string
+	ChatID  int64
+	Created time.Time
+	Filter  ProcessFilter
+	Sort    string
+	Procs   []ProcessInfo
+	Tree    []string // Строки дерева процессов для снимка /pstree; у списков процессов пусто
+}
+
+// processSnapshots хранит снимки списков процессов (ключ - ID снимка)
 var (
-	processCache = make(map[int64][]*process.Process)
-	cacheMutex   sync.Mutex
+	processSnapshots = make(map[string]*ProcessSnapshot)
+	snapshotsMutex   sync.Mutex
 )
 
-// GetProcesses возвращает процессы для чата (из кэша или загружает)
-func GetProcesses(chatID int64) ([]*process.Process, error) {
-	cacheMutex.Lock()
-	defer cacheMutex.Unlock()
+// snapshotTTL возвращает время жизни снимка
+func snapshotTTL() time.Duration {
+	return config.GetEnvDuration("SHOWPROC_TTL", 10*time.Minute)
+}
 
-	if procs, ok := processCache[chatID]; ok {
-		return procs, nil
+// NewProcessSnapshot строит снимок процессов по фильтру и сортировке и сохраняет его для чата.
+// Загрузка CPU замеряется только для сортировки по CPU, так как замер занимает время
+func NewProcessSnapshot(chatID int64, filter ProcessFilter, sortKey string) (*ProcessSnapshot, error) {
+	var cpuUsage map[int32]float64
+	if sortKey == SortByCPU {
+		var err error
+		cpuUsage, err = monitor.SampleProcessCPU(config.GetEnvDuration("PROCESSES_CPU_WINDOW", time.Second))
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	procs, err := process.Processes()
+	procs, err := CollectProcesses(filter, cpuUsage)
 	if err != nil {
 		return nil, err
 	}
+	SortProcesses(procs, sortKey)
 
-	processCache[chatID] = procs
-	return procs, nil
+	return storeSnapshot(&ProcessSnapshot{
+		ChatID:  chatID,
+		Created: time.Now(),
+		Filter:  filter,
+		Sort:    sortKey,
+		Procs:   procs,
+	})
 }
 
-// GetProcessesPage возвращает процессы для страницы и общее количество страниц
-func GetProcessesPage(chatID int64, page int) ([]*process.Process, int, error) {
-	procs, err := GetProcesses(chatID)
+// storeSnapshot присваивает снимку ID и сохраняет его, вытесняя устаревшие снимки чата
+func storeSnapshot(snapshot *ProcessSnapshot) (*ProcessSnapshot, error) {
+	id, err := newSnapshotID()
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
+	snapshot.ID = id
 
-	totalPages := (len(procs) + 39) / 40 // Округление вверх
-	start := page * 40
-	end := start + 40
+	snapshotsMutex.Lock()
+	defer snapshotsMutex.Unlock()
+	evictSnapshots(snapshot.ChatID, snapshot.Created)
+	processSnapshots[id] = snapshot
+	return snapshot, nil
+}
 
-	if start >= len(procs) {
-		return []*process.Process{}, totalPages, nil
+// GetProcessSnapshot возвращает снимок списка процессов чата, если он существует и ещё не устарел
+func GetProcessSnapshot(chatID int64, id string) (*ProcessSnapshot, bool) {
+	snapshot, ok := getSnapshot(chatID, id)
+	if !ok || snapshot.Tree != nil {
+		return nil, false
 	}
+	return snapshot, true
+}
+
+// getSnapshot возвращает снимок чата любого вида, удаляя его, если он устарел
+func getSnapshot(chatID int64, id string) (*ProcessSnapshot, bool) {
+	snapshotsMutex.Lock()
+	defer snapshotsMutex.Unlock()
 
-	if end > len(procs) {
-		end = len(procs)
+	snapshot, ok := processSnapshots[id]
+	if !ok || snapshot.ChatID != chatID {
+		return nil, false
 	}
+	if time.Since(snapshot.Created) > snapshotTTL() {
+		delete(processSnapshots, id)
+		return nil, false
+	}
+	return snapshot, true
+}
 
-	return procs[start:end], totalPages, nil
+// evictSnapshots удаляет устаревшие снимки и самые старые снимки чата сверх лимита.
+// Вызывается под snapshotsMutex перед добавлением нового снимка
+func evictSnapshots(chatID int64, now time.Time) {
+	ttl := snapshotTTL()
+	var own []*ProcessSnapshot
+	for id, snapshot := range processSnapshots {
+		if now.Sub(snapshot.Created) > ttl {
+			delete(processSnapshots, id)
+			continue
+		}
+		if snapshot.ChatID == chatID {
+			own = append(own, snapshot)
+		}
+	}
+
+	// Освобождаем место под новый снимок
+	sort.Slice(own, func(i, j int) bool {
+		return own[i].Created.Before(own[j].Created)
+	})
+	for len(own) >= showprocMaxPerChat {
+		delete(processSnapshots, own[0].ID)
+		own = own[1:]
+	}
+}
+
+// newSnapshotID возвращает случайный идентификатор снимка, не совпадающий с ID до перезапуска бота
+func newSnapshotID() (string, error) {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
 }
 
-// FormatProcessesMessage формирует сообщение со списком процессов и клавиатуру для пагинации
-func FormatProcessesMessage(procs []*process.Process, page, totalPages int) (string, tgbotapi.InlineKeyboardMarkup) {
+// Page возвращает процессы для страницы и общее количество страниц
+func (s *ProcessSnapshot) Page(page int) ([]ProcessInfo, int) {
+	totalPages := (len(s.Procs) + showprocPageSize - 1) / showprocPageSize // Округление вверх
+	start := page * showprocPageSize
+	if page < 0 || start >= len(s.Procs) {
+		return []ProcessInfo{}, totalPages
+	}
+	end := min(start+showprocPageSize, len(s.Procs))
+	return s.Procs[start:end], totalPages
+}
+
+// ExpiredSnapshotMessage — ответ на нажатие кнопки устаревшего или удалённого снимка
+const ExpiredSnapshotMessage = "⌛ Этот список процессов устарел и больше не хранится.\n" +
+	"Отправьте /showproc или /find, чтобы получить актуальный список."
+
+// FormatProcessesMessage формирует страницу снимка и клавиатуру для пагинации, сортировки и обновления
+func FormatProcessesMessage(s *ProcessSnapshot, page int) (string, tgbotapi.InlineKeyboardMarkup) {
+	procs, totalPages := s.Page(page)
+
 	var sb strings.Builder
 
+	// Заголовок с фильтром, сортировкой и временем снимка
+	if !s.Filter.Empty() {
+		sb.WriteString(fmt.Sprintf("🔎 Фильтр: %s, найдено: %d\n", s.Filter, len(s.Procs)))
+	}
+	sb.WriteString(fmt.Sprintf("↕️ Сортировка: %s\n", processSortTitle(s.Sort)))
+	sb.WriteString(fmt.Sprintf("📸 Снимок от %s\n\n", s.Created.Format("15:04:05")))
+
 	// Формируем список процессов
 	for i, p := range procs {
-		name, err := p.Name()
-		if err != nil {
-			name = "unknown" // Если не удалось получить имя процесса
+		line := fmt.Sprintf(
+			"%d. %s [PID: %d]",
+			(page*showprocPageSize)+i+1, // Порядковый номер с учетом страницы
+			p.Name,                      // Имя процесса
+			p.PID,                       // PID процесса
+		)
+		if value := processSortValue(p, s.Sort); value != "" {
+			line += " — " + value
 		}
-		pid := p.Pid // Получаем PID процесса
-		sb.WriteString(fmt.Sprintf(
-			"%d. %s [PID: %d]\n",
-			(page*40)+i+1, // Порядковый номер с учетом страницы
-			name,          // Имя процесса
-			pid,           // PID процесса
-		))
+		sb.WriteString(line + "\n")
 	}
 
 	// Добавляем информацию о текущей странице и общем количестве страниц
 	sb.WriteString(fmt.Sprintf("\nСтраница %d/%d", page+1, totalPages))
+	sb.WriteString("\nНажмите на PID, чтобы открыть действия с процессом")
 
-	// Создаем клавиатуру
+	// Создаем клавиатуру с кнопками процессов (по 5 в ряд)
 	keyboard := tgbotapi.NewInlineKeyboardMarkup()
+	var row []tgbotapi.InlineKeyboardButton
+	for _, p := range procs {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			strconv.Itoa(int(p.PID)),
+			"procinfo_"+strconv.Itoa(int(p.PID)),
+		))
+		if len(row) == 5 {
+			keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, row)
+	}
 
-	// Если есть следующая страница, добавляем кнопку "Вывести ещё"
+	// Кнопки перехода между страницами
+	row = nil
+	if page > 0 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад", fmt.Sprintf("showproc_page_%s_%d", s.ID, page-1)))
+	}
 	if page+1 < totalPages {
-		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("Вывести ещё", "showproc_page_"+strconv.Itoa(page+1)),
-		))
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("Вывести ещё", fmt.Sprintf("showproc_page_%s_%d", s.ID, page+1)))
+	}
+	if len(row) > 0 {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, row)
 	}
 
+	// Кнопки сортировки и обновления
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, processSortKeyboard("showproc_sort_"+s.ID+"_", s.Sort)...)
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔄 Обновить", "showproc_refresh_"+s.ID),
+	))
+
 	// Возвращаем сформированное сообщение и клавиатуру
 	return sb.String(), keyboard
 }
 
-// HandleShowProcCommand обрабатывает команду /showproc
+// HandleShowProcCommand обрабатывает команду /showproc [user=<имя>] [sort=<ключ>]
 func HandleShowProcCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
+	showProcessList(update, bot, false)
+}
+
+// HandleFindCommand обрабатывает команду /find <шаблон|/regex/> [user=<имя>] [sort=<ключ>]
+func HandleFindCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
+	showProcessList(update, bot, true)
+}
+
+// showProcessList строит список процессов по аргументам команды и отправляет первую страницу
+func showProcessList(update tgbotapi.Update, bot *tgbotapi.BotAPI, requireFilter bool) {
 	chatID := update.Message.Chat.ID
 
-	// Получаем первую страницу
-	procs, totalPages, err := GetProcessesPage(chatID, 0)
+	filter, sortKey, err := ParseProcessQuery(update.Message.CommandArguments(), SortByPID)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "❌ "+err.Error())
+		bot.Send(msg)
+		return
+	}
+	if requireFilter && filter.Empty() {
+		msg := tgbotapi.NewMessage(chatID, "Использование: /find <подстрока|/регулярное выражение/> [user=<имя>] [sort=cpu|mem|gpu|net|pid|name]\n"+
+			"Поиск идёт по имени процесса и командной строке")
+		bot.Send(msg)
+		return
+	}
+
+	snapshot, err := NewProcessSnapshot(chatID, filter, sortKey)
 	if err != nil {
 		msg := tgbotapi.NewMessage(chatID, "❌ Ошибка при получении процессов")
 		bot.Send(msg)
 		return
 	}
+	if len(snapshot.Procs) == 0 {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🔎 Процессы по фильтру %s не найдены", filter))
+		bot.Send(msg)
+		return
+	}
 
-	// Формируем сообщение
-	message, keyboard := FormatProcessesMessage(procs, 0, totalPages)
+	// Формируем первую страницу
+	message, keyboard := FormatProcessesMessage(snapshot, 0)
 	msg := tgbotapi.NewMessage(chatID, message)
 	msg.ReplyMarkup = keyboard
 	bot.Send(msg)
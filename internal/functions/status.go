@@ -13,6 +13,7 @@ func HandleStatusCommandOutput() string {
 	gpuUsage := monitor.GetGPUUsage()
 	memInfo := monitor.GetMemoryUsage()
 	networkInfo := monitor.GetNetworkUsage()
+	powerInfo := monitor.GetPowerUsage()
 
 	output := "+------------------------------+\n"
 	output += "| 💽 Диски:                     \n"
@@ -23,7 +24,7 @@ func HandleStatusCommandOutput() string {
 	output += "+------------------------------+\n"
 	output += cpuUsage + "\n"
 	output += "+------------------------------+\n"
-	output += "| 🎮 Видеокарта:                \n"
+	output += "| 🎮 Видеокарты:                \n"
 	output += "+------------------------------+\n"
 	output += gpuUsage + "\n"
 	output += "+------------------------------+\n"
@@ -34,6 +35,13 @@ func HandleStatusCommandOutput() string {
 	output += "| 🌐 Сеть:                      \n"
 	output += "+------------------------------+\n"
 	output += networkInfo
+	// Блок питания выводим только на ноутбуках и при настроенном ИБП
+	if powerInfo != "" {
+		output += "+------------------------------+\n"
+		output += "| 🔋 Питание:                   \n"
+		output += "+------------------------------+\n"
+		output += powerInfo
+	}
 	output += "+------------------------------+"
 
 	return output
@@ -43,5 +51,10 @@ func HandleStatusCommandOutput() string {
 func HandleStatusCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
 	output := HandleStatusCommandOutput()
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, output)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📈 График", "chart_menu"),
+		),
+	)
 	bot.Send(msg)
 }
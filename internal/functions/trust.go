@@ -0,0 +1,52 @@
+package functions
+
+import (
+	"TG_BOT_GO/internal/monitor"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// trustUsage — подсказка по аргументам /trust
+const trustUsage = "Использование: /trust <путь к файлу | хэш SHA-256 | первые 8 символов хэша> — добавить файл в доверенные"
+
+// HandleTrustCommandOutput выполняет /trust [путь|хэш] и возвращает ответ
+func HandleTrustCommandOutput(args string) string {
+	arg := strings.TrimSpace(args)
+	if arg == "" {
+		return formatUnknownExecutables(monitor.UnknownExecutables())
+	}
+
+	exe, err := monitor.TrustExecutable(arg)
+	if err != nil {
+		return "❌ " + err.Error()
+	}
+	if exe.Path == "" {
+		return fmt.Sprintf("✅ Хэш добавлен в доверенные\n🔑 SHA-256: %s", exe.Hash)
+	}
+	return fmt.Sprintf("✅ Файл %s добавлен в доверенные\n🔑 SHA-256: %s", exe.Path, exe.Hash)
+}
+
+// formatUnknownExecutables выводит исполняемые файлы, ожидающие решения
+func formatUnknownExecutables(list []monitor.UnknownExecutable) string {
+	if len(list) == 0 {
+		return "✅ Неизвестных исполняемых файлов нет.\n\n" + trustUsage
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🆕 Неизвестные исполняемые файлы (%d):\n", len(list)))
+	for _, exe := range list {
+		sb.WriteString(fmt.Sprintf("\n%s [PID: %d], замечен %s\n📍 %s\n🔑 %s\n",
+			exe.Name, exe.PID, exe.FirstSeen.Format("02.01.2006 15:04:05"), exe.Path, exe.Hash))
+	}
+	sb.WriteString("\n" + trustUsage)
+	return sb.String()
+}
+
+// HandleTrustCommand обрабатывает команду /trust
+func HandleTrustCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
+	output := HandleTrustCommandOutput(update.Message.CommandArguments())
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, output)
+	bot.Send(msg)
+}
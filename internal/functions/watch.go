@@ -0,0 +1,129 @@
+package functions
+
+import (
+	"TG_BOT_GO/internal/monitor"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// watchUsage — справка по команде /watch
+const watchUsage = "Использование:\n" +
+	"/watch list — отслеживаемые процессы\n" +
+	"/watch add <имя|/регулярное выражение/> [max=N] [restart=<команда>]\n" +
+	"/watch del <номер> — перестать отслеживать\n\n" +
+	"Имя сравнивается с именем процесса целиком, регулярное выражение — с именем и командной строкой.\n" +
+	"max — допустимое число экземпляров (по умолчанию 1).\n" +
+	"restart — имя команды перезапуска. Команды задаются на сервере в переменных WATCHDOG_CMD_<ИМЯ>, " +
+	"например WATCHDOG_CMD_NGINX=systemctl start nginx.\n\n" +
+	"Примеры:\n" +
+	"/watch add nginx restart=nginx\n" +
+	"/watch add /worker\\.py/ max=4"
+
+// watchStateTitles — состояния процессов для вывода
+var watchStateTitles = map[string]string{
+	monitor.WatchUnknown:  "⏳ проверяется",
+	monitor.WatchRunning:  "✅ работает",
+	monitor.WatchDown:     "💥 не запущен",
+	monitor.WatchMultiple: "⚠️ слишком много экземпляров",
+}
+
+// HandleWatchCommandOutput выполняет /watch list|add|del и возвращает ответ
+func HandleWatchCommandOutput(chatID int64, args string) string {
+	action, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	rest = strings.TrimSpace(rest)
+
+	switch action {
+	case "", "list":
+		return formatWatchStatuses(monitor.WatchStatuses())
+	case "add":
+		max, restart := 1, ""
+		var pattern []string
+		for _, field := range strings.Fields(rest) {
+			if value, ok := strings.CutPrefix(field, "max="); ok {
+				n, err := strconv.Atoi(value)
+				if err != nil {
+					return fmt.Sprintf("❌ Некорректное число экземпляров %q", value)
+				}
+				max = n
+				continue
+			}
+			if value, ok := strings.CutPrefix(field, "restart="); ok {
+				restart = value
+				continue
+			}
+			pattern = append(pattern, field)
+		}
+
+		rule, err := monitor.AddWatchRule(chatID, strings.Join(pattern, " "), max, restart)
+		if err != nil {
+			return "❌ " + err.Error() + "\n\n" + watchUsage
+		}
+		text := fmt.Sprintf("✅ Процесс %s отслеживается (правило #%d, допустимо экземпляров: %d)", rule.Pattern, rule.ID, rule.Max)
+		if rule.Restart != "" {
+			text += fmt.Sprintf("\n🔄 Команда перезапуска %s: %s", rule.Restart, monitor.WatchRestartCommands()[rule.Restart])
+		}
+		return text
+	case "del":
+		id, err := strconv.Atoi(rest)
+		if err != nil {
+			return "❌ Укажите номер правила из /watch list"
+		}
+		deleted, err := monitor.DeleteWatchRule(id)
+		if err != nil {
+			return "❌ Ошибка при сохранении правил: " + err.Error()
+		}
+		if !deleted {
+			return fmt.Sprintf("❌ Правило #%d не найдено", id)
+		}
+		return fmt.Sprintf("🗑️ Правило #%d удалено", id)
+	default:
+		return watchUsage
+	}
+}
+
+// formatWatchStatuses выводит правила сторожа с состоянием процессов и счётчиками падений
+func formatWatchStatuses(list []monitor.WatchStatus) string {
+	if len(list) == 0 {
+		return "🐕 Отслеживаемых процессов нет.\n\n" + watchUsage
+	}
+
+	commands := monitor.WatchRestartCommands()
+	var sb strings.Builder
+	sb.WriteString("🐕 Сторож процессов:\n")
+	for _, status := range list {
+		sb.WriteString(fmt.Sprintf("\n#%d %s — %s", status.ID, status.Pattern, watchStateTitles[status.State]))
+		if len(status.PIDs) > 0 {
+			sb.WriteString(fmt.Sprintf(" (экземпляров: %d из %d)", len(status.PIDs), status.Max))
+		}
+		sb.WriteString(fmt.Sprintf("\n  💥 Падений: %d", status.Crashes))
+		if status.Restart != "" {
+			sb.WriteString(fmt.Sprintf(", 🔄 перезапусков: %d\n  Команда %s: ", status.Restarts, status.Restart))
+			if command, ok := commands[status.Restart]; ok {
+				sb.WriteString(command)
+			} else {
+				sb.WriteString("⚠️ не настроена на сервере")
+			}
+			if !status.NextRestart.IsZero() {
+				if wait := time.Until(status.NextRestart); wait > 0 {
+					sb.WriteString(fmt.Sprintf("\n  ⏳ Следующая попытка через %s", wait.Round(time.Second)))
+				}
+			}
+			if status.LastError != "" {
+				sb.WriteString("\n  ❌ " + status.LastError)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// HandleWatchCommand обрабатывает команду /watch
+func HandleWatchCommand(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
+	output := HandleWatchCommandOutput(update.Message.Chat.ID, update.Message.CommandArguments())
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, output)
+	bot.Send(msg)
+}
@@ -0,0 +1,101 @@
+package geoip
+
+import (
+	"TG_BOT_GO/internal/config"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+)
+
+// Info — сведения об IP-адресе из локальных баз
+type Info struct {
+	CountryCode string // Код страны ISO 3166-1, например "DE"
+	Country     string // Название страны
+	ASN         uint64 // Номер автономной системы
+	Org         string // Владелец автономной системы
+}
+
+// String описывает адрес коротко: "DE Германия, AS24940 Hetzner Online GmbH"
+func (i Info) String() string {
+	var s string
+	if i.CountryCode != "" {
+		s = i.CountryCode
+		if i.Country != "" {
+			s += " " + i.Country
+		}
+	}
+	if i.ASN != 0 {
+		if s != "" {
+			s += ", "
+		}
+		s += fmt.Sprintf("AS%d", i.ASN)
+		if i.Org != "" {
+			s += " " + i.Org
+		}
+	}
+	return s
+}
+
+var (
+	countryDB, asnDB *Reader
+	loadOnce         sync.Once
+)
+
+// load открывает базы, указанные в конфигурации. Отсутствующие базы просто не используются
+func load() {
+	countryPath, asnPath := config.GetGeoIPPaths()
+	open := func(path string) *Reader {
+		if path == "" {
+			return nil
+		}
+		r, err := Open(path)
+		if err != nil {
+			log.Printf("Ошибка при открытии базы GeoIP %s: %v", path, err)
+			return nil
+		}
+		log.Printf("База GeoIP %s загружена (%s)", path, r.DatabaseType())
+		return r
+	}
+	countryDB = open(countryPath)
+	asnDB = open(asnPath)
+}
+
+// Available сообщает, настроена ли хотя бы одна база
+func Available() bool {
+	loadOnce.Do(load)
+	return countryDB != nil || asnDB != nil
+}
+
+// Lookup ищет IP-адрес в локальных базах. Для частных и служебных адресов возвращает пустой результат
+func Lookup(ip net.IP) Info {
+	var info Info
+	if !Available() || ip == nil || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+		return info
+	}
+
+	// Базы городов и стран хранят страну в "country", при её отсутствии берём страну регистрации
+	if countryDB != nil {
+		if record, err := countryDB.Lookup(ip); err == nil && record != nil {
+			country, ok := record["country"].(map[string]any)
+			if !ok {
+				country, _ = record["registered_country"].(map[string]any)
+			}
+			info.CountryCode, _ = country["iso_code"].(string)
+			if names, ok := country["names"].(map[string]any); ok {
+				if name, ok := names["ru"].(string); ok {
+					info.Country = name
+				} else {
+					info.Country, _ = names["en"].(string)
+				}
+			}
+		}
+	}
+	if asnDB != nil {
+		if record, err := asnDB.Lookup(ip); err == nil && record != nil {
+			info.ASN = toUint(record["autonomous_system_number"])
+			info.Org, _ = record["autonomous_system_organization"].(string)
+		}
+	}
+	return info
+}
@@ -0,0 +1,366 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"os"
+)
+
+// metadataMarker предшествует метаданным в конце файла mmdb
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// dataSectionSeparator — 16 нулевых байт между деревом поиска и разделом данных
+const dataSectionSeparator = 16
+
+// Reader читает базы в формате MaxMind DB (GeoLite2, DB-IP и совместимые) целиком из памяти.
+// Формат описан в https://maxmind.github.io/MaxMind-DB/
+type Reader struct {
+	buf          []byte
+	nodeCount    uint
+	recordSize   uint
+	ipVersion    uint
+	databaseType string
+	treeSize     uint
+	ipv4Start    uint // Узел, с которого начинается поиск IPv4-адресов в дереве IPv6
+}
+
+// Open загружает базу из файла
+func Open(path string) (*Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return FromBytes(buf)
+}
+
+// FromBytes разбирает базу из содержимого файла
+func FromBytes(buf []byte) (*Reader, error) {
+	start := bytes.LastIndex(buf, metadataMarker)
+	if start < 0 {
+		return nil, errors.New("не найдены метаданные MaxMind DB")
+	}
+	start += len(metadataMarker)
+
+	d := decoder{buf: buf[start:]}
+	value, _, err := d.decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора метаданных: %w", err)
+	}
+	meta, ok := value.(map[string]any)
+	if !ok {
+		return nil, errors.New("метаданные MaxMind DB должны быть словарём")
+	}
+
+	r := &Reader{buf: buf}
+	r.nodeCount = uint(toUint(meta["node_count"]))
+	r.recordSize = uint(toUint(meta["record_size"]))
+	r.ipVersion = uint(toUint(meta["ip_version"]))
+	r.databaseType, _ = meta["database_type"].(string)
+	switch r.recordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("неподдерживаемый размер записи %d", r.recordSize)
+	}
+	r.treeSize = r.recordSize * 2 / 8 * r.nodeCount
+	if r.treeSize+dataSectionSeparator > uint(len(buf)) {
+		return nil, errors.New("файл MaxMind DB повреждён")
+	}
+
+	// В дереве IPv6 адреса IPv4 лежат в ::/96 — заранее проходим 96 нулевых бит
+	if r.ipVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < r.nodeCount; i++ {
+			node = r.readRecord(node, 0)
+		}
+		r.ipv4Start = node
+	}
+	return r, nil
+}
+
+// DatabaseType возвращает тип базы из метаданных, например "GeoLite2-Country"
+func (r *Reader) DatabaseType() string {
+	return r.databaseType
+}
+
+// Lookup возвращает запись базы для адреса или nil, если адреса в базе нет
+func (r *Reader) Lookup(ip net.IP) (map[string]any, error) {
+	addr := ip.To4()
+	node := uint(0)
+	if addr != nil {
+		if r.ipVersion == 6 {
+			node = r.ipv4Start
+		}
+	} else {
+		if r.ipVersion == 4 {
+			return nil, nil // IPv6-адресов в базе IPv4 нет
+		}
+		addr = ip.To16()
+		if addr == nil {
+			return nil, fmt.Errorf("некорректный IP-адрес %v", ip)
+		}
+	}
+
+	bits := len(addr) * 8
+	for i := 0; i < bits && node < r.nodeCount; i++ {
+		bit := uint(addr[i/8]>>(7-uint(i%8))) & 1
+		node = r.readRecord(node, bit)
+	}
+
+	switch {
+	case node == r.nodeCount:
+		return nil, nil // Адрес не найден
+	case node < r.nodeCount:
+		return nil, errors.New("некорректное дерево поиска MaxMind DB")
+	}
+
+	// Значение записи больше числа узлов — это ссылка на раздел данных
+	offset := node - r.nodeCount - dataSectionSeparator
+	d := decoder{buf: r.buf[r.treeSize+dataSectionSeparator:]}
+	value, _, err := d.decode(offset)
+	if err != nil {
+		return nil, err
+	}
+	record, ok := value.(map[string]any)
+	if !ok {
+		return nil, errors.New("запись MaxMind DB должна быть словарём")
+	}
+	return record, nil
+}
+
+// readRecord читает левую (bit=0) или правую (bit=1) запись узла дерева
+func (r *Reader) readRecord(node, bit uint) uint {
+	b := r.buf[node*r.recordSize/4:]
+	switch r.recordSize {
+	case 24:
+		b = b[bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		// Средний байт делится пополам: старшие 4 бита — левой записи, младшие — правой
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(b[bit*4:]))
+	}
+}
+
+// Типы данных MaxMind DB
+const (
+	typeExtended  = 0
+	typePointer   = 1
+	typeString    = 2
+	typeDouble    = 3
+	typeBytes     = 4
+	typeUint16    = 5
+	typeUint32    = 6
+	typeMap       = 7
+	typeInt32     = 8
+	typeUint64    = 9
+	typeUint128   = 10
+	typeArray     = 11
+	typeContainer = 12
+	typeEnd       = 13
+	typeBool      = 14
+	typeFloat     = 15
+)
+
+// decoder разбирает значения раздела данных (смещения указателей отсчитываются от начала buf)
+type decoder struct {
+	buf []byte
+}
+
+// errCorrupt — ошибка разбора повреждённых данных
+var errCorrupt = errors.New("данные MaxMind DB повреждены")
+
+// maxDecodeDepth — предельная вложенность словарей, массивов и указателей. В повреждённой
+// базе указатель может вести в словарь, который снова ссылается сам на себя
+const maxDecodeDepth = 512
+
+// decode разбирает значение по смещению и возвращает его вместе со смещением следующего значения
+func (d *decoder) decode(offset uint) (any, uint, error) {
+	return d.decodeValue(offset, 0)
+}
+
+// decodeValue разбирает значение на глубине вложенности depth
+func (d *decoder) decodeValue(offset uint, depth int) (any, uint, error) {
+	if depth > maxDecodeDepth {
+		return nil, 0, fmt.Errorf("%w: превышена вложенность значений", errCorrupt)
+	}
+	if offset >= uint(len(d.buf)) {
+		return nil, 0, errCorrupt
+	}
+	ctrl := d.buf[offset]
+	offset++
+	kind := uint(ctrl >> 5)
+
+	if kind == typePointer {
+		pointer, next, err := d.pointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		// Спецификация запрещает указатель на указатель
+		if pointer < uint(len(d.buf)) && uint(d.buf[pointer]>>5) == typePointer {
+			return nil, 0, fmt.Errorf("%w: указатель ссылается на указатель", errCorrupt)
+		}
+		value, _, err := d.decodeValue(pointer, depth+1)
+		return value, next, err
+	}
+
+	if kind == typeExtended {
+		if offset >= uint(len(d.buf)) {
+			return nil, 0, errCorrupt
+		}
+		kind = 7 + uint(d.buf[offset])
+		offset++
+	}
+
+	size, offset, err := d.size(ctrl, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Каждый элемент словаря или массива занимает хотя бы байт: размер больше остатка
+	// буфера означает повреждённые данные, а не повод выделять под них память
+	if (kind == typeMap || kind == typeArray) && size > uint(len(d.buf))-offset {
+		return nil, 0, errCorrupt
+	}
+
+	switch kind {
+	case typeMap:
+		m := make(map[string]any, size)
+		for i := uint(0); i < size; i++ {
+			key, next, err := d.decodeValue(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, errCorrupt
+			}
+			value, next, err := d.decodeValue(next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[name] = value
+			offset = next
+		}
+		return m, offset, nil
+	case typeArray:
+		list := make([]any, 0, size)
+		for i := uint(0); i < size; i++ {
+			value, next, err := d.decodeValue(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			list = append(list, value)
+			offset = next
+		}
+		return list, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	case typeContainer, typeEnd:
+		return nil, offset, nil
+	}
+
+	end := offset + size
+	if end > uint(len(d.buf)) {
+		return nil, 0, errCorrupt
+	}
+	data := d.buf[offset:end]
+
+	switch kind {
+	case typeString:
+		return string(data), end, nil
+	case typeBytes:
+		return append([]byte(nil), data...), end, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, errCorrupt
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(data)), end, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, errCorrupt
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), end, nil
+	case typeUint16, typeUint32, typeUint64:
+		var n uint64
+		for _, b := range data {
+			n = n<<8 | uint64(b)
+		}
+		return n, end, nil
+	case typeInt32:
+		var n uint32
+		for _, b := range data {
+			n = n<<8 | uint32(b)
+		}
+		return int64(int32(n)), end, nil
+	case typeUint128:
+		return new(big.Int).SetBytes(data), end, nil
+	}
+	return nil, 0, fmt.Errorf("неизвестный тип данных MaxMind DB %d", kind)
+}
+
+// size читает размер значения из управляющего байта и следующих за ним байт
+func (d *decoder) size(ctrl byte, offset uint) (uint, uint, error) {
+	size := uint(ctrl & 0x1F)
+	if size < 29 {
+		return size, offset, nil
+	}
+	extra := size - 28 // 1, 2 или 3 дополнительных байта
+	if offset+extra > uint(len(d.buf)) {
+		return 0, 0, errCorrupt
+	}
+	var n uint
+	for _, b := range d.buf[offset : offset+extra] {
+		n = n<<8 | uint(b)
+	}
+	switch extra {
+	case 1:
+		size = 29 + n
+	case 2:
+		size = 285 + n
+	default:
+		size = 65821 + n
+	}
+	return size, offset + extra, nil
+}
+
+// pointer читает указатель и возвращает смещение, на которое он ссылается
+func (d *decoder) pointer(ctrl byte, offset uint) (uint, uint, error) {
+	length := uint(ctrl>>3)&0x3 + 1
+	if offset+length > uint(len(d.buf)) {
+		return 0, 0, errCorrupt
+	}
+	var n uint
+	if length < 4 {
+		n = uint(ctrl & 0x7)
+	}
+	for _, b := range d.buf[offset : offset+length] {
+		n = n<<8 | uint(b)
+	}
+	switch length {
+	case 2:
+		n += 2048
+	case 3:
+		n += 526336
+	}
+	return n, offset + length, nil
+}
+
+// toUint приводит числовое значение метаданных к uint64
+func toUint(value any) uint64 {
+	switch v := value.(type) {
+	case uint64:
+		return v
+	case int64:
+		return uint64(v)
+	}
+	return 0
+}
@@ -0,0 +1,268 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// Кодировщик значений MaxMind DB для сборки тестовых баз
+
+// encCtrl кодирует управляющий байт (и байт расширенного типа) для значения размера size
+func encCtrl(kind int, size int) []byte {
+	var out []byte
+	first := byte(kind << 5)
+	if kind > 7 {
+		first = 0
+	}
+	switch {
+	case size < 29:
+		out = []byte{first | byte(size)}
+	case size < 285:
+		out = []byte{first | 29}
+	default:
+		out = []byte{first | 30}
+	}
+	if kind > 7 {
+		out = append(out, byte(kind-7))
+	}
+	switch {
+	case size >= 285:
+		out = binary.BigEndian.AppendUint16(out, uint16(size-285))
+	case size >= 29:
+		out = append(out, byte(size-29))
+	}
+	return out
+}
+
+func encString(s string) []byte {
+	return append(encCtrl(typeString, len(s)), s...)
+}
+
+// encUint кодирует беззнаковое число минимальным числом байт
+func encUint(kind int, n uint64) []byte {
+	var data []byte
+	for ; n > 0; n >>= 8 {
+		data = append([]byte{byte(n)}, data...)
+	}
+	return append(encCtrl(kind, len(data)), data...)
+}
+
+func encInt32(n int32) []byte {
+	return append(encCtrl(typeInt32, 4), binary.BigEndian.AppendUint32(nil, uint32(n))...)
+}
+
+func encDouble(f float64) []byte {
+	return append(encCtrl(typeDouble, 8), binary.BigEndian.AppendUint64(nil, math.Float64bits(f))...)
+}
+
+func encBool(b bool) []byte {
+	if b {
+		return encCtrl(typeBool, 1)
+	}
+	return encCtrl(typeBool, 0)
+}
+
+// encPointer кодирует указатель на смещение меньше 2048
+func encPointer(offset int) []byte {
+	return []byte{byte(typePointer<<5) | byte(offset>>8&0x7), byte(offset)}
+}
+
+// encMap кодирует словарь из пар ключ (уже закодированный) — значение
+func encMap(pairs ...[]byte) []byte {
+	out := encCtrl(typeMap, len(pairs)/2)
+	for _, p := range pairs {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func encArray(items ...[]byte) []byte {
+	out := encCtrl(typeArray, len(items))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+// putRecord записывает запись узла дерева поиска
+func putRecord(tree []byte, recordSize, node, bit int, value uint32) {
+	b := tree[node*recordSize/4:]
+	switch recordSize {
+	case 24:
+		b = b[bit*3:]
+		b[0], b[1], b[2] = byte(value>>16), byte(value>>8), byte(value)
+	case 28:
+		if bit == 0 {
+			b[0], b[1], b[2] = byte(value>>16), byte(value>>8), byte(value)
+			b[3] = b[3]&0x0F | byte(value>>20)&0xF0
+		} else {
+			b[3] = b[3]&0xF0 | byte(value>>24)&0x0F
+			b[4], b[5], b[6] = byte(value>>16), byte(value>>8), byte(value)
+		}
+	default:
+		binary.BigEndian.PutUint32(b[bit*4:], value)
+	}
+}
+
+// buildTestDB собирает базу, в которой сеть network указывает на запись record раздела data.
+// Для базы IPv6 сеть IPv4 размещается в ::/96
+func buildTestDB(t *testing.T, ipVersion, recordSize int, network string, data []byte, record int) []byte {
+	t.Helper()
+	_, ipnet, err := net.ParseCIDR(network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ones, _ := ipnet.Mask.Size()
+	addr := []byte(ipnet.IP.To4())
+	if ipVersion == 6 {
+		addr = append(make([]byte, 12), addr...)
+		ones += 96
+	}
+
+	// Цепочка из ones узлов вдоль битов сети; все остальные ветви ведут в «не найдено»
+	nodeCount := ones
+	tree := make([]byte, nodeCount*recordSize/4)
+	for node := 0; node < nodeCount; node++ {
+		bit := int(addr[node/8]>>(7-node%8)) & 1
+		next := uint32(node + 1)
+		if node == nodeCount-1 {
+			next = uint32(nodeCount + dataSectionSeparator + record)
+		}
+		putRecord(tree, recordSize, node, bit, next)
+		putRecord(tree, recordSize, node, 1-bit, uint32(nodeCount))
+	}
+
+	db := append(tree, make([]byte, dataSectionSeparator)...)
+	db = append(db, data...)
+	db = append(db, metadataMarker...)
+	db = append(db, encMap(
+		encString("node_count"), encUint(typeUint32, uint64(nodeCount)),
+		encString("record_size"), encUint(typeUint16, uint64(recordSize)),
+		encString("ip_version"), encUint(typeUint16, uint64(ipVersion)),
+		encString("database_type"), encString("Test-Country"),
+	)...)
+	return db
+}
+
+// testData — раздел данных: строка "iso_code" по смещению 0, на которую ссылается запись
+func testData() ([]byte, int) {
+	data := encString("iso_code")
+	record := len(data)
+	data = append(data, encMap(
+		encString("country"), encMap(
+			encPointer(0), encString("DE"),
+			encString("names"), encMap(encString("en"), encString("Germany")),
+		),
+		encString("asn"), encUint(typeUint32, 3320),
+		encString("ratio"), encDouble(0.25),
+		encString("offset"), encInt32(-5),
+		encString("flags"), encArray(encBool(true), encBool(false)),
+		encString("big"), encUint(typeUint128, 1<<40),
+	)...)
+	return data, record
+}
+
+func TestLookup(t *testing.T) {
+	data, record := testData()
+	want := map[string]any{
+		"country": map[string]any{
+			"iso_code": "DE",
+			"names":    map[string]any{"en": "Germany"},
+		},
+		"asn":    uint64(3320),
+		"ratio":  0.25,
+		"offset": int64(-5),
+		"flags":  []any{true, false},
+		"big":    new(big.Int).SetUint64(1 << 40),
+	}
+
+	for _, tt := range []struct {
+		ipVersion, recordSize int
+	}{{4, 24}, {4, 28}, {4, 32}, {6, 24}, {6, 28}} {
+		r, err := FromBytes(buildTestDB(t, tt.ipVersion, tt.recordSize, "192.0.2.0/24", data, record))
+		if err != nil {
+			t.Fatalf("IPv%d, %d бит: %v", tt.ipVersion, tt.recordSize, err)
+		}
+		if r.DatabaseType() != "Test-Country" {
+			t.Errorf("DatabaseType() = %q", r.DatabaseType())
+		}
+
+		got, err := r.Lookup(net.ParseIP("192.0.2.77"))
+		if err != nil {
+			t.Fatalf("IPv%d, %d бит: Lookup() = %v", tt.ipVersion, tt.recordSize, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("IPv%d, %d бит: Lookup() =\n%v\nожидалось\n%v", tt.ipVersion, tt.recordSize, got, want)
+		}
+
+		for _, ip := range []string{"192.0.3.1", "10.0.0.1", "2001:db8::1"} {
+			if got, err := r.Lookup(net.ParseIP(ip)); got != nil || err != nil {
+				t.Errorf("IPv%d, %d бит: Lookup(%s) = %v, %v, ожидалось отсутствие записи", tt.ipVersion, tt.recordSize, ip, got, err)
+			}
+		}
+	}
+}
+
+func TestFromBytesErrors(t *testing.T) {
+	data, record := testData()
+	valid := buildTestDB(t, 4, 24, "192.0.2.0/24", data, record)
+
+	if _, err := FromBytes(valid[:len(valid)/2]); err == nil {
+		t.Error("ожидалась ошибка для файла без метаданных")
+	}
+
+	// Неподдерживаемый размер записи
+	badSize := append(append([]byte(nil), metadataMarker...), encMap(
+		encString("node_count"), encUint(typeUint32, 1),
+		encString("record_size"), encUint(typeUint16, 20),
+	)...)
+	if _, err := FromBytes(badSize); err == nil {
+		t.Error("ожидалась ошибка для размера записи 20 бит")
+	}
+
+	// Дерево поиска длиннее файла
+	tooManyNodes := append(append([]byte(nil), metadataMarker...), encMap(
+		encString("node_count"), encUint(typeUint32, 1000),
+		encString("record_size"), encUint(typeUint16, 24),
+	)...)
+	if _, err := FromBytes(tooManyNodes); err == nil {
+		t.Error("ожидалась ошибка для дерева длиннее файла")
+	}
+}
+
+func TestDecodeCorrupt(t *testing.T) {
+	tests := []struct {
+		name string
+		buf  []byte
+	}{
+		{"указатель на указатель", append(encPointer(2), encPointer(4)...)},
+		{"указатель на себя", encPointer(0)},
+		// Словарь, значение которого указывает обратно на сам словарь
+		{"цикл через словарь", encMap(encString("a"), encPointer(0))},
+		{"цикл через массив", encArray(encPointer(0))},
+		{"указатель за пределы данных", encPointer(100)},
+		{"обрезанная строка", encString("Germany")[:4]},
+		{"огромный словарь", []byte{byte(typeMap<<5) | 31, 0xFF, 0xFF, 0xFF}},
+		{"ключ словаря не строка", encMap(encUint(typeUint16, 1), encString("x"))},
+		{"неверная длина double", append(encCtrl(typeDouble, 4), 0, 0, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := decoder{buf: tt.buf}
+			if value, _, err := d.decode(0); !errors.Is(err, errCorrupt) {
+				t.Errorf("decode() = %v, %v, ожидалась ошибка errCorrupt", value, err)
+			}
+		})
+	}
+
+	// Указатель на строку — обычный случай, он должен разбираться
+	d := decoder{buf: append(encString("DE"), encPointer(0)...)}
+	if value, next, err := d.decode(3); err != nil || value != "DE" || next != 5 {
+		t.Errorf("decode(указатель) = %v, %d, %v", value, next, err)
+	}
+}
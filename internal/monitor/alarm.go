@@ -1,121 +1,180 @@
 package monitor
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
+	"log"
 	"strings"
+	"sync"
 	"time"
 
-	"log"
-
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-// ThresholdSettings содержит пороговые значения для уведомлений
-type ThresholdSettings struct {
-	CPUTemp      float64 `json:"cpu_temp"`      // Порог температуры CPU
-	GPUTemp      float64 `json:"gpu_temp"`      // Порог температуры GPU
-	CPUUsage     float64 `json:"cpu_usage"`     // Порог нагрузки CPU
-	GPUUsage     float64 `json:"gpu_usage"`     // Порог нагрузки GPU
-	MemoryUsage  float64 `json:"memory_usage"`  // Порог использования памяти
-	NetworkUsage float64 `json:"network_usage"` // Порог использования сети
-	DiskUsage    float64 `json:"disk_usage"`    // Порог загруженности дисков
-}
+// AlarmState — состояние отдельного правила в подписке
+type AlarmState int
 
-var (
-	thresholdsFile = "alarm_thresholds.json" // Файл для сохранения порогов
+const (
+	AlarmOK      AlarmState = iota // Значение в норме
+	AlarmPending                   // Условие выполняется, ждём истечения длительности
+	AlarmFiring                    // Условие выполняется дольше заданной длительности, уведомление отправлено
 )
 
-// LoadThresholds загружает пороговые значения из файла
-func LoadThresholds() error {
-	file, err := os.ReadFile(thresholdsFile)
-	if err != nil {
-		if os.IsNotExist(err) {
-			log.Println("Файл с пороговыми значениями не найден. Создан новый.")
-			return SaveThresholds() // Создаем файл, если он не существует
-		}
-		return err
+// String возвращает название состояния
+func (s AlarmState) String() string {
+	switch s {
+	case AlarmPending:
+		return "PENDING"
+	case AlarmFiring:
+		return "FIRING"
+	default:
+		return "OK"
 	}
-	return json.Unmarshal(file, &AlarmThresholds)
 }
 
-// SaveThresholds сохраняет пороговые значения в файл
-func SaveThresholds() error {
-	data, err := json.MarshalIndent(AlarmThresholds, "", "  ")
-	if err != nil {
-		return err
-	}
-	log.Printf("Сохранение пороговых значений: %s", string(data))
-	return os.WriteFile(thresholdsFile, data, 0644)
+// ruleState хранит состояние правила для одного чата
+type ruleState struct {
+	state    AlarmState
+	since    time.Time // Время перехода в текущее состояние
+	notified time.Time // Время последнего уведомления о превышении
 }
 
-// StartAlarmMonitor запускает мониторинг для уведомлений
-func StartAlarmMonitor(bot *tgbotapi.BotAPI, chatID int64) {
-	var lastNotification time.Time // Время последнего уведомления
+var (
+	alarmStates      = make(map[int64]map[string]*ruleState) // Состояния правил по чатам
+	alarmStatesMutex sync.Mutex
+)
 
-	for {
-		if !AlarmEnabled {
-			time.Sleep(10 * time.Second)
-			continue
-		}
+// AlarmStates возвращает состояния правил чата, отличные от OK
+func AlarmStates(chatID int64) map[string]AlarmState {
+	alarmStatesMutex.Lock()
+	defer alarmStatesMutex.Unlock()
 
-		// Проверяем, прошло ли достаточно времени с последнего уведомления
-		if time.Since(lastNotification) < time.Duration(AlarmInterval)*time.Minute {
-			time.Sleep(10 * time.Second)
-			continue
+	result := make(map[string]AlarmState)
+	for name, s := range alarmStates[chatID] {
+		if s.state != AlarmOK {
+			result[name] = s.state
 		}
+	}
+	return result
+}
 
-		// Получаем текущие значения
-		cpuTemp := GetCPUTempValue()       // float64
-		gpuTemp := GetGPUTempValue()       // float64
-		cpuUsage := GetCPUUsageValue()     // float64
-		gpuUsage := GetGPUUsageValue()     // float64
-		memUsage := GetMemoryUsageValue()  // float64
-		netUsage := GetNetworkUsageValue() // float64
-		diskUsage := GetDiskUsageValue()   // float64
-
-		// Формируем уведомление
-		var output strings.Builder
-		output.WriteString("🚨 Внимание! Превышены пороговые значения:\n")
-
-		// Проверяем каждое пороговое значение
-		if AlarmThresholds.CPUTemp > 0 && cpuTemp > AlarmThresholds.CPUTemp {
-			output.WriteString(fmt.Sprintf("🌡️ CPU: %.1f°C (порог: %.1f°C)\n", cpuTemp, AlarmThresholds.CPUTemp))
-		}
-		if AlarmThresholds.GPUTemp > 0 && gpuTemp > AlarmThresholds.GPUTemp {
-			output.WriteString(fmt.Sprintf("🌡️ GPU: %.1f°C (порог: %.1f°C)\n", gpuTemp, AlarmThresholds.GPUTemp))
-		}
-		if AlarmThresholds.CPUUsage > 0 && cpuUsage > AlarmThresholds.CPUUsage {
-			output.WriteString(fmt.Sprintf("⚙️ CPU: %.1f%% (порог: %.1f%%)\n", cpuUsage, AlarmThresholds.CPUUsage))
-		}
-		if AlarmThresholds.GPUUsage > 0 && gpuUsage > AlarmThresholds.GPUUsage {
-			output.WriteString(fmt.Sprintf("🎮 GPU: %.1f%% (порог: %.1f%%)\n", gpuUsage, AlarmThresholds.GPUUsage))
-		}
-		if AlarmThresholds.MemoryUsage > 0 && memUsage > AlarmThresholds.MemoryUsage {
-			output.WriteString(fmt.Sprintf("🧠 Память: %.1f%% (порог: %.1f%%)\n", memUsage, AlarmThresholds.MemoryUsage))
+// StartAlarmMonitor запускает единый цикл мониторинга и рассылает уведомления подписчикам
+func StartAlarmMonitor(bot *tgbotapi.BotAPI) {
+	for {
+		subs := activeSubscriptions()
+		now := time.Now()
+
+		// Берём текущие значения из сборщика метрик один раз для всех подписчиков
+		values := ruleValues(subs)
+
+		alarmStatesMutex.Lock()
+		// Забываем состояния отписавшихся и выключивших уведомления чатов
+		active := make(map[int64]bool, len(subs))
+		for _, sub := range subs {
+			active[sub.ChatID] = true
 		}
-		if AlarmThresholds.NetworkUsage > 0 && netUsage > AlarmThresholds.NetworkUsage {
-			output.WriteString(fmt.Sprintf("🌐 Сеть: %.1f МБ/с (порог: %.1f МБ/с)\n", netUsage, AlarmThresholds.NetworkUsage))
+		for chatID := range alarmStates {
+			if !active[chatID] {
+				delete(alarmStates, chatID)
+			}
 		}
-		if AlarmThresholds.DiskUsage > 0 && diskUsage > AlarmThresholds.DiskUsage {
-			output.WriteString(fmt.Sprintf("💽 Диски: %.1f%% (порог: %.1f%%)\n", diskUsage, AlarmThresholds.DiskUsage))
+
+		texts := make(map[int64]string)
+		for _, sub := range subs {
+			if text := evaluateSubscription(sub, values, now); text != "" {
+				texts[sub.ChatID] = text
+			}
 		}
+		alarmStatesMutex.Unlock()
 
-		// Если есть превышения и chatID не равен 0, отправляем уведомление
-		if output.Len() > len("🚨 Внимание! Превышены пороговые значения:\n") && chatID != 0 {
-			msg := tgbotapi.NewMessage(chatID, output.String())
+		for chatID, text := range texts {
+			msg := tgbotapi.NewMessage(chatID, text)
 			_, err := bot.Send(msg)
 			if err != nil {
-				log.Printf("Ошибка при отправке уведомления: %v", err)
+				log.Printf("Ошибка при отправке уведомления в чат %d: %v", chatID, err)
 			} else {
-				log.Println("Уведомление отправлено успешно.")
+				log.Printf("Уведомление отправлено в чат %d.", chatID)
 			}
-
-			// Обновляем время последнего уведомления
-			lastNotification = time.Now()
 		}
 
 		time.Sleep(10 * time.Second)
 	}
 }
+
+// evaluateSubscription обновляет состояния правил подписки и формирует текст уведомления
+// (вызывается под alarmStatesMutex). Возвращает пустую строку, если сообщать нечего
+func evaluateSubscription(sub Subscription, values map[string]float64, now time.Time) string {
+	states, ok := alarmStates[sub.ChatID]
+	if !ok {
+		states = make(map[string]*ruleState)
+		alarmStates[sub.ChatID] = states
+	}
+	reminder := time.Duration(sub.Interval) * time.Minute
+
+	// Забываем состояния удалённых правил
+	for name := range states {
+		if _, ok := sub.FindRule(name); !ok {
+			delete(states, name)
+		}
+	}
+
+	var firing, recovered strings.Builder
+	for _, rule := range sub.Rules {
+		// Метрики нет на этой машине (или датчик пропал) — правило не проверяется и не меняет состояние
+		value, ok := values[rule.Metric]
+		if !ok {
+			continue
+		}
+		s, ok := states[rule.Name]
+		if !ok {
+			s = &ruleState{since: now}
+			states[rule.Name] = s
+		}
+		hold := time.Duration(rule.Hold) * time.Second
+		muted := rule.Muted(now)
+		line := fmt.Sprintf("%s %s: %s = %.1f%s", SeverityIcon(rule.Severity), rule.Name, rule.Metric, value, rule.Unit())
+
+		switch {
+		case rule.Breached(value):
+			if s.state == AlarmOK {
+				s.state, s.since = AlarmPending, now
+			}
+			switch {
+			case s.state == AlarmPending && now.Sub(s.since) >= hold:
+				s.state, s.since, s.notified = AlarmFiring, now, now
+				if !muted {
+					firing.WriteString(fmt.Sprintf("%s (условие: %s)\n", line, rule))
+				}
+			case s.state == AlarmFiring && reminder > 0 && now.Sub(s.notified) >= reminder:
+				s.notified = now
+				if !muted {
+					firing.WriteString(fmt.Sprintf("%s (условие: %s, продолжается %s)\n",
+						line, rule, now.Sub(s.since).Round(time.Second)))
+				}
+			}
+		case rule.Recovered(value):
+			if s.state == AlarmFiring && !muted {
+				recovered.WriteString(fmt.Sprintf("%s (длилось %s)\n", line, now.Sub(s.since).Round(time.Second)))
+			}
+			if s.state != AlarmOK {
+				s.state, s.since = AlarmOK, now
+			}
+		default:
+			// Между порогом возврата и порогом: превышение не продержалось нужное время
+			if s.state == AlarmPending {
+				s.state, s.since = AlarmOK, now
+			}
+		}
+	}
+
+	var output strings.Builder
+	if firing.Len() > 0 {
+		output.WriteString("🚨 Внимание! Сработали правила уведомлений:\n" + firing.String())
+	}
+	if recovered.Len() > 0 {
+		if output.Len() > 0 {
+			output.WriteString("\n")
+		}
+		output.WriteString("✅ Показатели вернулись в норму:\n" + recovered.String())
+	}
+	return output.String()
+}
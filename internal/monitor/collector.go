@@ -0,0 +1,328 @@
+package monitor
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/net"
+)
+
+// Названия метрик, которые собирает фоновый сборщик
+const (
+	MetricCPU        = "cpu"          // Загрузка CPU (%)
+	MetricCPUTemp    = "cpu_temp"     // Температура CPU (°C)
+	MetricGPU        = "gpu"          // Загрузка GPU (%)
+	MetricGPUTemp    = "gpu_temp"     // Температура GPU (°C)
+	MetricGPUMem     = "gpu_mem"      // Занятая видеопамять (%)
+	MetricMemory     = "memory"       // Использование памяти (%)
+	MetricDisk       = "disk"         // Загруженность корневого диска (%)
+	MetricNetDown    = "net_down"     // Входящая скорость сети (МБ/с)
+	MetricNetUp      = "net_up"       // Исходящая скорость сети (МБ/с)
+	MetricNetRxTotal = "net_rx_total" // Всего получено с момента загрузки (МБ)
+	MetricNetTxTotal = "net_tx_total" // Всего отправлено с момента загрузки (МБ)
+)
+
+// Sample — одно измерение метрики
+type Sample struct {
+	Time  time.Time
+	Value float64
+}
+
+// Stats содержит агрегаты метрики за окно
+type Stats struct {
+	Min   float64
+	Avg   float64
+	Max   float64
+	First float64
+	Last  float64
+	Delta float64 // Last - First, полезно для счётчиков
+	Count int
+}
+
+// ringBuffer хранит последние измерения фиксированного размера
+type ringBuffer struct {
+	samples []Sample
+	next    int
+	full    bool
+}
+
+// newRingBuffer создаёт кольцевой буфер на size измерений
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{samples: make([]Sample, size)}
+}
+
+// add добавляет измерение, вытесняя самое старое
+func (r *ringBuffer) add(s Sample) {
+	r.samples[r.next] = s
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// since возвращает измерения не старше t в хронологическом порядке
+func (r *ringBuffer) since(t time.Time) []Sample {
+	var ordered []Sample
+	if r.full {
+		ordered = append(ordered, r.samples[r.next:]...)
+	}
+	ordered = append(ordered, r.samples[:r.next]...)
+
+	idx := sort.Search(len(ordered), func(i int) bool {
+		return !ordered[i].Time.Before(t)
+	})
+	return ordered[idx:]
+}
+
+// last возвращает последнее измерение
+func (r *ringBuffer) last() (Sample, bool) {
+	if !r.full && r.next == 0 {
+		return Sample{}, false
+	}
+	idx := (r.next - 1 + len(r.samples)) % len(r.samples)
+	return r.samples[idx], true
+}
+
+// Collector периодически снимает показания и хранит их историю в кольцевых буферах
+type Collector struct {
+	mutex    sync.RWMutex
+	interval time.Duration
+	capacity int
+	series   map[string]*ringBuffer
+	running  bool
+
+	// Предыдущие счётчики сетевых интерфейсов для расчёта скорости
+	prevNet     map[string]net.IOCountersStat
+	prevNetTime time.Time
+}
+
+// metrics — общий сборщик метрик
+var metrics = &Collector{series: make(map[string]*ringBuffer)}
+
+// StartCollector запускает фоновый сбор метрик с заданным интервалом и глубиной истории
+func StartCollector(interval, history time.Duration) {
+	metrics.mutex.Lock()
+	if metrics.running {
+		metrics.mutex.Unlock()
+		return
+	}
+	metrics.interval = interval
+	metrics.capacity = int(history/interval) + 1
+	metrics.running = true
+	metrics.mutex.Unlock()
+
+	log.Printf("Сборщик метрик запущен: интервал %s, история %s", interval, history)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		metrics.collect()
+		for range ticker.C {
+			metrics.collect()
+		}
+	}()
+}
+
+// collect снимает показания всех метрик и добавляет их в историю
+func (c *Collector) collect() {
+	now := time.Now()
+	values := map[string]float64{
+		MetricCPU:    GetCPUUsageValue(),
+		MetricMemory: GetMemoryUsageValue(),
+		MetricDisk:   GetDiskUsageValue(),
+	}
+	// Без датчика температуры метрики нет вовсе, чтобы правила и графики не видели ложный 0
+	if temp, _, ok := cpuTemperature(); ok {
+		values[MetricCPUTemp] = temp
+	}
+
+	// Показания каждой видеокарты и максимумы по всем видеокартам
+	gpuMetricValues(values)
+
+	// Загруженность каждого раздела
+	if partitions, err := disk.Partitions(false); err == nil {
+		for _, partition := range partitions {
+			if usage, err := disk.Usage(partition.Mountpoint); err == nil {
+				values[MetricDisk+":"+partition.Mountpoint] = usage.UsedPercent
+			}
+		}
+	}
+
+	c.collectNetwork(now, values)
+	traffic.sample(now)
+
+	c.mutex.Lock()
+	for name, value := range values {
+		buf, ok := c.series[name]
+		if !ok {
+			buf = newRingBuffer(c.capacity)
+			c.series[name] = buf
+		}
+		buf.add(Sample{Time: now, Value: value})
+	}
+	c.mutex.Unlock()
+
+	persist(now, values)
+}
+
+// collectNetwork считает скорость сети по разнице счётчиков между измерениями
+func (c *Collector) collectNetwork(now time.Time, values map[string]float64) {
+	counters, err := net.IOCounters(true)
+	if err != nil {
+		return
+	}
+
+	current := make(map[string]net.IOCountersStat, len(counters))
+	var rxTotal, txTotal uint64
+	for _, stats := range counters {
+		current[stats.Name] = stats
+		rxTotal += stats.BytesRecv
+		txTotal += stats.BytesSent
+	}
+	values[MetricNetRxTotal] = float64(rxTotal) / 1024 / 1024
+	values[MetricNetTxTotal] = float64(txTotal) / 1024 / 1024
+
+	if c.prevNet != nil {
+		seconds := now.Sub(c.prevNetTime).Seconds()
+		var down, up float64
+		for name, stats := range current {
+			prev, ok := c.prevNet[name]
+			// Пропускаем новые интерфейсы и сброшенные счётчики
+			if !ok || stats.BytesRecv < prev.BytesRecv || stats.BytesSent < prev.BytesSent {
+				continue
+			}
+			ifDown := float64(stats.BytesRecv-prev.BytesRecv) / 1024 / 1024 / seconds
+			ifUp := float64(stats.BytesSent-prev.BytesSent) / 1024 / 1024 / seconds
+			values[MetricNetDown+":"+name] = ifDown
+			values[MetricNetUp+":"+name] = ifUp
+			down += ifDown
+			up += ifUp
+		}
+		values[MetricNetDown] = down
+		values[MetricNetUp] = up
+	}
+
+	c.prevNet = current
+	c.prevNetTime = now
+}
+
+// CollectorRunning сообщает, запущен ли фоновый сборщик
+func CollectorRunning() bool {
+	metrics.mutex.RLock()
+	defer metrics.mutex.RUnlock()
+	return metrics.running
+}
+
+// Latest возвращает последнее измерение метрики
+func Latest(metric string) (Sample, bool) {
+	metrics.mutex.RLock()
+	defer metrics.mutex.RUnlock()
+
+	buf, ok := metrics.series[metric]
+	if !ok {
+		return Sample{}, false
+	}
+	return buf.last()
+}
+
+// History возвращает измерения метрики за последние window
+func History(metric string, window time.Duration) []Sample {
+	metrics.mutex.RLock()
+	defer metrics.mutex.RUnlock()
+
+	buf, ok := metrics.series[metric]
+	if !ok {
+		return nil
+	}
+	return buf.since(time.Now().Add(-window))
+}
+
+// Summary возвращает минимум, среднее, максимум и прирост метрики за последние window
+func Summary(metric string, window time.Duration) (Stats, bool) {
+	samples := History(metric, window)
+	if len(samples) == 0 {
+		return Stats{}, false
+	}
+
+	stats := Stats{
+		Min:   samples[0].Value,
+		Max:   samples[0].Value,
+		First: samples[0].Value,
+		Last:  samples[len(samples)-1].Value,
+		Count: len(samples),
+	}
+	var sum float64
+	for _, s := range samples {
+		sum += s.Value
+		if s.Value < stats.Min {
+			stats.Min = s.Value
+		}
+		if s.Value > stats.Max {
+			stats.Max = s.Value
+		}
+	}
+	stats.Avg = sum / float64(len(samples))
+	stats.Delta = stats.Last - stats.First
+	return stats, true
+}
+
+// MetricNames возвращает отсортированный список собираемых метрик
+func MetricNames() []string {
+	metrics.mutex.RLock()
+	defer metrics.mutex.RUnlock()
+
+	names := make([]string, 0, len(metrics.series))
+	for name := range metrics.series {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CurrentValue возвращает свежее значение метрики из сборщика, а если его нет — снимает показание сразу.
+// Отсутствующая на машине метрика читается как 0
+func CurrentValue(metric string) float64 {
+	value, _ := LookupValue(metric)
+	return value
+}
+
+// LookupValue возвращает свежее значение метрики и сообщает, есть ли такая метрика на машине
+func LookupValue(metric string) (float64, bool) {
+	if sample, ok := Latest(metric); ok && time.Since(sample.Time) <= 2*metrics.interval {
+		return sample.Value, true
+	}
+
+	switch {
+	case metric == MetricCPU:
+		return GetCPUUsageValue(), true
+	case metric == MetricCPUTemp:
+		temp, _, ok := cpuTemperature()
+		return temp, ok
+	case metric == MetricGPU, metric == MetricGPUTemp, metric == MetricGPUMem,
+		strings.HasPrefix(metric, MetricGPU+":"), strings.HasPrefix(metric, MetricGPUTemp+":"), strings.HasPrefix(metric, MetricGPUMem+":"):
+		values := make(map[string]float64)
+		gpuMetricValues(values)
+		value, ok := values[metric]
+		return value, ok
+	case metric == MetricMemory:
+		return GetMemoryUsageValue(), true
+	case metric == MetricDisk:
+		return GetDiskUsageValue(), true
+	case metric == MetricNetDown:
+		download, _, err := measureNetworkSpeed()
+		return download, err == nil
+	case metric == MetricNetUp:
+		_, upload, err := measureNetworkSpeed()
+		return upload, err == nil
+	case strings.HasPrefix(metric, MetricDisk+":"):
+		if usage, err := disk.Usage(strings.TrimPrefix(metric, MetricDisk+":")); err == nil {
+			return usage.UsedPercent, true
+		}
+	}
+	return 0, false
+}
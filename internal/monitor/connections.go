@@ -0,0 +1,57 @@
+package monitor
+
+import (
+	"syscall"
+
+	psnet "github.com/shirou/gopsutil/net"
+)
+
+// Connection — сетевое соединение с удалённым узлом
+type Connection struct {
+	Proto      string // tcp или udp
+	LocalIP    string
+	LocalPort  uint32
+	RemoteIP   string
+	RemotePort uint32
+	Status     string // Состояние TCP (ESTABLISHED, TIME_WAIT...), для UDP — пусто
+	PID        int32
+	Process    string
+}
+
+// ActiveConnections возвращает TCP-соединения в любом состоянии, кроме LISTEN, и подключённые UDP-сокеты
+func ActiveConnections() ([]Connection, error) {
+	conns, err := psnet.Connections("inet")
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[int32]string)
+	var list []Connection
+	for _, conn := range conns {
+		if conn.Raddr.IP == "" || conn.Raddr.Port == 0 || conn.Status == "LISTEN" {
+			continue
+		}
+		c := Connection{
+			Proto:      "tcp",
+			LocalIP:    conn.Laddr.IP,
+			LocalPort:  conn.Laddr.Port,
+			RemoteIP:   conn.Raddr.IP,
+			RemotePort: conn.Raddr.Port,
+			Status:     conn.Status,
+			PID:        conn.Pid,
+		}
+		if conn.Type == syscall.SOCK_DGRAM {
+			c.Proto, c.Status = "udp", ""
+		}
+		if c.PID > 0 {
+			name, ok := names[c.PID]
+			if !ok {
+				name = processNameByPID(c.PID)
+				names[c.PID] = name
+			}
+			c.Process = name
+		}
+		list = append(list, c)
+	}
+	return list, nil
+}
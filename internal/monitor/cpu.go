@@ -3,33 +3,28 @@ package monitor
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/shirou/gopsutil/cpu"
-	"github.com/shirou/gopsutil/host"
 )
 
 // GetCPUUsage возвращает информацию о загруженности процессора в виде строки
 func GetCPUUsage() string {
-	percent, err := cpu.Percent(0, false)
-	if err != nil {
-		return "Ошибка при получении информации о процессоре"
-	}
-	progressBar := getProgressBar(percent[0])
+	usage := CurrentValue(MetricCPU)
+	progressBar := getProgressBar(usage)
 
-	// Получение температуры процессора
-	temps, err := host.SensorsTemperatures()
-	if err != nil {
-		return fmt.Sprintf("🔄 Загрузка: %.2f%%\n%s", percent[0], progressBar)
+	// Температура процессора с датчика, выбранного в CPU_TEMP_SENSOR или автоматически
+	var tempInfo string
+	if temp, sensor, ok := cpuTemperature(); ok {
+		tempInfo += fmt.Sprintf("🌡️ Температура: %.1f°C (%s)\n", temp, sensor)
 	}
 
-	var tempInfo string
-	for _, temp := range temps {
-		if temp.SensorKey == "coretemp" || strings.Contains(temp.SensorKey, "CPU") {
-			tempInfo += fmt.Sprintf("🌡️ Температура: %.1f°C\n", temp.Temperature)
-		}
+	// Средняя и пиковая загрузка за 5 минут из истории сборщика
+	if stats, ok := Summary(MetricCPU, 5*time.Minute); ok && stats.Count > 1 {
+		tempInfo += fmt.Sprintf("📉 За 5 мин: мин %.1f%%, сред %.1f%%, макс %.1f%%\n", stats.Min, stats.Avg, stats.Max)
 	}
 
-	return fmt.Sprintf("🔄 Загрузка: %.2f%%\n%s%s", percent[0], progressBar, tempInfo)
+	return strings.TrimSuffix(fmt.Sprintf("🔄 Загрузка: %.2f%%\n%s\n%s", usage, progressBar, tempInfo), "\n")
 }
 
 // GetCPUUsageValue возвращает загрузку CPU в процентах (float64)
@@ -43,17 +38,8 @@ func GetCPUUsageValue() float64 {
 
 // GetCPUTempValue возвращает температуру CPU в °C (float64)
 func GetCPUTempValue() float64 {
-	temps, err := host.SensorsTemperatures()
-	if err != nil {
-		return 0.0
-	}
-
-	for _, temp := range temps {
-		if temp.SensorKey == "coretemp" || strings.Contains(temp.SensorKey, "CPU") {
-			return temp.Temperature
-		}
-	}
-	return 0.0
+	temp, _, _ := cpuTemperature()
+	return temp
 }
 
 // getProgressBar возвращает строку с полоской загрузки
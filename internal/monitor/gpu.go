@@ -1,281 +1,350 @@
 package monitor
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"os/exec"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 )
 
-// GetGPUUsage возвращает информацию о видеокарте в виде строки
-func GetGPUUsage() string {
-	gpuType := detectGPU()
+// GPUStats — показания одной видеокарты. Недоступные показатели равны нулю
+type GPUStats struct {
+	Index        int     // Порядковый номер среди всех видеокарт
+	Vendor       string  // nvidia, amd, intel
+	Name         string  // Модель
+	Utilization  float64 // Загрузка (%)
+	MemUsedMB    float64 // Занято видеопамяти (МБ)
+	MemTotalMB   float64 // Всего видеопамяти (МБ)
+	Temperature  float64 // Температура (°C)
+	PowerW       float64 // Потребляемая мощность (Вт)
+	FanPercent   float64 // Скорость вентилятора (%)
+	CoreClockMHz float64 // Частота ядра (МГц)
+	MemClockMHz  float64 // Частота памяти (МГц)
 
-	switch gpuType {
-	case "nvidia":
-		usage, temp, err := getNvidiaGPUUsage()
-		if err == nil {
-			return fmt.Sprintf("🎮 NVIDIA:\n🔄 Загруженность GPU: %s%%\n%s\n🌡️ Температура: %.1f°C", usage, getProgressBar(parseFloat(usage)), temp)
-		}
-	case "amd":
-		usage, temp, err := getAMDGPUUsage()
-		if err == nil {
-			return fmt.Sprintf("🎮 AMD:\n🔄 Загруженность GPU: %s%%\n%s\n🌡️ Температура: %.1f°C", usage, getProgressBar(parseFloat(usage)), temp)
-		}
-	case "intel":
-		usage, temp, err := getIntelGPUUsage()
-		if err == nil {
-			return fmt.Sprintf("🎮 Intel:\n🔄 Загруженность GPU: %s%%\n%s\n🌡️ Температура: %.1f°C", usage, getProgressBar(parseFloat(usage)), temp)
-		}
+	// Расширенные показатели, которые сообщают не все утилиты
+	PowerLimitW    float64  // Предел мощности (Вт)
+	EncoderUtil    float64  // Загрузка видеокодера (%)
+	DecoderUtil    float64  // Загрузка видеодекодера (%)
+	PState         string   // Режим производительности (P0 — максимальный)
+	PCIeGen        int      // Текущее поколение PCIe
+	PCIeMaxGen     int      // Максимальное поколение PCIe
+	PCIeWidth      int      // Текущая ширина шины PCIe (линий)
+	PCIeMaxWidth   int      // Максимальная ширина шины PCIe (линий)
+	Throttle       []string // Причины снижения частот
+	ECCEnabled     bool     // Включена коррекция ошибок памяти
+	ECCCorrected   uint64   // Исправленные ошибки памяти с момента загрузки драйвера
+	ECCUncorrected uint64   // Неисправимые ошибки памяти с момента загрузки драйвера
+}
+
+// MemPercent возвращает занятую долю видеопамяти в процентах
+func (s GPUStats) MemPercent() float64 {
+	if s.MemTotalMB <= 0 {
+		return 0
 	}
+	return s.MemUsedMB / s.MemTotalMB * 100
+}
 
-	return "🎮 Видеокарта: не удалось получить данные"
+// GPUProvider снимает показания видеокарт одного производителя
+type GPUProvider interface {
+	// Vendor возвращает производителя: nvidia, amd, intel
+	Vendor() string
+	// Stats возвращает показания всех видеокарт производителя
+	Stats() ([]GPUStats, error)
+	// ProcessMemory возвращает занятую процессами видеопамять (МБ) по PID
+	ProcessMemory() (map[int32]float64, error)
 }
 
-// GetGPUUsageValue возвращает загрузку GPU в процентах (float64)
-func GetGPUUsageValue() float64 {
-	switch detectGPU() {
-	case "nvidia":
-		usage, _, err := getNvidiaGPUUsage()
-		if err == nil {
-			return parseFloat(usage)
-		}
-	case "amd":
-		usage, _, err := getAMDGPUUsage()
-		if err == nil {
-			return parseFloat(usage)
+// gpuStatsTTL — сколько переиспользовать последние показания, чтобы сборщик, /status
+// и экспортёр в пределах одного такта не запускали утилиты производителей повторно
+const gpuStatsTTL = 2 * time.Second
+
+var (
+	gpuProviders     []GPUProvider
+	gpuDetectOnce    sync.Once
+	gpuStatsMutex    sync.Mutex
+	gpuStatsCache    []GPUStats
+	gpuStatsCachedAt time.Time
+)
+
+// GPUProviders возвращает источники показаний видеокарт. Определение выполняется один раз
+func GPUProviders() []GPUProvider {
+	gpuDetectOnce.Do(func() {
+		gpuProviders = detectGPUProviders()
+		if len(gpuProviders) == 0 {
+			log.Println("Видеокарты с поддерживаемыми утилитами мониторинга не найдены")
+			return
 		}
-	case "intel":
-		usage, _, err := getIntelGPUUsage()
-		if err == nil {
-			return parseFloat(usage)
+		vendors := make([]string, len(gpuProviders))
+		for i, p := range gpuProviders {
+			vendors[i] = p.Vendor()
 		}
-	}
-	return 0.0
+		log.Printf("Мониторинг видеокарт: %s", strings.Join(vendors, ", "))
+	})
+	return gpuProviders
 }
 
-// GetGPUTempValue возвращает температуру GPU в °C (float64)
-func GetGPUTempValue() float64 {
-	switch detectGPU() {
-	case "nvidia":
-		_, temp, err := getNvidiaGPUUsage()
-		if err == nil {
-			return temp
-		}
-	case "amd":
-		_, temp, err := getAMDGPUUsage()
-		if err == nil {
-			return temp
+// GetGPUStats возвращает показания всех видеокарт с единой нумерацией
+func GetGPUStats() []GPUStats {
+	providers := GPUProviders()
+
+	gpuStatsMutex.Lock()
+	defer gpuStatsMutex.Unlock()
+	if !gpuStatsCachedAt.IsZero() && time.Since(gpuStatsCachedAt) < gpuStatsTTL {
+		return append([]GPUStats(nil), gpuStatsCache...)
+	}
+
+	var all []GPUStats
+	for _, provider := range providers {
+		stats, err := provider.Stats()
+		if err != nil {
+			log.Printf("Ошибка получения показаний видеокарт %s: %v", provider.Vendor(), err)
+			continue
 		}
-	case "intel":
-		_, temp, err := getIntelGPUUsage()
-		if err == nil {
-			return temp
+		for _, s := range stats {
+			s.Index = len(all)
+			s.Vendor = provider.Vendor()
+			all = append(all, s)
 		}
 	}
-	return 0.0
+	gpuStatsCache, gpuStatsCachedAt = all, time.Now()
+	return append([]GPUStats(nil), all...)
 }
 
-// detectGPU определяет тип видеокарты
-func detectGPU() string {
-	switch runtime.GOOS {
-	case "linux":
-		return detectGPULinux()
-	case "windows":
-		return detectGPUWindows()
-	case "darwin":
-		return detectGPUMacOS()
-	default:
-		return "unknown"
+// GetGPUUsage возвращает информацию о видеокартах в виде строки
+func GetGPUUsage() string {
+	stats := GetGPUStats()
+	if len(stats) == 0 {
+		return "🎮 Видеокарта: не удалось получить данные"
 	}
-}
 
-// detectGPULinux определяет видеокарту на Linux
-func detectGPULinux() string {
-	out, err := exec.Command("lspci").Output()
-	if err != nil {
-		return "unknown"
-	}
-	if strings.Contains(strings.ToLower(string(out)), "nvidia") {
-		return "nvidia"
-	}
-	if strings.Contains(strings.ToLower(string(out)), "amd") {
-		return "amd"
-	}
-	if strings.Contains(strings.ToLower(string(out)), "intel") {
-		return "intel"
+	parts := make([]string, len(stats))
+	for i, s := range stats {
+		text := fmt.Sprintf("🎮 #%d %s:\n🔄 Загруженность GPU: %.0f%%\n%s",
+			s.Index, s.Name, s.Utilization, getProgressBar(s.Utilization))
+		// У встроенных видеокарт Intel датчика температуры нет
+		if s.Temperature > 0 {
+			text += fmt.Sprintf("\n🌡️ Температура: %.1f°C", s.Temperature)
+		}
+		if s.MemTotalMB > 0 {
+			text += fmt.Sprintf("\n💾 Видеопамять: %.1f ГБ из %.1f ГБ (%.0f%%)", s.MemUsedMB/1024, s.MemTotalMB/1024, s.MemPercent())
+		}
+		if s.PowerW > 0 {
+			text += fmt.Sprintf("\n⚡ Мощность: %.0f Вт", s.PowerW)
+			if s.PowerLimitW > 0 {
+				text += fmt.Sprintf(" из %.0f Вт", s.PowerLimitW)
+			}
+		}
+		if s.FanPercent > 0 {
+			text += fmt.Sprintf("\n🌀 Вентилятор: %.0f%%", s.FanPercent)
+		}
+		if s.CoreClockMHz > 0 {
+			text += fmt.Sprintf("\n⏱️ Частоты: ядро %.0f МГц", s.CoreClockMHz)
+			if s.MemClockMHz > 0 {
+				text += fmt.Sprintf(", память %.0f МГц", s.MemClockMHz)
+			}
+			if s.PState != "" {
+				text += " (" + s.PState + ")"
+			}
+		}
+		if s.EncoderUtil > 0 || s.DecoderUtil > 0 {
+			text += fmt.Sprintf("\n🎬 Кодер: %.0f%%, декодер: %.0f%%", s.EncoderUtil, s.DecoderUtil)
+		}
+		if s.PCIeGen > 0 {
+			text += fmt.Sprintf("\n🔌 PCIe %d.0 x%d", s.PCIeGen, s.PCIeWidth)
+			if s.PCIeMaxGen > s.PCIeGen || s.PCIeMaxWidth > s.PCIeWidth {
+				text += fmt.Sprintf(" (максимум %d.0 x%d)", s.PCIeMaxGen, s.PCIeMaxWidth)
+			}
+		}
+		if len(s.Throttle) > 0 {
+			text += "\n🐢 Снижение частот: " + strings.Join(s.Throttle, ", ")
+		}
+		if s.ECCEnabled {
+			text += fmt.Sprintf("\n🧮 Ошибки ECC: исправлено %d, неисправимых %d", s.ECCCorrected, s.ECCUncorrected)
+		}
+		parts[i] = text
 	}
-	return "unknown"
+	return strings.Join(parts, "\n\n")
 }
 
-// detectGPUWindows определяет видеокарту на Windows
-func detectGPUWindows() string {
-	out, err := exec.Command("wmic", "path", "win32_VideoController", "get", "name").Output()
-	if err != nil {
-		return "unknown"
-	}
-	if strings.Contains(strings.ToLower(string(out)), "nvidia") {
-		return "nvidia"
-	}
-	if strings.Contains(strings.ToLower(string(out)), "amd") {
-		return "amd"
-	}
-	if strings.Contains(strings.ToLower(string(out)), "intel") {
-		return "intel"
+// GetGPUUsageValue возвращает загрузку самой загруженной видеокарты в процентах
+func GetGPUUsageValue() float64 {
+	var value float64
+	for _, s := range GetGPUStats() {
+		value = max(value, s.Utilization)
 	}
-	return "unknown"
+	return value
 }
 
-// detectGPUMacOS определяет видеокарту на macOS
-func detectGPUMacOS() string {
-	out, err := exec.Command("system_profiler", "SPDisplaysDataType").Output()
-	if err != nil {
-		return "unknown"
-	}
-	if strings.Contains(strings.ToLower(string(out)), "nvidia") {
-		return "nvidia"
-	}
-	if strings.Contains(strings.ToLower(string(out)), "amd") {
-		return "amd"
-	}
-	if strings.Contains(strings.ToLower(string(out)), "intel") {
-		return "intel"
+// GetGPUTempValue возвращает температуру самой горячей видеокарты в °C
+func GetGPUTempValue() float64 {
+	var value float64
+	for _, s := range GetGPUStats() {
+		value = max(value, s.Temperature)
 	}
-	return "unknown"
+	return value
 }
 
-// getNvidiaGPUUsage возвращает загруженность и температуру видеокарты NVIDIA
-func getNvidiaGPUUsage() (string, float64, error) {
-	out, err := exec.Command("nvidia-smi", "--query-gpu=utilization.gpu,temperature.gpu", "--format=csv,noheader,nounits").Output()
-	if err != nil {
-		return "", 0, err
-	}
-	data := strings.Split(strings.TrimSpace(string(out)), ", ")
-	if len(data) < 2 {
-		return "", 0, fmt.Errorf("неверный формат данных")
-	}
-	usage := data[0]
-	temp := parseFloat(data[1])
-	return usage, temp, nil
+// gpuMetricValues добавляет в values показания каждой видеокарты (gpu:N, gpu_temp:N, gpu_mem:N)
+// и максимумы по всем видеокартам (gpu, gpu_temp, gpu_mem). Температура и видеопамять, которых
+// видеокарта не сообщает (нулевые в GPUStats), не записываются и не входят в максимумы, чтобы
+// правила и графики не видели ложный 0. Без видеокарт values не меняется
+func gpuMetricValues(values map[string]float64) {
+	addGPUMetricValues(values, GetGPUStats())
 }
 
-// getAMDGPUUsage возвращает загруженность и температуру видеокарты AMD
-func getAMDGPUUsage() (string, float64, error) {
-	out, err := exec.Command("rocm-smi", "--showuse", "--showtemp").Output()
-	if err != nil {
-		return "", 0, err
+// addGPUMetricValues добавляет в values метрики видеокарт stats (см. gpuMetricValues)
+func addGPUMetricValues(values map[string]float64, stats []GPUStats) {
+	if len(stats) == 0 {
+		return
 	}
-	lines := strings.Split(string(out), "\n")
-	var usage, temp string
-	for _, line := range lines {
-		if strings.Contains(line, "GPU use") {
-			usage = strings.TrimSpace(strings.Split(line, ":")[1])
+	var usage, temp, memory float64
+	var hasTemp, hasMemory bool
+	for _, s := range stats {
+		index := fmt.Sprint(s.Index)
+		values[MetricGPU+":"+index] = s.Utilization
+		usage = max(usage, s.Utilization)
+		if s.Temperature > 0 {
+			values[MetricGPUTemp+":"+index] = s.Temperature
+			temp, hasTemp = max(temp, s.Temperature), true
 		}
-		if strings.Contains(line, "Temperature") {
-			temp = strings.TrimSpace(strings.Split(line, ":")[1])
+		if s.MemTotalMB > 0 {
+			values[MetricGPUMem+":"+index] = s.MemPercent()
+			memory, hasMemory = max(memory, s.MemPercent()), true
 		}
 	}
-	return usage, parseFloat(temp), nil
-}
-
-// getIntelGPUUsage возвращает загруженность и температуру видеокарты Intel
-func getIntelGPUUsage() (string, float64, error) {
-	out, err := exec.Command("intel_gpu_top", "-l", "-o", "-").Output()
-	if err != nil {
-		return "", 0, err
+	values[MetricGPU] = usage
+	if hasTemp {
+		values[MetricGPUTemp] = temp
 	}
-	lines := strings.Split(string(out), "\n")
-	var usage, temp string
-	for _, line := range lines {
-		if strings.Contains(line, "Render/3D") {
-			usage = strings.TrimSpace(strings.Split(line, ":")[1])
-		}
-		if strings.Contains(line, "Temperature") {
-			temp = strings.TrimSpace(strings.Split(line, ":")[1])
-		}
+	if hasMemory {
+		values[MetricGPUMem] = memory
 	}
-	return usage, parseFloat(temp), nil
 }
 
-// parseFloat преобразует строку в число
-func parseFloat(s string) float64 {
-	var f float64
-	fmt.Sscanf(s, "%f", &f)
-	return f
+// GetGPUMemoryForProcess возвращает видеопамять (МБ), занятую конкретным процессом
+func GetGPUMemoryForProcess(pid int32) float64 {
+	return GetGPUMemoryByProcess()[pid]
 }
 
-// GetGPUUsageForProcess возвращает нагрузку на GPU для конкретного процесса
-func GetGPUUsageForProcess(pid int32) float64 {
-	switch detectGPU() {
-	case "nvidia":
-		usage, _, err := getNvidiaGPUUsageForProcess(pid)
-		if err == nil {
-			return usage
-		}
-	case "amd":
-		usage, _, err := getAMDGPUUsageForProcess(pid)
-		if err == nil {
-			return usage
+// GetGPUMemoryByProcess возвращает видеопамять (МБ), занятую процессами на всех видеокартах.
+// Источник каждого производителя (nvidia-smi или fdinfo) опрашивается один раз на весь список
+func GetGPUMemoryByProcess() map[int32]float64 {
+	usage := make(map[int32]float64)
+	for _, provider := range GPUProviders() {
+		byPID, err := provider.ProcessMemory()
+		if err != nil {
+			continue
 		}
-	case "intel":
-		usage, _, err := getIntelGPUUsageForProcess(pid)
-		if err == nil {
-			return usage
+		for pid, value := range byPID {
+			usage[pid] += value
 		}
 	}
-	return 0.0
+	return usage
 }
 
-// getNvidiaGPUUsageForProcess возвращает нагрузку на GPU NVIDIA для процесса
-func getNvidiaGPUUsageForProcess(pid int32) (float64, float64, error) {
-	out, err := exec.Command("nvidia-smi", "--query-compute-apps=pid,used_memory", "--format=csv,noheader,nounits").Output()
-	if err != nil {
-		return 0, 0, err
+// detectGPUProviders выбирает источники показаний установленных видеокарт: NVIDIA — через
+// nvidia-smi, AMD и Intel — через sysfs (только Linux)
+func detectGPUProviders() []GPUProvider {
+	var providers []GPUProvider
+	if _, err := exec.LookPath("nvidia-smi"); err == nil {
+		// Без списка устройств (например, в контейнере без lspci) полагаемся на наличие утилиты
+		if vendors, listed := listGPUVendors(); !listed || vendors["nvidia"] {
+			providers = append(providers, &nvidiaProvider{})
+		}
 	}
-	lines := strings.Split(string(out), "\n")
-	for _, line := range lines {
-		var p int32
-		var usage float64
-		fmt.Sscanf(line, "%d, %f", &p, &usage)
-		if p == pid {
-			return usage, 0, nil
+
+	amd, intel := &amdProvider{}, &intelProvider{}
+	for _, card := range drmCards() {
+		switch card.Driver {
+		case "amdgpu":
+			amd.cards = append(amd.cards, card)
+		case "i915", "xe":
+			intel.cards = append(intel.cards, card)
 		}
 	}
-	return 0, 0, fmt.Errorf("процесс не найден")
+	if len(amd.cards) > 0 {
+		providers = append(providers, amd)
+	}
+	if len(intel.cards) > 0 {
+		providers = append(providers, intel)
+	}
+	return providers
 }
 
-// getAMDGPUUsageForProcess возвращает нагрузку на GPU AMD для процесса
-func getAMDGPUUsageForProcess(pid int32) (float64, float64, error) {
-	out, err := exec.Command("rocm-smi", "--showpidgpus").Output()
+// listGPUVendors возвращает производителей видеоадаптеров из списка устройств ОС.
+// Второе значение false, если список получить не удалось
+func listGPUVendors() (map[string]bool, bool) {
+	var out []byte
+	var err error
+	switch runtime.GOOS {
+	case "linux":
+		out, err = exec.Command("lspci").Output()
+	case "windows":
+		out, err = exec.Command("wmic", "path", "win32_VideoController", "get", "name").Output()
+	case "darwin":
+		out, err = exec.Command("system_profiler", "SPDisplaysDataType").Output()
+	default:
+		return nil, false
+	}
 	if err != nil {
-		return 0, 0, err
+		return nil, false
 	}
-	lines := strings.Split(string(out), "\n")
-	for _, line := range lines {
-		var p int32
-		var usage float64
-		fmt.Sscanf(line, "%d %f", &p, &usage)
-		if p == pid {
-			return usage, 0, nil
+
+	vendors := make(map[string]bool)
+	for _, line := range strings.Split(strings.ToLower(string(out)), "\n") {
+		// В lspci учитываем только видеоадаптеры: у процессоров AMD и Intel есть и другие устройства
+		if runtime.GOOS == "linux" && !strings.Contains(line, "vga") &&
+			!strings.Contains(line, "3d controller") && !strings.Contains(line, "display controller") {
+			continue
+		}
+		switch {
+		case strings.Contains(line, "nvidia"):
+			vendors["nvidia"] = true
+		case strings.Contains(line, "amd"), strings.Contains(line, "radeon"), strings.Contains(line, "ati "):
+			vendors["amd"] = true
+		case strings.Contains(line, "intel"):
+			vendors["intel"] = true
 		}
 	}
-	return 0, 0, fmt.Errorf("процесс не найден")
+	return vendors, true
 }
 
-// getIntelGPUUsageForProcess возвращает нагрузку на GPU Intel для процесса
-func getIntelGPUUsageForProcess(pid int32) (float64, float64, error) {
-	out, err := exec.Command("intel_gpu_top", "-l", "-o", "-").Output()
+// gpuToolTimeout ограничивает время работы утилит производителей
+const gpuToolTimeout = 5 * time.Second
+
+// runGPUTool запускает утилиту производителя с ограничением по времени
+func runGPUTool(name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gpuToolTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, name, args...).Output()
+}
+
+// parseGPUProcessList запускает утилиту и разбирает строки вида "<pid> <видеопамять>" по формату format.
+// Подходит только для утилит, которые завершаются сами и выводят список одним блоком
+// (nvidia-smi); AMD и Intel берут процессы из fdinfo без внешних утилит
+func parseGPUProcessList(format, name string, args ...string) (map[int32]float64, error) {
+	out, err := runGPUTool(name, args...)
 	if err != nil {
-		return 0, 0, err
+		return nil, err
 	}
-	lines := strings.Split(string(out), "\n")
-	for _, line := range lines {
-		var p int32
-		var usage float64
-		fmt.Sscanf(line, "%d %f", &p, &usage)
-		if p == pid {
-			return usage, 0, nil
+	usage := make(map[int32]float64)
+	for _, line := range strings.Split(string(out), "\n") {
+		var pid int32
+		var value float64
+		if n, _ := fmt.Sscanf(line, format, &pid, &value); n == 2 && pid > 0 {
+			usage[pid] += value
 		}
 	}
-	return 0, 0, fmt.Errorf("процесс не найден")
+	return usage, nil
+}
+
+// parseFloat преобразует строку в число
+func parseFloat(s string) float64 {
+	var f float64
+	fmt.Sscanf(s, "%f", &f)
+	return f
 }
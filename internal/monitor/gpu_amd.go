@@ -0,0 +1,70 @@
+package monitor
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// amdProvider читает показания видеокарт AMD (драйвер amdgpu) из sysfs
+type amdProvider struct {
+	cards []drmCard
+}
+
+func (*amdProvider) Vendor() string { return "amd" }
+
+func (p *amdProvider) Stats() ([]GPUStats, error) {
+	stats := make([]GPUStats, 0, len(p.cards))
+	for _, card := range p.cards {
+		stats = append(stats, amdCardStats(card))
+	}
+	return stats, nil
+}
+
+// amdCardStats читает показания одной видеокарты AMD. Единицы sysfs: память в байтах,
+// температура в милли°C, мощность в микроваттах, частоты в герцах
+func amdCardStats(card drmCard) GPUStats {
+	dev := card.Device
+	hwmon := hwmonDir(dev)
+
+	s := GPUStats{Name: readSysfsString(filepath.Join(dev, "product_name"))}
+	if s.Name == "" {
+		s.Name = fmt.Sprintf("AMD Radeon (%s)", card.Name)
+	}
+	s.Utilization, _ = readSysfsFloat(filepath.Join(dev, "gpu_busy_percent"))
+	if used, ok := readSysfsFloat(filepath.Join(dev, "mem_info_vram_used")); ok {
+		s.MemUsedMB = used / 1024 / 1024
+	}
+	if total, ok := readSysfsFloat(filepath.Join(dev, "mem_info_vram_total")); ok {
+		s.MemTotalMB = total / 1024 / 1024
+	}
+	s.PCIeGen, s.PCIeMaxGen, s.PCIeWidth, s.PCIeMaxWidth = pcieLink(dev)
+
+	if hwmon == "" {
+		return s
+	}
+	s.Temperature, _ = hwmonTemp(hwmon, "edge")
+	if power, ok := readSysfsFloat(filepath.Join(hwmon, "power1_average"), filepath.Join(hwmon, "power1_input")); ok {
+		s.PowerW = power / 1e6
+	}
+	if limit, ok := readSysfsFloat(filepath.Join(hwmon, "power1_cap")); ok {
+		s.PowerLimitW = limit / 1e6
+	}
+	if pwm, ok := readSysfsFloat(filepath.Join(hwmon, "pwm1")); ok {
+		pwmMax, ok := readSysfsFloat(filepath.Join(hwmon, "pwm1_max"))
+		if !ok || pwmMax <= 0 {
+			pwmMax = 255
+		}
+		s.FanPercent = pwm / pwmMax * 100
+	}
+	if sclk, ok := readSysfsFloat(filepath.Join(hwmon, "freq1_input")); ok {
+		s.CoreClockMHz = sclk / 1e6
+	}
+	if mclk, ok := readSysfsFloat(filepath.Join(hwmon, "freq2_input")); ok {
+		s.MemClockMHz = mclk / 1e6
+	}
+	return s
+}
+
+func (*amdProvider) ProcessMemory() (map[int32]float64, error) {
+	return drmClientMemory("amdgpu"), nil
+}
@@ -0,0 +1,104 @@
+package monitor
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// intelSampleDelay — пауза между двумя первыми замерами, по которым считается загрузка
+const intelSampleDelay = 250 * time.Millisecond
+
+// intelSample — накопительные счётчики видеокарты Intel на момент замера
+type intelSample struct {
+	time   time.Time
+	rc6    float64 // Время в режиме простоя RC6 (мс)
+	energy float64 // Потреблённая энергия (мкДж)
+}
+
+// intelProvider читает показания видеокарт Intel (драйверы i915 и xe) из sysfs.
+// Загрузка и мощность вычисляются по приросту счётчиков между вызовами
+type intelProvider struct {
+	cards []drmCard
+	mutex sync.Mutex
+	prev  map[string]intelSample
+}
+
+func (*intelProvider) Vendor() string { return "intel" }
+
+func (p *intelProvider) Stats() ([]GPUStats, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.prev == nil {
+		// Первый вызов: делаем опорный замер, чтобы сразу показать загрузку
+		p.prev = make(map[string]intelSample)
+		for _, card := range p.cards {
+			p.prev[card.Name] = intelCardSample(card)
+		}
+		time.Sleep(intelSampleDelay)
+	}
+
+	stats := make([]GPUStats, 0, len(p.cards))
+	for _, card := range p.cards {
+		s := intelCardStats(card)
+		current := intelCardSample(card)
+		if prev, ok := p.prev[card.Name]; ok {
+			elapsed := current.time.Sub(prev.time)
+			if elapsed > 0 {
+				// Загрузка — доля времени вне режима простоя RC6
+				if current.rc6 > 0 || prev.rc6 > 0 {
+					idle := (current.rc6 - prev.rc6) / float64(elapsed.Milliseconds()) * 100
+					s.Utilization = min(max(100-idle, 0), 100)
+				}
+				if s.PowerW == 0 && current.energy > prev.energy {
+					s.PowerW = (current.energy - prev.energy) / 1e6 / elapsed.Seconds()
+				}
+			}
+		}
+		p.prev[card.Name] = current
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+// intelCardSample снимает накопительные счётчики видеокарты
+func intelCardSample(card drmCard) intelSample {
+	sample := intelSample{time: time.Now()}
+	sample.rc6, _ = readSysfsFloat(
+		filepath.Join(card.Dir, "gt", "gt0", "rc6_residency_ms"),
+		filepath.Join(card.Dir, "power", "rc6_residency_ms"),
+		filepath.Join(card.Device, "tile0", "gt0", "gtidle", "idle_residency_ms"), // Драйвер xe
+	)
+	if hwmon := hwmonDir(card.Device); hwmon != "" {
+		sample.energy, _ = readSysfsFloat(filepath.Join(hwmon, "energy1_input"))
+	}
+	return sample
+}
+
+// intelCardStats читает мгновенные показания видеокарты Intel
+func intelCardStats(card drmCard) GPUStats {
+	s := GPUStats{Name: fmt.Sprintf("Intel Graphics (%s)", card.Name)}
+	s.CoreClockMHz, _ = readSysfsFloat(
+		filepath.Join(card.Dir, "gt_act_freq_mhz"),
+		filepath.Join(card.Dir, "gt", "gt0", "rps_act_freq_mhz"),
+		filepath.Join(card.Device, "tile0", "gt0", "freq0", "act_freq"), // Драйвер xe
+	)
+	s.PCIeGen, s.PCIeMaxGen, s.PCIeWidth, s.PCIeMaxWidth = pcieLink(card.Device)
+
+	// Датчики hwmon есть только у дискретных видеокарт (Arc)
+	if hwmon := hwmonDir(card.Device); hwmon != "" {
+		s.Temperature, _ = hwmonTemp(hwmon, "")
+		if power, ok := readSysfsFloat(filepath.Join(hwmon, "power1_input")); ok {
+			s.PowerW = power / 1e6
+		}
+		if limit, ok := readSysfsFloat(filepath.Join(hwmon, "power1_max")); ok {
+			s.PowerLimitW = limit / 1e6
+		}
+	}
+	return s
+}
+
+func (*intelProvider) ProcessMemory() (map[int32]float64, error) {
+	return drmClientMemory("i915", "xe"), nil
+}
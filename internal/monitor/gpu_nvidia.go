@@ -0,0 +1,298 @@
+package monitor
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// nvidiaSMILog — корень вывода nvidia-smi -q -x
+type nvidiaSMILog struct {
+	DriverVersion string      `xml:"driver_version"`
+	CUDAVersion   string      `xml:"cuda_version"`
+	GPUs          []nvidiaGPU `xml:"gpu"`
+}
+
+// nvidiaGPU — раздел <gpu> вывода nvidia-smi -q -x. Названия некоторых разделов
+// менялись между версиями драйвера, поэтому старые и новые варианты перечислены вместе
+type nvidiaGPU struct {
+	ID          string `xml:"id,attr"` // Адрес на шине PCI
+	ProductName string `xml:"product_name"`
+	UUID        string `xml:"uuid"`
+	MinorNumber string `xml:"minor_number"`
+	PState      string `xml:"performance_state"`
+	FanSpeed    string `xml:"fan_speed"`
+
+	PCI struct {
+		Link struct {
+			MaxGen       string `xml:"pcie_gen>max_link_gen"`
+			CurrentGen   string `xml:"pcie_gen>current_link_gen"`
+			MaxWidth     string `xml:"link_widths>max_link_width"`
+			CurrentWidth string `xml:"link_widths>current_link_width"`
+		} `xml:"pci_gpu_link_info"`
+	} `xml:"pci"`
+
+	ThrottleReasons nvidiaReasons `xml:"clocks_throttle_reasons"` // До драйвера 535
+	EventReasons    nvidiaReasons `xml:"clocks_event_reasons"`    // Драйвер 535 и новее
+
+	Memory struct {
+		Total string `xml:"total"`
+		Used  string `xml:"used"`
+	} `xml:"fb_memory_usage"`
+
+	Utilization struct {
+		GPU     string `xml:"gpu_util"`
+		Memory  string `xml:"memory_util"`
+		Encoder string `xml:"encoder_util"`
+		Decoder string `xml:"decoder_util"`
+	} `xml:"utilization"`
+
+	ECCMode struct {
+		Current string `xml:"current_ecc"`
+	} `xml:"ecc_mode"`
+	ECCErrors struct {
+		Volatile nvidiaECCCounters `xml:"volatile"`
+	} `xml:"ecc_errors"`
+
+	Temperature struct {
+		GPU string `xml:"gpu_temp"`
+	} `xml:"temperature"`
+
+	Power    nvidiaPower `xml:"power_readings"`     // До драйвера 530
+	GPUPower nvidiaPower `xml:"gpu_power_readings"` // Драйвер 530 и новее
+
+	Clocks struct {
+		Graphics string `xml:"graphics_clock"`
+		Memory   string `xml:"mem_clock"`
+	} `xml:"clocks"`
+
+	Processes []nvidiaProcess `xml:"processes>process_info"`
+}
+
+// nvidiaReasons — список причин снижения частот вида <clocks_throttle_reason_sw_power_cap>Active</...>
+type nvidiaReasons struct {
+	Items []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+// nvidiaECCCounters — счётчики ошибок памяти. Старые драйверы группируют их по числу
+// ошибочных бит, новые — по типу памяти
+type nvidiaECCCounters struct {
+	SingleBitTotal    string `xml:"single_bit>total"`
+	DoubleBitTotal    string `xml:"double_bit>total"`
+	SRAMCorrectable   string `xml:"sram_correctable"`
+	SRAMUncorrectable string `xml:"sram_uncorrectable"`
+	DRAMCorrectable   string `xml:"dram_correctable"`
+	DRAMUncorrectable string `xml:"dram_uncorrectable"`
+}
+
+// nvidiaPower — показания питания
+type nvidiaPower struct {
+	PowerDraw         string `xml:"power_draw"`
+	AveragePowerDraw  string `xml:"average_power_draw"`
+	InstantPowerDraw  string `xml:"instant_power_draw"`
+	PowerLimit        string `xml:"power_limit"`
+	CurrentPowerLimit string `xml:"current_power_limit"`
+}
+
+// nvidiaProcess — процесс, использующий видеокарту
+type nvidiaProcess struct {
+	PID        int32  `xml:"pid"`
+	Type       string `xml:"type"` // C — вычисления, G — графика
+	Name       string `xml:"process_name"`
+	UsedMemory string `xml:"used_memory"`
+}
+
+// nvidiaThrottleTitles — описания причин снижения частот. Простой (gpu_idle) проблемой не считается
+var nvidiaThrottleTitles = map[string]string{
+	"applications_clocks_setting": "частоты заданы приложением",
+	"sw_power_cap":                "предел мощности",
+	"hw_slowdown":                 "аппаратное замедление",
+	"hw_thermal_slowdown":         "перегрев (аппаратно)",
+	"hw_power_brake_slowdown":     "внешнее ограничение питания",
+	"sync_boost":                  "синхронизация частот",
+	"sw_thermal_slowdown":         "перегрев (программно)",
+	"display_clocks_setting":      "частоты дисплея",
+}
+
+// parseNvidiaSMILog разбирает вывод nvidia-smi -q -x
+func parseNvidiaSMILog(data []byte) (*nvidiaSMILog, error) {
+	var smi nvidiaSMILog
+	if err := xml.Unmarshal(data, &smi); err != nil {
+		return nil, fmt.Errorf("неверный формат данных nvidia-smi: %w", err)
+	}
+	return &smi, nil
+}
+
+// Stats переводит раздел <gpu> в общие показания видеокарты
+func (g nvidiaGPU) Stats() GPUStats {
+	power := g.GPUPower
+	if power == (nvidiaPower{}) {
+		power = g.Power
+	}
+
+	s := GPUStats{
+		Name:         g.ProductName,
+		Utilization:  parseFloat(g.Utilization.GPU),
+		MemUsedMB:    parseFloat(g.Memory.Used),
+		MemTotalMB:   parseFloat(g.Memory.Total),
+		Temperature:  parseFloat(g.Temperature.GPU),
+		PowerW:       firstPositive(power.PowerDraw, power.AveragePowerDraw, power.InstantPowerDraw),
+		FanPercent:   parseFloat(g.FanSpeed),
+		CoreClockMHz: parseFloat(g.Clocks.Graphics),
+		MemClockMHz:  parseFloat(g.Clocks.Memory),
+
+		PowerLimitW:  firstPositive(power.CurrentPowerLimit, power.PowerLimit),
+		EncoderUtil:  parseFloat(g.Utilization.Encoder),
+		DecoderUtil:  parseFloat(g.Utilization.Decoder),
+		PCIeGen:      int(parseFloat(g.PCI.Link.CurrentGen)),
+		PCIeMaxGen:   int(parseFloat(g.PCI.Link.MaxGen)),
+		PCIeWidth:    int(parseFloat(g.PCI.Link.CurrentWidth)),
+		PCIeMaxWidth: int(parseFloat(g.PCI.Link.MaxWidth)),
+		ECCEnabled:   strings.EqualFold(g.ECCMode.Current, "Enabled"),
+	}
+	if g.PState != "" && !strings.Contains(g.PState, "N/A") {
+		s.PState = g.PState
+	}
+
+	for _, reasons := range []nvidiaReasons{g.ThrottleReasons, g.EventReasons} {
+		for _, item := range reasons.Items {
+			if !strings.EqualFold(strings.TrimSpace(item.Value), "Active") {
+				continue
+			}
+			name := item.XMLName.Local
+			name = strings.TrimPrefix(name, "clocks_throttle_reason_")
+			name = strings.TrimPrefix(name, "clocks_event_reason_")
+			if title, ok := nvidiaThrottleTitles[name]; ok {
+				s.Throttle = append(s.Throttle, title)
+			}
+		}
+	}
+
+	if s.ECCEnabled {
+		ecc := g.ECCErrors.Volatile
+		s.ECCCorrected = uint64(parseFloat(ecc.SingleBitTotal) + parseFloat(ecc.SRAMCorrectable) + parseFloat(ecc.DRAMCorrectable))
+		s.ECCUncorrected = uint64(parseFloat(ecc.DoubleBitTotal) + parseFloat(ecc.SRAMUncorrectable) + parseFloat(ecc.DRAMUncorrectable))
+	}
+	return s
+}
+
+// firstPositive возвращает первое положительное значение из строк с числами
+func firstPositive(values ...string) float64 {
+	for _, value := range values {
+		if f := parseFloat(value); f > 0 {
+			return f
+		}
+	}
+	return 0
+}
+
+// nvidiaProvider снимает показания видеокарт NVIDIA через nvidia-smi
+type nvidiaProvider struct {
+	mutex    sync.Mutex
+	last     *nvidiaSMILog
+	lastTime time.Time
+}
+
+func (*nvidiaProvider) Vendor() string { return "nvidia" }
+
+// query запускает nvidia-smi -q -x. Показания и список процессов берутся из одного вызова,
+// поэтому результат переиспользуется в течение gpuStatsTTL
+func (p *nvidiaProvider) query() (*nvidiaSMILog, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.last != nil && time.Since(p.lastTime) < gpuStatsTTL {
+		return p.last, nil
+	}
+	out, err := runGPUTool("nvidia-smi", "-q", "-x")
+	if err != nil {
+		return nil, err
+	}
+	smi, err := parseNvidiaSMILog(out)
+	if err != nil {
+		return nil, err
+	}
+	p.last, p.lastTime = smi, time.Now()
+	return smi, nil
+}
+
+func (p *nvidiaProvider) Stats() ([]GPUStats, error) {
+	smi, err := p.query()
+	if err != nil {
+		return nil, err
+	}
+	if len(smi.GPUs) == 0 {
+		return nil, fmt.Errorf("nvidia-smi не нашёл видеокарт")
+	}
+	stats := make([]GPUStats, len(smi.GPUs))
+	for i, gpu := range smi.GPUs {
+		stats[i] = gpu.Stats()
+	}
+	return stats, nil
+}
+
+// ProcessMemory возвращает занятую процессами видеопамять (МБ). Если драйвер не сообщает
+// процессы в XML (например, в контейнере), используются pmon и --query-compute-apps
+func (p *nvidiaProvider) ProcessMemory() (map[int32]float64, error) {
+	smi, err := p.query()
+	if err == nil {
+		usage := make(map[int32]float64)
+		for _, gpu := range smi.GPUs {
+			for _, proc := range gpu.Processes {
+				if proc.PID > 0 {
+					usage[proc.PID] += parseFloat(proc.UsedMemory)
+				}
+			}
+		}
+		if len(usage) > 0 {
+			return usage, nil
+		}
+	}
+	if out, err := runGPUTool("nvidia-smi", "pmon", "-c", "1", "-s", "m"); err == nil {
+		if usage := parseNvidiaPmon(out); len(usage) > 0 {
+			return usage, nil
+		}
+	}
+	return parseGPUProcessList("%d, %f", "nvidia-smi", "--query-compute-apps=pid,used_memory", "--format=csv,noheader,nounits")
+}
+
+// parseNvidiaPmon разбирает вывод nvidia-smi pmon -s m. Столбец с видеопамятью (fb)
+// ищется по заголовку: новые драйверы добавляют после него столбец ccpm
+func parseNvidiaPmon(out []byte) map[int32]float64 {
+	usage := make(map[int32]float64)
+	pidColumn, fbColumn := -1, -1
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "#" {
+			// Первая строка заголовка содержит названия столбцов, вторая — единицы измерения
+			if pidColumn < 0 {
+				for i, name := range fields[1:] {
+					switch name {
+					case "pid":
+						pidColumn = i
+					case "fb":
+						fbColumn = i
+					}
+				}
+			}
+			continue
+		}
+		if pidColumn < 0 || fbColumn < 0 || len(fields) <= max(pidColumn, fbColumn) {
+			continue
+		}
+		pid, err := strconv.ParseInt(fields[pidColumn], 10, 32)
+		if err != nil || pid <= 0 {
+			continue // "-" в строке видеокарты без процессов
+		}
+		usage[int32(pid)] += parseFloat(fields[fbColumn])
+	}
+	return usage
+}
@@ -0,0 +1,221 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestParseNvidiaSMILog(t *testing.T) {
+	tests := []struct {
+		fixture string
+		driver  string
+		cuda    string
+		ids     []string
+	}{
+		{"nvidia-smi-470.xml", "470.223.02", "11.4", []string{"00000000:01:00.0", "00000000:02:00.0"}},
+		{"nvidia-smi-550.xml", "550.54.15", "12.4", []string{"00000000:41:00.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			smi := readNvidiaFixture(t, tt.fixture)
+			if smi.DriverVersion != tt.driver || smi.CUDAVersion != tt.cuda {
+				t.Errorf("версии = %q, %q, ожидалось %q, %q", smi.DriverVersion, smi.CUDAVersion, tt.driver, tt.cuda)
+			}
+			var ids []string
+			for _, gpu := range smi.GPUs {
+				ids = append(ids, gpu.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.ids) {
+				t.Errorf("видеокарты = %v, ожидалось %v", ids, tt.ids)
+			}
+		})
+	}
+
+	if _, err := parseNvidiaSMILog([]byte("NVIDIA-SMI has failed because it couldn't communicate with the NVIDIA driver")); err == nil {
+		t.Error("ожидалась ошибка для вывода не в формате XML")
+	}
+}
+
+func TestNvidiaGPUStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		gpu     int
+		want    GPUStats
+	}{
+		{
+			name:    "драйвер 470: power_readings, clocks_throttle_reasons, ECC по числу бит",
+			fixture: "nvidia-smi-470.xml",
+			want: GPUStats{
+				Name: "Tesla T4", Utilization: 87, MemUsedMB: 6044, MemTotalMB: 15109, Temperature: 71,
+				PowerW: 62.35, CoreClockMHz: 1590, MemClockMHz: 5000,
+				PowerLimitW: 70, EncoderUtil: 12, DecoderUtil: 3, PState: "P0",
+				PCIeGen: 3, PCIeMaxGen: 3, PCIeWidth: 8, PCIeMaxWidth: 16,
+				Throttle:   []string{"предел мощности", "перегрев (программно)"},
+				ECCEnabled: true, ECCCorrected: 5, ECCUncorrected: 1,
+			},
+		},
+		{
+			name:    "драйвер 470: простаивающая видеокарта без ECC",
+			fixture: "nvidia-smi-470.xml",
+			gpu:     1,
+			want: GPUStats{
+				Name: "Tesla T4", MemUsedMB: 3, MemTotalMB: 15109, Temperature: 34,
+				PowerW: 9.81, CoreClockMHz: 300, MemClockMHz: 405, PowerLimitW: 70, PState: "P8",
+			},
+		},
+		{
+			name:    "драйвер 550: gpu_power_readings, clocks_event_reasons, ECC по типу памяти",
+			fixture: "nvidia-smi-550.xml",
+			want: GPUStats{
+				Name: "NVIDIA RTX A6000", Utilization: 64, MemUsedMB: 24570, MemTotalMB: 49140, Temperature: 66,
+				PowerW: 212.47, FanPercent: 45, CoreClockMHz: 1755, MemClockMHz: 8001,
+				PowerLimitW: 300, PState: "P2",
+				PCIeGen: 4, PCIeMaxGen: 4, PCIeWidth: 16, PCIeMaxWidth: 16,
+				Throttle:   []string{"аппаратное замедление"},
+				ECCEnabled: true, ECCCorrected: 7, ECCUncorrected: 2,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := readNvidiaFixture(t, tt.fixture).GPUs[tt.gpu].Stats()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Stats() =\n%+v\nожидалось\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNvidiaPmon(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want map[int32]float64
+	}{
+		{
+			name: "со столбцом ccpm",
+			out:  readFixture(t, "nvidia-smi-pmon.txt"),
+			want: map[int32]float64{4242: 5000, 1313: 1040},
+		},
+		{
+			name: "старый драйвер без ccpm",
+			out: "# gpu        pid  type    fb    command\n" +
+				"# Idx          #   C/G    MB    name\n" +
+				"    0       2001     C   300    a.out\n" +
+				"    1       2001     C   200    a.out\n",
+			want: map[int32]float64{2001: 500},
+		},
+		{
+			name: "нет процессов",
+			out:  "# gpu        pid  type    fb    command\n    0          -     -     -    -\n",
+			want: map[int32]float64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseNvidiaPmon([]byte(tt.out)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNvidiaPmon() = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeNvidiaSMI — подменный nvidia-smi: отдаёт XML из FAKE_NVIDIA_XML, вывод pmon
+// из FAKE_NVIDIA_PMON и фиксированный список --query-compute-apps
+const fakeNvidiaSMI = `#!/bin/sh
+case "$1" in
+-q) cat "$FAKE_NVIDIA_XML" ;;
+pmon) cat "$FAKE_NVIDIA_PMON" ;;
+--query-compute-apps=*) printf '77, 128\n78, 64\n' ;;
+*) exit 2 ;;
+esac
+`
+
+// installFakeNvidiaSMI кладёт подменный nvidia-smi в начало PATH
+func installFakeNvidiaSMI(t *testing.T, xmlPath, pmonPath string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("подменная утилита написана на sh")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "nvidia-smi"), []byte(fakeNvidiaSMI), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("FAKE_NVIDIA_XML", xmlPath)
+	t.Setenv("FAKE_NVIDIA_PMON", pmonPath)
+}
+
+func TestNvidiaProviderStats(t *testing.T) {
+	installFakeNvidiaSMI(t, filepath.Join("testdata", "nvidia-smi-470.xml"), "")
+
+	stats, err := (&nvidiaProvider{}).Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 || stats[0].Utilization != 87 || stats[1].Temperature != 34 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+
+	t.Setenv("FAKE_NVIDIA_XML", filepath.Join(t.TempDir(), "missing.xml"))
+	if _, err := (&nvidiaProvider{}).Stats(); err == nil {
+		t.Error("ожидалась ошибка, когда nvidia-smi завершился с ошибкой")
+	}
+}
+
+func TestNvidiaProviderProcessMemory(t *testing.T) {
+	// XML без процессов, как у драйвера в контейнере
+	noProcesses := filepath.Join(t.TempDir(), "no-processes.xml")
+	if err := os.WriteFile(noProcesses, []byte(`<nvidia_smi_log><gpu id="0"><processes></processes></gpu></nvidia_smi_log>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pmon := filepath.Join("testdata", "nvidia-smi-pmon.txt")
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+
+	tests := []struct {
+		name string
+		xml  string
+		pmon string
+		want map[int32]float64
+	}{
+		{"процессы из XML", filepath.Join("testdata", "nvidia-smi-470.xml"), pmon, map[int32]float64{4242: 5000, 1313: 1040}},
+		{"процессы из pmon", noProcesses, pmon, map[int32]float64{4242: 5000, 1313: 1040}},
+		{"процессы из --query-compute-apps", noProcesses, missing, map[int32]float64{77: 128, 78: 64}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installFakeNvidiaSMI(t, tt.xml, tt.pmon)
+			got, err := (&nvidiaProvider{}).ProcessMemory()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProcessMemory() = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// readNvidiaFixture разбирает записанный вывод nvidia-smi -q -x из testdata
+func readNvidiaFixture(t *testing.T, name string) *nvidiaSMILog {
+	t.Helper()
+	smi, err := parseNvidiaSMILog([]byte(readFixture(t, name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return smi
+}
+
+// readFixture читает файл из testdata
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,234 @@
+package monitor
+
+import (
+	"TG_BOT_GO/internal/config"
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// drmCard — видеокарта из /sys/class/drm
+type drmCard struct {
+	Name   string // card0
+	Dir    string // Каталог /sys/class/drm/card0
+	Device string // Каталог устройства PCI (card0/device)
+	Driver string // amdgpu, i915, xe, nvidia...
+}
+
+// drmCardName отсекает разъёмы видеокарт вида card0-DP-1
+var drmCardName = regexp.MustCompile(`^card(\d+)$`)
+
+// drmCards возвращает видеокарты из <SYSFS_ROOT>/class/drm, упорядоченные по номеру
+func drmCards() []drmCard {
+	dirs, err := filepath.Glob(filepath.Join(config.GetSysfsRoot(), "class", "drm", "card*"))
+	if err != nil {
+		return nil
+	}
+
+	var cards []drmCard
+	for _, dir := range dirs {
+		name := filepath.Base(dir)
+		if !drmCardName.MatchString(name) {
+			continue
+		}
+		card := drmCard{Name: name, Dir: dir, Device: filepath.Join(dir, "device")}
+		for _, line := range strings.Split(readSysfsString(filepath.Join(card.Device, "uevent")), "\n") {
+			if driver, ok := strings.CutPrefix(line, "DRIVER="); ok {
+				card.Driver = driver
+			}
+		}
+		cards = append(cards, card)
+	}
+	sort.Slice(cards, func(i, j int) bool {
+		a, _ := strconv.Atoi(drmCardName.FindStringSubmatch(cards[i].Name)[1])
+		b, _ := strconv.Atoi(drmCardName.FindStringSubmatch(cards[j].Name)[1])
+		return a < b
+	})
+	return cards
+}
+
+// readSysfsString читает файл sysfs без завершающего перевода строки
+func readSysfsString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// readSysfsFloat читает число из первого существующего файла paths
+func readSysfsFloat(paths ...string) (float64, bool) {
+	for _, path := range paths {
+		value := readSysfsString(path)
+		if value == "" {
+			continue
+		}
+		if f, err := strconv.ParseFloat(strings.Fields(value)[0], 64); err == nil {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+// hwmonDir возвращает каталог датчиков hwmon устройства
+func hwmonDir(device string) string {
+	dirs, _ := filepath.Glob(filepath.Join(device, "hwmon", "hwmon*"))
+	if len(dirs) == 0 {
+		return ""
+	}
+	sort.Strings(dirs)
+	return dirs[0]
+}
+
+// hwmonTemp возвращает температуру датчика с подписью label (или первого датчика) в °C
+func hwmonTemp(dir, label string) (float64, bool) {
+	if dir == "" {
+		return 0, false
+	}
+	inputs, _ := filepath.Glob(filepath.Join(dir, "temp*_input"))
+	sort.Strings(inputs)
+	for _, input := range inputs {
+		if strings.EqualFold(readSysfsString(strings.TrimSuffix(input, "_input")+"_label"), label) {
+			value, ok := readSysfsFloat(input)
+			return value / 1000, ok
+		}
+	}
+	if len(inputs) > 0 {
+		value, ok := readSysfsFloat(inputs[0])
+		return value / 1000, ok
+	}
+	return 0, false
+}
+
+// pcieLink читает текущие и максимальные поколение и ширину шины PCIe устройства
+func pcieLink(device string) (gen, maxGen, width, maxWidth int) {
+	speed, _ := readSysfsFloat(filepath.Join(device, "current_link_speed"))
+	maxSpeed, _ := readSysfsFloat(filepath.Join(device, "max_link_speed"))
+	w, _ := readSysfsFloat(filepath.Join(device, "current_link_width"))
+	maxW, _ := readSysfsFloat(filepath.Join(device, "max_link_width"))
+	return pcieGeneration(speed), pcieGeneration(maxSpeed), int(w), int(maxW)
+}
+
+// pcieGeneration переводит скорость линии (ГТ/с) в поколение PCIe
+func pcieGeneration(speed float64) int {
+	switch {
+	case speed >= 64:
+		return 6
+	case speed >= 32:
+		return 5
+	case speed >= 16:
+		return 4
+	case speed >= 8:
+		return 3
+	case speed >= 5:
+		return 2
+	case speed > 0:
+		return 1
+	}
+	return 0
+}
+
+// drmClientMemory возвращает видеопамять (МБ), занятую процессами через драйверы drivers.
+// Данные берутся из <PROCFS_ROOT>/<pid>/fdinfo открытых файлов /dev/dri (ядро 5.19 и новее).
+// Один клиент может быть открыт через несколько дескрипторов, поэтому клиенты учитываются по drm-client-id
+func drmClientMemory(drivers ...string) map[int32]float64 {
+	usage := make(map[int32]float64)
+
+	procRoot := config.GetProcfsRoot()
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return usage
+	}
+	for _, entry := range entries {
+		pid, err := strconv.ParseInt(entry.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(procRoot, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		clients := make(map[string]bool)
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "/dev/dri/") {
+				continue
+			}
+			client, memory := parseDRMFdinfo(filepath.Join(procRoot, entry.Name(), "fdinfo", fd.Name()), drivers)
+			if client == "" || clients[client] {
+				continue
+			}
+			clients[client] = true
+			if memory > 0 {
+				usage[int32(pid)] += memory
+			}
+		}
+	}
+	return usage
+}
+
+// parseDRMFdinfo читает идентификатор клиента и занятую видеопамять (МБ) из fdinfo.
+// Возвращает пустой идентификатор, если дескриптор принадлежит другому драйверу
+func parseDRMFdinfo(path string, drivers []string) (string, float64) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0
+	}
+	defer file.Close()
+
+	var driver, client string
+	var memory float64
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case key == "drm-driver":
+			driver = value
+		case key == "drm-client-id":
+			client = value
+		// amdgpu сообщает drm-memory-vram, i915 и xe — drm-total-local0 и т. п.
+		case key == "drm-memory-vram", strings.HasPrefix(key, "drm-total-local"), strings.HasPrefix(key, "drm-total-vram"):
+			memory += parseDRMSize(value)
+		}
+	}
+	for _, d := range drivers {
+		if d == driver {
+			return client, memory
+		}
+	}
+	return "", 0
+}
+
+// parseDRMSize переводит размер вида "1024 KiB" в мегабайты
+func parseDRMSize(value string) float64 {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0
+	}
+	size, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0
+	}
+	unit := ""
+	if len(fields) > 1 {
+		unit = fields[1]
+	}
+	switch unit {
+	case "KiB":
+		return size / 1024
+	case "MiB":
+		return size
+	case "GiB":
+		return size * 1024
+	}
+	return size / 1024 / 1024
+}
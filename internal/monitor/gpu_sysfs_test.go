@@ -0,0 +1,247 @@
+package monitor
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeTree создаёт файлы с содержимым в каталоге root
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// fakeDRMTree создаёт в SYSFS_ROOT видеокарты AMD (card0, с разъёмом card0-DP-1),
+// Intel i915 (card1), Intel xe (card2) и NVIDIA (card10)
+func fakeDRMTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	t.Setenv("SYSFS_ROOT", root)
+	writeTree(t, root, map[string]string{
+		"class/drm/card0/device/uevent":                             "DRIVER=amdgpu\nPCI_ID=1002:73BF",
+		"class/drm/card0/device/product_name":                       "Radeon RX 6800 XT",
+		"class/drm/card0/device/gpu_busy_percent":                   "42",
+		"class/drm/card0/device/mem_info_vram_used":                 "4294967296",
+		"class/drm/card0/device/mem_info_vram_total":                "17163091968",
+		"class/drm/card0/device/current_link_speed":                 "8.0 GT/s PCIe",
+		"class/drm/card0/device/max_link_speed":                     "16.0 GT/s PCIe",
+		"class/drm/card0/device/current_link_width":                 "16",
+		"class/drm/card0/device/max_link_width":                     "16",
+		"class/drm/card0/device/hwmon/hwmon3/temp1_input":           "61000",
+		"class/drm/card0/device/hwmon/hwmon3/temp1_label":           "edge",
+		"class/drm/card0/device/hwmon/hwmon3/temp2_input":           "74000",
+		"class/drm/card0/device/hwmon/hwmon3/temp2_label":           "junction",
+		"class/drm/card0/device/hwmon/hwmon3/power1_average":        "187000000",
+		"class/drm/card0/device/hwmon/hwmon3/power1_cap":            "255000000",
+		"class/drm/card0/device/hwmon/hwmon3/pwm1":                  "102",
+		"class/drm/card0/device/hwmon/hwmon3/pwm1_max":              "255",
+		"class/drm/card0/device/hwmon/hwmon3/freq1_input":           "2250000000",
+		"class/drm/card0/device/hwmon/hwmon3/freq2_input":           "1000000000",
+		"class/drm/card0-DP-1/status":                               "connected",
+		"class/drm/card1/device/uevent":                             "DRIVER=i915\nPCI_ID=8086:56A0",
+		"class/drm/card1/gt_act_freq_mhz":                           "2050",
+		"class/drm/card1/gt/gt0/rc6_residency_ms":                   "250",
+		"class/drm/card1/device/current_link_speed":                 "2.5 GT/s PCIe",
+		"class/drm/card1/device/current_link_width":                 "1",
+		"class/drm/card1/device/hwmon/hwmon5/temp1_input":           "48000",
+		"class/drm/card1/device/hwmon/hwmon5/energy1_input":         "5000000",
+		"class/drm/card1/device/hwmon/hwmon5/power1_max":            "190000000",
+		"class/drm/card10/device/uevent":                            "DRIVER=nvidia",
+		"class/drm/card2/device/uevent":                             "DRIVER=xe",
+		"class/drm/card2/device/tile0/gt0/freq0/act_freq":           "1300",
+		"class/drm/card2/device/tile0/gt0/gtidle/idle_residency_ms": "900",
+	})
+	return root
+}
+
+func TestDRMCards(t *testing.T) {
+	fakeDRMTree(t)
+
+	var got [][2]string
+	for _, card := range drmCards() {
+		got = append(got, [2]string{card.Name, card.Driver})
+	}
+	want := [][2]string{{"card0", "amdgpu"}, {"card1", "i915"}, {"card2", "xe"}, {"card10", "nvidia"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("drmCards() = %v, ожидалось %v", got, want)
+	}
+}
+
+func TestAMDCardStats(t *testing.T) {
+	root := fakeDRMTree(t)
+
+	got := amdCardStats(drmCards()[0])
+	want := GPUStats{
+		Name: "Radeon RX 6800 XT", Utilization: 42, MemUsedMB: 4096, MemTotalMB: 16368,
+		Temperature: 61, PowerW: 187, PowerLimitW: 255, FanPercent: 40,
+		CoreClockMHz: 2250, MemClockMHz: 1000,
+		PCIeGen: 3, PCIeMaxGen: 4, PCIeWidth: 16, PCIeMaxWidth: 16,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("amdCardStats() =\n%+v\nожидалось\n%+v", got, want)
+	}
+
+	// Без hwmon и product_name остаются показания устройства и имя по умолчанию
+	if err := os.RemoveAll(filepath.Join(root, "class/drm/card0/device/hwmon")); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(filepath.Join(root, "class/drm/card0/device/product_name"))
+	got = amdCardStats(drmCards()[0])
+	if got.Name != "AMD Radeon (card0)" || got.Utilization != 42 || got.Temperature != 0 || got.PowerW != 0 {
+		t.Errorf("amdCardStats() без hwmon = %+v", got)
+	}
+}
+
+func TestIntelProviderStats(t *testing.T) {
+	fakeDRMTree(t)
+	cards := drmCards()[1:3]
+
+	// Опорный замер секунду назад: RC6 и энергия были нулевыми
+	start := time.Now().Add(-time.Second)
+	p := &intelProvider{cards: cards, prev: map[string]intelSample{
+		"card1": {time: start},
+		"card2": {time: start},
+	}}
+	stats, err := p.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("Stats() вернул %d видеокарт, ожидалось 2", len(stats))
+	}
+
+	i915 := stats[0]
+	if i915.Name != "Intel Graphics (card1)" || i915.CoreClockMHz != 2050 || i915.Temperature != 48 ||
+		i915.PowerLimitW != 190 || i915.PCIeGen != 1 || i915.PCIeWidth != 1 {
+		t.Errorf("показания i915 = %+v", i915)
+	}
+	// 250 мс простоя за ~1 с — загрузка ~75%, 5 Дж за ~1 с — ~5 Вт
+	if math.Abs(i915.Utilization-75) > 2 {
+		t.Errorf("загрузка i915 = %.2f, ожидалось ~75", i915.Utilization)
+	}
+	if math.Abs(i915.PowerW-5) > 0.2 {
+		t.Errorf("мощность i915 = %.2f, ожидалось ~5", i915.PowerW)
+	}
+
+	xe := stats[1]
+	if xe.CoreClockMHz != 1300 || math.Abs(xe.Utilization-10) > 2 {
+		t.Errorf("показания xe = %+v, ожидалась частота 1300 и загрузка ~10%%", xe)
+	}
+
+	// Следующий вызов считает загрузку по приросту относительно запомненного замера
+	if p.prev["card1"].rc6 != 250 {
+		t.Errorf("замер не сохранён: %+v", p.prev["card1"])
+	}
+}
+
+func TestDRMClientMemory(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("PROCFS_ROOT", root)
+	writeTree(t, root, map[string]string{
+		// Клиент amdgpu открыт через два дескриптора и должен учитываться один раз
+		"123/fdinfo/3":  "pos:\t0\ndrm-driver:\tamdgpu\ndrm-client-id:\t7\ndrm-memory-vram:\t2048 KiB\ndrm-memory-gtt:\t512 KiB",
+		"123/fdinfo/4":  "pos:\t0\ndrm-driver:\tamdgpu\ndrm-client-id:\t7\ndrm-memory-vram:\t2048 KiB",
+		"123/fdinfo/5":  "pos:\t0",
+		"456/fdinfo/10": "drm-driver:\ti915\ndrm-client-id:\t3\ndrm-total-local0:\t512 MiB\ndrm-total-system0:\t64 MiB",
+		"self/fdinfo/1": "pos:\t0",
+	})
+	links := map[string]string{
+		"123/fd/3":  "/dev/dri/renderD128",
+		"123/fd/4":  "/dev/dri/card0",
+		"123/fd/5":  "/dev/null",
+		"456/fd/10": "/dev/dri/renderD129",
+	}
+	for name, target := range links {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, path); err != nil {
+			t.Skip("символические ссылки недоступны:", err)
+		}
+	}
+
+	if got, want := drmClientMemory("amdgpu"), map[int32]float64{123: 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("drmClientMemory(amdgpu) = %v, ожидалось %v", got, want)
+	}
+	if got, want := drmClientMemory("i915", "xe"), map[int32]float64{456: 512}; !reflect.DeepEqual(got, want) {
+		t.Errorf("drmClientMemory(i915, xe) = %v, ожидалось %v", got, want)
+	}
+}
+
+func TestSysfsProvidersRunNoTools(t *testing.T) {
+	// Утилиты, которые не завершаются сами (intel_gpu_top -l) или выводят список в
+	// непредсказуемом виде (rocm-smi), не должны запускаться ни при каком опросе
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "started")
+	for _, tool := range []string{"intel_gpu_top", "rocm-smi", "amd-smi", "xpu-smi"} {
+		script := "#!/bin/sh\necho " + tool + " >> " + marker + "\n"
+		if err := os.WriteFile(filepath.Join(dir, tool), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv("PROCFS_ROOT", t.TempDir())
+	fakeDRMTree(t)
+	cards := drmCards()
+
+	providers := []GPUProvider{
+		&amdProvider{cards: cards[:1]},
+		&intelProvider{cards: cards[1:3], prev: map[string]intelSample{}},
+	}
+	for _, p := range providers {
+		if _, err := p.Stats(); err != nil {
+			t.Errorf("%s: Stats() = %v", p.Vendor(), err)
+		}
+		if _, err := p.ProcessMemory(); err != nil {
+			t.Errorf("%s: ProcessMemory() = %v", p.Vendor(), err)
+		}
+	}
+	if started, err := os.ReadFile(marker); err == nil {
+		t.Errorf("запущены внешние утилиты: %s", started)
+	}
+}
+
+func TestGPUMetricValuesSkipMissingReadings(t *testing.T) {
+	stats := []GPUStats{
+		// Встроенная Intel без hwmon и без сведений об объёме видеопамяти
+		{Index: 0, Vendor: "intel", Utilization: 15},
+		{Index: 1, Vendor: "amd", Utilization: 40, Temperature: 55, MemUsedMB: 1024, MemTotalMB: 4096},
+	}
+	values := make(map[string]float64)
+	addGPUMetricValues(values, stats)
+
+	want := map[string]float64{
+		MetricGPU + ":0":     15,
+		MetricGPU + ":1":     40,
+		MetricGPUTemp + ":1": 55,
+		MetricGPUMem + ":1":  25,
+		MetricGPU:            40,
+		MetricGPUTemp:        55,
+		MetricGPUMem:         25,
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("метрики = %v, ожидалось %v", values, want)
+	}
+
+	// Ни одна видеокарта не сообщает температуру и видеопамять — метрик нет совсем
+	values = make(map[string]float64)
+	addGPUMetricValues(values, stats[:1])
+	for _, metric := range []string{MetricGPUTemp, MetricGPUMem, MetricGPUTemp + ":0", MetricGPUMem + ":0"} {
+		if _, ok := values[metric]; ok {
+			t.Errorf("метрика %s записана без показаний: %v", metric, values)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package monitor
+
+import (
+	"TG_BOT_GO/internal/storage"
+	"log"
+	"strings"
+	"time"
+)
+
+// metricsStore — хранилище метрик на диске (nil, если не подключено)
+var metricsStore *storage.Store
+
+// SetMetricsStore подключает хранилище, в которое сборщик записывает каждое измерение
+func SetMetricsStore(store *storage.Store) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	metricsStore = store
+}
+
+// persist записывает измерения в хранилище, если оно подключено
+func persist(now time.Time, values map[string]float64) {
+	metrics.mutex.RLock()
+	store := metricsStore
+	metrics.mutex.RUnlock()
+
+	if store == nil {
+		return
+	}
+	if err := store.Append(now, values); err != nil {
+		log.Printf("Ошибка при записи метрик на диск: %v", err)
+	}
+}
+
+// QueryHistory возвращает значения метрики за интервал с шагом step.
+// Данные берутся из хранилища на диске, а без него — из истории в памяти
+func QueryHistory(metric string, from, to time.Time, step time.Duration) ([]storage.Point, error) {
+	metrics.mutex.RLock()
+	store := metricsStore
+	metrics.mutex.RUnlock()
+
+	if store != nil {
+		return store.Query(metric, from, to, step)
+	}
+
+	var points []storage.Point
+	for _, s := range History(metric, time.Since(from)) {
+		if s.Time.After(to) {
+			break
+		}
+		bucketTime := from.Add(s.Time.Sub(from) / step * step)
+		if n := len(points); n > 0 && points[n-1].Time.Equal(bucketTime) {
+			p := &points[n-1]
+			p.Avg = (p.Avg*float64(p.Count) + s.Value) / float64(p.Count+1)
+			p.Count++
+			if s.Value < p.Min {
+				p.Min = s.Value
+			}
+			if s.Value > p.Max {
+				p.Max = s.Value
+			}
+			continue
+		}
+		points = append(points, storage.Point{Time: bucketTime, Avg: s.Value, Min: s.Value, Max: s.Value, Count: 1})
+	}
+	return points, nil
+}
+
+// MetricUnit возвращает единицу измерения метрики
+func MetricUnit(metric string) string {
+	base := metric
+	if idx := strings.Index(metric, ":"); idx >= 0 {
+		base = metric[:idx]
+	}
+
+	switch base {
+	case MetricCPUTemp, MetricGPUTemp:
+		return "°C"
+	case MetricNetDown, MetricNetUp:
+		return "МБ/с"
+	case MetricNetRxTotal, MetricNetTxTotal:
+		return "МБ"
+	default:
+		return "%"
+	}
+}
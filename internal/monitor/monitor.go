@@ -2,7 +2,6 @@ package monitor
 
 // Экспортируем переменные для использования в других пакетах
 var (
-	AlarmEnabled    bool                  // Флаг включения/выключения уведомлений
-	AlarmThresholds = ThresholdSettings{} // Пороговые значения
-	AlarmInterval   int                   // Интервал между уведомлениями (в минутах)
+	DefaultRules  []Rule // Правила по умолчанию для новых подписок
+	AlarmInterval = 5    // Интервал напоминаний о продолжающемся превышении по умолчанию (в минутах)
 )
@@ -5,11 +5,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"sort"
 	"time"
 
 	"github.com/shirou/gopsutil/net"
-	"github.com/shirou/gopsutil/process"
 )
 
 // IPInfo представляет информацию о IP-адресе
@@ -24,15 +22,20 @@ type IPInfo struct {
 
 // ProcessTraffic представляет трафик процесса
 type ProcessTraffic struct {
+	PID        int32
 	Name       string
-	DownloadMB float64
-	UploadMB   float64
+	DownSpeed  float64 // Входящая скорость (МБ/с)
+	UpSpeed    float64 // Исходящая скорость (МБ/с)
+	DownloadMB float64 // Получено (МБ)
+	UploadMB   float64 // Отправлено (МБ)
 }
 
 // TrafficStats представляет статистику трафика
 type TrafficStats struct {
 	DownloadMB float64
 	UploadMB   float64
+	DownSpeed  float64 // Входящая скорость (МБ/с)
+	UpSpeed    float64 // Исходящая скорость (МБ/с)
 }
 
 // GetIPInfo возвращает информацию о внешнем IP
@@ -57,52 +60,50 @@ func GetIPInfo() (IPInfo, error) {
 	return info, nil
 }
 
-// GetTopProcesses возвращает топ-3 процессов по использованию сети
-func GetTopProcesses() ([]ProcessTraffic, error) {
-	processes, err := process.Processes()
-	if err != nil {
+// GetTopProcesses возвращает процессы с наибольшей текущей скоростью сети (TCP, только Linux)
+func GetTopProcesses(limit int) ([]ProcessTraffic, error) {
+	if err := traffic.ensureFresh(); err != nil {
 		return nil, err
 	}
 
-	var traffic []ProcessTraffic
-	for _, p := range processes {
-		name, _ := p.Name()
-		conns, _ := net.ConnectionsPid("all", p.Pid)
-
-		var download, upload float64
-		for _, conn := range conns {
-			if conn.Status == "ESTABLISHED" {
-				// Используем BytesRecv и BytesSent из IOCounters
-				io, err := net.IOCounters(false)
-				if err != nil {
-					continue
-				}
-				download += float64(io[0].BytesRecv) / 1024 / 1024
-				upload += float64(io[0].BytesSent) / 1024 / 1024
-			}
-		}
-
-		traffic = append(traffic, ProcessTraffic{
-			Name:       name,
-			DownloadMB: download,
-			UploadMB:   upload,
-		})
+	traffic.mutex.Lock()
+	list := make([]ProcessTraffic, 0, len(traffic.rates))
+	for _, p := range traffic.rates {
+		list = append(list, p)
 	}
+	traffic.mutex.Unlock()
+
+	return topTraffic(list, limit, func(p ProcessTraffic) float64 { return p.DownSpeed + p.UpSpeed }), nil
+}
 
-	// Сортируем по убыванию входящего трафика
-	sort.Slice(traffic, func(i, j int) bool {
-		return traffic[i].DownloadMB > traffic[j].DownloadMB
-	})
+// GetTopProcessesAllTime возвращает приложения с наибольшим трафиком с момента запуска бота
+func GetTopProcessesAllTime(limit int) ([]ProcessTraffic, error) {
+	if err := traffic.ensureFresh(); err != nil {
+		return nil, err
+	}
 
-	// Возвращаем топ-3
-	if len(traffic) > 3 {
-		return traffic[:3], nil
+	traffic.mutex.Lock()
+	list := make([]ProcessTraffic, 0, len(traffic.totals))
+	for _, p := range traffic.totals {
+		list = append(list, *p)
 	}
-	return traffic, nil
+	traffic.mutex.Unlock()
+
+	return topTraffic(list, limit, func(p ProcessTraffic) float64 { return p.DownloadMB + p.UploadMB }), nil
 }
 
-// GetNetworkSpeed возвращает текущую скорость сети
+// GetNetworkSpeed возвращает текущую скорость сети из сборщика метрик (без ожидания)
 func GetNetworkSpeed() (float64, float64, error) {
+	down, okDown := Latest(MetricNetDown)
+	up, okUp := Latest(MetricNetUp)
+	if okDown && okUp {
+		return down.Value, up.Value, nil
+	}
+	return measureNetworkSpeed()
+}
+
+// measureNetworkSpeed измеряет скорость сети за одну секунду
+func measureNetworkSpeed() (float64, float64, error) {
 	io1, err := net.IOCounters(false)
 	if err != nil {
 		return 0, 0, err
@@ -123,25 +124,20 @@ func GetNetworkSpeed() (float64, float64, error) {
 
 // GetNetworkUsageValue возвращает текущую скорость сети в МБ/с (float64)
 func GetNetworkUsageValue() float64 {
-	download, _, err := GetNetworkSpeed()
-	if err != nil {
-		return 0.0
-	}
-	return download
+	return CurrentValue(MetricNetDown)
 }
 
-// GetTrafficLast5Min возвращает общий трафик за последние 5 минут
+// GetTrafficLast5Min возвращает общий трафик за последние 5 минут по истории сборщика метрик
 func GetTrafficLast5Min() (TrafficStats, error) {
-	io, err := net.IOCounters(false)
-	if err != nil {
-		return TrafficStats{}, err
+	rx, okRx := Summary(MetricNetRxTotal, 5*time.Minute)
+	tx, okTx := Summary(MetricNetTxTotal, 5*time.Minute)
+	if !okRx || !okTx {
+		return TrafficStats{}, nil // История ещё не накоплена
 	}
 
-	// TODO: Реализовать логику для сбора данных за 5 минут
-	// Пока возвращаем текущие значения
 	return TrafficStats{
-		DownloadMB: float64(io[0].BytesRecv) / 1024 / 1024,
-		UploadMB:   float64(io[0].BytesSent) / 1024 / 1024,
+		DownloadMB: rx.Delta,
+		UploadMB:   tx.Delta,
 	}, nil
 }
 
@@ -166,32 +162,44 @@ func GetNetworkUsage() string {
 			float64(stats.BytesRecv)/1024/1024,
 			float64(stats.BytesSent)/1024/1024,
 		)
+
+		// Текущая скорость интерфейса из сборщика метрик
+		down, okDown := Latest(MetricNetDown + ":" + stats.Name)
+		up, okUp := Latest(MetricNetUp + ":" + stats.Name)
+		if okDown && okUp {
+			networkInfo += fmt.Sprintf("  🚀 Скорость: ⬇️ %.2f МБ/с, ⬆️ %.2f МБ/с\n", down.Value, up.Value)
+		}
 	}
 
 	return networkInfo
 }
 
-// GetNetworkUsageForProcess возвращает сетевую активность для конкретного процесса
+// GetNetworkUsageForProcess возвращает текущую скорость и трафик процесса с момента запуска бота
 func GetNetworkUsageForProcess(pid int32) (TrafficStats, error) {
-	conns, err := net.ConnectionsPid("all", pid)
-	if err != nil {
-		return TrafficStats{}, err
-	}
-
-	var downloadMB, uploadMB float64
-	for _, conn := range conns {
-		if conn.Status == "ESTABLISHED" {
-			io, err := net.IOCounters(false)
-			if err != nil {
-				continue
-			}
-			downloadMB += float64(io[0].BytesRecv) / 1024 / 1024
-			uploadMB += float64(io[0].BytesSent) / 1024 / 1024
-		}
+	usage, err := GetNetworkUsageByProcess()
+	return usage[pid], err
+}
+
+// GetNetworkUsageByProcess возвращает скорость и трафик всех процессов, у которых был сетевой трафик.
+// Для списка процессов вызывается один раз, а не для каждого PID
+func GetNetworkUsageByProcess() (map[int32]TrafficStats, error) {
+	if err := traffic.ensureFresh(); err != nil {
+		return nil, err
 	}
 
-	return TrafficStats{
-		DownloadMB: downloadMB,
-		UploadMB:   uploadMB,
-	}, nil
+	traffic.mutex.Lock()
+	defer traffic.mutex.Unlock()
+
+	usage := make(map[int32]TrafficStats, len(traffic.pidTotals))
+	for pid, total := range traffic.pidTotals {
+		stats := usage[pid]
+		stats.DownloadMB, stats.UploadMB = total.DownloadMB, total.UploadMB
+		usage[pid] = stats
+	}
+	for pid, rate := range traffic.rates {
+		stats := usage[pid]
+		stats.DownSpeed, stats.UpSpeed = rate.DownSpeed, rate.UpSpeed
+		usage[pid] = stats
+	}
+	return usage, nil
 }
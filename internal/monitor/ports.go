@@ -0,0 +1,197 @@
+package monitor
+
+import (
+	"TG_BOT_GO/internal/config"
+	"fmt"
+	"log"
+	"maps"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	psnet "github.com/shirou/gopsutil/net"
+)
+
+// ListeningPort — сокет, ожидающий подключений
+type ListeningPort struct {
+	Proto   string // tcp, tcp6, udp, udp6
+	Addr    string // Адрес привязки
+	Port    uint32
+	PID     int32
+	Process string
+	Exposed bool // Доступен не только через loopback
+}
+
+// Key возвращает идентификатор порта для сравнения состояний
+func (p ListeningPort) Key() string {
+	return p.Proto + " " + net.JoinHostPort(p.Addr, strconv.Itoa(int(p.Port)))
+}
+
+// String описывает порт для уведомлений
+func (p ListeningPort) String() string {
+	owner := "процесс неизвестен"
+	if p.PID > 0 {
+		owner = fmt.Sprintf("%s [PID: %d]", p.Process, p.PID)
+	}
+	scope := "только локально"
+	if p.Exposed {
+		scope = "доступен извне"
+	}
+	return fmt.Sprintf("%s — %s, %s", p.Key(), owner, scope)
+}
+
+// ListeningPorts возвращает все слушающие TCP-сокеты и UDP-сокеты без удалённого адреса
+func ListeningPorts() ([]ListeningPort, error) {
+	conns, err := psnet.Connections("inet")
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[int32]string)
+	seen := make(map[string]bool)
+	var ports []ListeningPort
+	for _, conn := range conns {
+		proto := "tcp"
+		if conn.Type == syscall.SOCK_DGRAM {
+			proto = "udp"
+			if conn.Raddr.IP != "" && conn.Raddr.Port != 0 {
+				continue // Подключённый UDP-сокет клиента
+			}
+		} else if conn.Status != "LISTEN" {
+			continue
+		}
+		if conn.Family == syscall.AF_INET6 {
+			proto += "6"
+		}
+
+		port := ListeningPort{Proto: proto, Addr: conn.Laddr.IP, Port: conn.Laddr.Port, PID: conn.Pid}
+		// Один сокет могут разделять несколько процессов (например, рабочие процессы nginx)
+		if seen[port.Key()] {
+			continue
+		}
+		seen[port.Key()] = true
+
+		if ip := net.ParseIP(port.Addr); ip == nil || !ip.IsLoopback() {
+			port.Exposed = true
+		}
+		if port.PID > 0 {
+			name, ok := names[port.PID]
+			if !ok {
+				name = processNameByPID(port.PID)
+				names[port.PID] = name
+			}
+			port.Process = name
+		}
+		ports = append(ports, port)
+	}
+
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].Port != ports[j].Port {
+			return ports[i].Port < ports[j].Port
+		}
+		return ports[i].Key() < ports[j].Key()
+	})
+	return ports, nil
+}
+
+// PortAllowList — ожидаемые порты из PORTS_ALLOW, например "22,80,443/tcp,53/udp"
+type PortAllowList map[string]bool
+
+// LoadPortAllowList читает список разрешённых портов из окружения
+func LoadPortAllowList() PortAllowList {
+	allow := make(PortAllowList)
+	for _, field := range strings.FieldsFunc(config.GetEnv("PORTS_ALLOW"), func(r rune) bool {
+		return r == ',' || r == ' ' || r == ';'
+	}) {
+		port, proto, _ := strings.Cut(strings.ToLower(field), "/")
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil || (proto != "" && proto != "tcp" && proto != "udp") {
+			log.Printf("Некорректный порт %q в PORTS_ALLOW", field)
+			continue
+		}
+		allow[port+"/"+proto] = true
+	}
+	return allow
+}
+
+// Allowed проверяет, ожидается ли порт (без указания протокола разрешены и TCP, и UDP)
+func (a PortAllowList) Allowed(p ListeningPort) bool {
+	port := strconv.Itoa(int(p.Port))
+	return a[port+"/"] || a[port+"/"+strings.TrimSuffix(p.Proto, "6")]
+}
+
+// StartPortWatcher следит за слушающими портами и сообщает подписчикам о новых и закрытых портах,
+// не входящих в PORTS_ALLOW. PORTS_CHECK_INTERVAL=0 отключает проверку
+func StartPortWatcher(bot *tgbotapi.BotAPI) {
+	interval := config.GetEnvDuration("PORTS_CHECK_INTERVAL", 30*time.Second)
+	if interval <= 0 {
+		return
+	}
+	allow := LoadPortAllowList()
+
+	var known, previous map[string]ListeningPort
+	for {
+		ports, err := ListeningPorts()
+		if err != nil {
+			log.Println("Ошибка при получении слушающих портов:", err)
+			time.Sleep(interval)
+			continue
+		}
+		current := make(map[string]ListeningPort, len(ports))
+		for _, p := range ports {
+			if !allow.Allowed(p) {
+				current[p.Key()] = p
+			}
+		}
+
+		// Первая проверка только запоминает текущее состояние
+		if known == nil {
+			known, previous = maps.Clone(current), current
+			time.Sleep(interval)
+			continue
+		}
+
+		lines := diffPorts(known, previous, current)
+		previous = current
+
+		if len(lines) > 0 {
+			sort.Strings(lines)
+			text := "🌐 Изменились слушающие порты:\n" + strings.Join(lines, "\n")
+			for _, sub := range activeSubscriptions() {
+				msg := tgbotapi.NewMessage(sub.ChatID, text)
+				if _, err := bot.Send(msg); err != nil {
+					log.Printf("Ошибка при отправке уведомления о портах в чат %d: %v", sub.ChatID, err)
+				}
+			}
+		}
+		time.Sleep(interval)
+	}
+}
+
+// diffPorts сравнивает две последние проверки с известными портами, обновляет known и возвращает
+// строки уведомления. Порт считается открытым или закрытым, только если изменение видно две
+// проверки подряд, чтобы не сообщать о кратковременных сокетах и перезапусках служб
+func diffPorts(known, previous, current map[string]ListeningPort) []string {
+	var lines []string
+	for key, p := range current {
+		if _, ok := known[key]; ok {
+			continue
+		}
+		if _, ok := previous[key]; ok {
+			known[key] = p
+			lines = append(lines, "🔓 Новый порт: "+p.String())
+		}
+	}
+	for key, p := range known {
+		_, inCurrent := current[key]
+		_, inPrevious := previous[key]
+		if !inCurrent && !inPrevious {
+			delete(known, key)
+			lines = append(lines, "🔒 Порт закрыт: "+p.String())
+		}
+	}
+	return lines
+}
@@ -0,0 +1,47 @@
+package monitor
+
+import (
+	"maps"
+	"reflect"
+	"testing"
+)
+
+func TestDiffPortsDebounce(t *testing.T) {
+	ssh := ListeningPort{Proto: "tcp", Addr: "0.0.0.0", Port: 22, PID: 1, Process: "sshd", Exposed: true}
+	web := ListeningPort{Proto: "tcp", Addr: "0.0.0.0", Port: 8080, PID: 2, Process: "app", Exposed: true}
+	state := func(ports ...ListeningPort) map[string]ListeningPort {
+		m := make(map[string]ListeningPort)
+		for _, p := range ports {
+			m[p.Key()] = p
+		}
+		return m
+	}
+
+	// Последовательность проверок и ожидаемые уведомления после каждой
+	steps := []struct {
+		name    string
+		current map[string]ListeningPort
+		want    []string
+	}{
+		{"порт появился впервые", state(ssh, web), nil},
+		{"порт виден второй раз", state(ssh, web), []string{"🔓 Новый порт: " + web.String()}},
+		{"служба перезапускается", state(ssh), nil},
+		{"служба снова слушает", state(ssh, web), nil},
+		{"порт пропал", state(ssh), nil},
+		{"порт пропал второй раз", state(ssh), []string{"🔒 Порт закрыт: " + web.String()}},
+		{"кратковременный сокет", state(ssh, web), nil},
+		{"сокет закрылся", state(ssh), nil},
+	}
+
+	known, previous := maps.Clone(state(ssh)), state(ssh)
+	for _, step := range steps {
+		got := diffPorts(known, previous, step.current)
+		if !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: diffPorts() = %q, ожидалось %q", step.name, got, step.want)
+		}
+		previous = step.current
+	}
+	if !reflect.DeepEqual(known, state(ssh)) {
+		t.Errorf("известные порты = %v", known)
+	}
+}
@@ -0,0 +1,345 @@
+package monitor
+
+import (
+	"TG_BOT_GO/internal/config"
+	"TG_BOT_GO/internal/nut"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Виды источников резервного питания
+const (
+	PowerBattery = "battery" // Батарея ноутбука из /sys/class/power_supply
+	PowerUPS     = "ups"     // ИБП, опрашиваемый через upsd (NUT)
+)
+
+// PowerSource — батарея или ИБП. Недоступные показатели равны нулю
+type PowerSource struct {
+	Kind        string
+	Name        string
+	Model       string
+	Capacity    float64       // Заряд (%)
+	Status      string        // Состояние, как его сообщает источник: Discharging, OL CHRG и т. п.
+	OnBattery   bool          // Питание от сети пропало, нагрузка работает от батареи
+	LowBattery  bool          // Источник сам сообщает о низком заряде (флаг LB у ИБП)
+	TimeToEmpty time.Duration // Оставшееся время работы от батареи
+	Health      float64       // Остаточная ёмкость батареи относительно паспортной (%)
+	Load        float64       // Нагрузка на ИБП (%)
+}
+
+// Key возвращает идентификатор источника для отслеживания изменений
+func (p PowerSource) Key() string {
+	return p.Kind + ":" + p.Name
+}
+
+// String описывает источник для уведомлений
+func (p PowerSource) String() string {
+	text := fmt.Sprintf("%s (заряд %.0f%%", p.Name, p.Capacity)
+	if p.TimeToEmpty > 0 {
+		text += fmt.Sprintf(", осталось ~%s", p.TimeToEmpty.Round(time.Minute))
+	}
+	return text + ")"
+}
+
+// upsCache — последний опрос upsd монитором питания. /status берёт ИБП отсюда,
+// чтобы не ждать ответа upsd при каждом запросе
+var upsCache struct {
+	mutex   sync.Mutex
+	sources []PowerSource
+	err     error
+	time    time.Time
+}
+
+// PowerSources возвращает батареи из sysfs и ИБП из upsd (если задан NUT_SERVER).
+// Результат опроса upsd запоминается для CachedPowerSources
+func PowerSources() ([]PowerSource, error) {
+	sources := Batteries()
+	if config.GetEnv("NUT_SERVER") == "" {
+		return sources, nil
+	}
+	ups, err := UPSSources()
+
+	upsCache.mutex.Lock()
+	upsCache.sources, upsCache.err, upsCache.time = ups, err, time.Now()
+	upsCache.mutex.Unlock()
+
+	return append(sources, ups...), err
+}
+
+// CachedPowerSources возвращает батареи из sysfs и ИБП из последнего опроса монитора питания.
+// Время опроса нулевое, если upsd ещё не опрашивался или NUT_SERVER не задан
+func CachedPowerSources() ([]PowerSource, time.Time, error) {
+	sources := Batteries()
+	if config.GetEnv("NUT_SERVER") == "" {
+		return sources, time.Time{}, nil
+	}
+	upsCache.mutex.Lock()
+	defer upsCache.mutex.Unlock()
+	return append(sources, upsCache.sources...), upsCache.time, upsCache.err
+}
+
+// Batteries читает батареи из <SYSFS_ROOT>/class/power_supply. Батареи периферийных
+// устройств (мышей, клавиатур) пропускаются
+func Batteries() []PowerSource {
+	dirs, _ := filepath.Glob(filepath.Join(config.GetSysfsRoot(), "class", "power_supply", "*"))
+	sort.Strings(dirs)
+
+	mainsPresent, mainsOnline := false, false
+	var batteries []PowerSource
+	for _, dir := range dirs {
+		switch readSysfsString(filepath.Join(dir, "type")) {
+		case "Mains":
+			mainsPresent = true
+			if readSysfsString(filepath.Join(dir, "online")) == "1" {
+				mainsOnline = true
+			}
+		case "Battery":
+			if readSysfsString(filepath.Join(dir, "scope")) == "Device" ||
+				readSysfsString(filepath.Join(dir, "present")) == "0" {
+				continue
+			}
+			batteries = append(batteries, readBattery(dir))
+		}
+	}
+
+	for i := range batteries {
+		// Состояние Discharging бывает и при подключённом зарядном устройстве, если оно слабое
+		batteries[i].OnBattery = batteries[i].Status == "Discharging" && !(mainsPresent && mainsOnline)
+	}
+	return batteries
+}
+
+// readBattery читает показания батареи. Единицы sysfs: мкВт·ч, мкВт, мкА·ч, мкА, секунды
+func readBattery(dir string) PowerSource {
+	b := PowerSource{
+		Kind:   PowerBattery,
+		Name:   filepath.Base(dir),
+		Model:  strings.TrimSpace(readSysfsString(filepath.Join(dir, "manufacturer")) + " " + readSysfsString(filepath.Join(dir, "model_name"))),
+		Status: readSysfsString(filepath.Join(dir, "status")),
+	}
+
+	energyNow, hasEnergy := readSysfsFloat(filepath.Join(dir, "energy_now"))
+	energyFull, _ := readSysfsFloat(filepath.Join(dir, "energy_full"))
+	chargeNow, hasCharge := readSysfsFloat(filepath.Join(dir, "charge_now"))
+	chargeFull, _ := readSysfsFloat(filepath.Join(dir, "charge_full"))
+
+	if capacity, ok := readSysfsFloat(filepath.Join(dir, "capacity")); ok {
+		b.Capacity = capacity
+	} else if hasEnergy && energyFull > 0 {
+		b.Capacity = energyNow / energyFull * 100
+	} else if hasCharge && chargeFull > 0 {
+		b.Capacity = chargeNow / chargeFull * 100
+	}
+
+	if seconds, ok := readSysfsFloat(filepath.Join(dir, "time_to_empty_now")); ok && seconds > 0 {
+		b.TimeToEmpty = time.Duration(seconds) * time.Second
+	} else if b.Status == "Discharging" {
+		// Оценка по текущему расходу: запас энергии / мощность или запас заряда / ток
+		power, _ := readSysfsFloat(filepath.Join(dir, "power_now"))
+		current, _ := readSysfsFloat(filepath.Join(dir, "current_now"))
+		switch {
+		case hasEnergy && power > 0:
+			b.TimeToEmpty = time.Duration(energyNow / power * float64(time.Hour))
+		case hasCharge && current > 0:
+			b.TimeToEmpty = time.Duration(chargeNow / current * float64(time.Hour))
+		}
+	}
+
+	if design, ok := readSysfsFloat(filepath.Join(dir, "energy_full_design")); ok && design > 0 && energyFull > 0 {
+		b.Health = energyFull / design * 100
+	} else if design, ok := readSysfsFloat(filepath.Join(dir, "charge_full_design")); ok && design > 0 && chargeFull > 0 {
+		b.Health = chargeFull / design * 100
+	}
+	return b
+}
+
+// UPSSources опрашивает upsd из NUT_SERVER (host[:port]). NUT_UPS ограничивает опрос
+// одним ИБП, NUT_USERNAME и NUT_PASSWORD нужны, если upsd требует авторизацию
+func UPSSources() ([]PowerSource, error) {
+	client, err := nut.Dial(config.GetEnv("NUT_SERVER"), 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подключения к upsd: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Login(config.GetEnv("NUT_USERNAME"), config.GetEnv("NUT_PASSWORD")); err != nil {
+		return nil, err
+	}
+
+	names := []string{config.GetEnv("NUT_UPS")}
+	if names[0] == "" {
+		list, err := client.ListUPS()
+		if err != nil {
+			return nil, err
+		}
+		names = names[:0]
+		for name := range list {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	var sources []PowerSource
+	for _, name := range names {
+		vars, err := client.ListVars(name)
+		if err != nil {
+			return sources, fmt.Errorf("ошибка опроса ИБП %s: %w", name, err)
+		}
+		sources = append(sources, upsSource(name, vars))
+	}
+	return sources, nil
+}
+
+// upsSource переводит переменные NUT в показания ИБП
+func upsSource(name string, vars map[string]string) PowerSource {
+	status := vars["ups.status"]
+	flags := strings.Fields(status)
+	has := func(flag string) bool {
+		for _, f := range flags {
+			if f == flag {
+				return true
+			}
+		}
+		return false
+	}
+
+	ups := PowerSource{
+		Kind:       PowerUPS,
+		Name:       name,
+		Model:      strings.TrimSpace(vars["ups.mfr"] + " " + vars["ups.model"]),
+		Capacity:   parseFloat(vars["battery.charge"]),
+		Status:     status,
+		OnBattery:  has("OB"),
+		LowBattery: has("LB"),
+		Load:       parseFloat(vars["ups.load"]),
+	}
+	if seconds, err := strconv.ParseFloat(vars["battery.runtime"], 64); err == nil {
+		ups.TimeToEmpty = time.Duration(seconds) * time.Second
+	}
+	return ups
+}
+
+// GetPowerUsage возвращает состояние батарей и ИБП в виде строки или пустую строку, если их нет.
+// ИБП берутся из последнего опроса StartPowerMonitor, upsd при этом не опрашивается
+func GetPowerUsage() string {
+	sources, polled, err := CachedPowerSources()
+	upsPending := config.GetEnv("NUT_SERVER") != "" && polled.IsZero()
+	if len(sources) == 0 && err == nil && !upsPending {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, p := range sources {
+		icon := "🔌"
+		if p.OnBattery {
+			icon = "🔋"
+		}
+		title := p.Name
+		if p.Model != "" {
+			title += " — " + p.Model
+		}
+		sb.WriteString(fmt.Sprintf("%s %s:\n", icon, title))
+		sb.WriteString(fmt.Sprintf("⚡ Заряд: %.0f%%\n%s\n", p.Capacity, getProgressBar(p.Capacity)))
+		if p.OnBattery {
+			sb.WriteString("🔋 Работает от батареи")
+		} else {
+			sb.WriteString("🔌 Питание от сети")
+		}
+		if p.Status != "" {
+			sb.WriteString(" (" + p.Status + ")")
+		}
+		sb.WriteString("\n")
+		if p.TimeToEmpty > 0 {
+			sb.WriteString(fmt.Sprintf("⏳ Запас работы от батареи: ~%s\n", p.TimeToEmpty.Round(time.Minute)))
+		}
+		if p.Health > 0 {
+			sb.WriteString(fmt.Sprintf("❤️ Износ: остаточная ёмкость %.0f%%\n", p.Health))
+		}
+		if p.Load > 0 {
+			sb.WriteString(fmt.Sprintf("📊 Нагрузка: %.0f%%\n", p.Load))
+		}
+	}
+	if err != nil {
+		sb.WriteString("❌ " + err.Error() + "\n")
+	}
+	if upsPending {
+		sb.WriteString("⏳ ИБП ещё не опрошен: состояние обновляет монитор питания (POWER_CHECK_INTERVAL)\n")
+	}
+	return sb.String()
+}
+
+// powerState — отслеживаемое состояние источника питания
+type powerState struct {
+	onBattery bool
+	low       bool
+}
+
+// StartPowerMonitor следит за батареями и ИБП и сообщает подписчикам о переходе на батарею,
+// низком заряде (POWER_LOW_BATTERY, по умолчанию 20%) и восстановлении питания.
+// POWER_CHECK_INTERVAL=0 отключает проверку
+func StartPowerMonitor(bot *tgbotapi.BotAPI) {
+	interval := config.GetEnvDuration("POWER_CHECK_INTERVAL", 30*time.Second)
+	if interval <= 0 {
+		return
+	}
+	lowThreshold := float64(config.GetEnvInt("POWER_LOW_BATTERY", 20))
+
+	var states map[string]powerState
+	for {
+		sources, err := PowerSources()
+		if err != nil {
+			log.Println("Ошибка при опросе источников питания:", err)
+		}
+
+		current := make(map[string]powerState, len(sources))
+		var lines []string
+		for _, p := range sources {
+			state := powerState{
+				onBattery: p.OnBattery,
+				low:       p.OnBattery && (p.LowBattery || p.Capacity > 0 && p.Capacity <= lowThreshold),
+			}
+			current[p.Key()] = state
+
+			// Первая проверка только запоминает текущее состояние
+			prev, known := states[p.Key()]
+			if states == nil || !known {
+				continue
+			}
+			switch {
+			case state.onBattery && !prev.onBattery:
+				lines = append(lines, "🔋 Питание от сети пропало: "+p.String()+" работает от батареи")
+			case !state.onBattery && prev.onBattery:
+				lines = append(lines, "🔌 Питание восстановлено: "+p.String())
+			}
+			if state.low && !prev.low {
+				lines = append(lines, "🪫 Низкий заряд батареи: "+p.String())
+			}
+		}
+		// Если ИБП временно не ответил, сохраняем его прошлое состояние
+		for key, state := range states {
+			if _, ok := current[key]; !ok && err != nil {
+				current[key] = state
+			}
+		}
+		states = current
+
+		if len(lines) > 0 {
+			text := "⚡ Питание:\n" + strings.Join(lines, "\n")
+			for _, sub := range activeSubscriptions() {
+				msg := tgbotapi.NewMessage(sub.ChatID, text)
+				if _, err := bot.Send(msg); err != nil {
+					log.Printf("Ошибка при отправке уведомления о питании в чат %d: %v", sub.ChatID, err)
+				}
+			}
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,60 @@
+package monitor
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+)
+
+// SampleProcessCPU измеряет текущую загрузку CPU каждым процессом за окно window.
+// Время CPU всех процессов снимается дважды, разница делится на длительность окна
+// и число ядер, так что 100% означает полную загрузку всех ядер
+func SampleProcessCPU(window time.Duration) (map[int32]float64, error) {
+	first, err := processCPUTimes()
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	time.Sleep(window)
+
+	second, err := processCPUTimes()
+	if err != nil {
+		return nil, err
+	}
+	elapsed := time.Since(start).Seconds()
+	cores := float64(runtime.NumCPU())
+
+	usage := make(map[int32]float64, len(second))
+	for pid, busy := range second {
+		prev, ok := first[pid]
+		if !ok || busy < prev {
+			continue // Процесс появился во время замера или PID переиспользован
+		}
+		usage[pid] = (busy - prev) / elapsed / cores * 100
+	}
+	return usage, nil
+}
+
+// processCPUTimes возвращает суммарное время CPU (user + system) каждого процесса в секундах
+func processCPUTimes() (map[int32]float64, error) {
+	pids, err := process.Pids()
+	if err != nil {
+		return nil, err
+	}
+
+	times := make(map[int32]float64, len(pids))
+	for _, pid := range pids {
+		p, err := process.NewProcess(pid)
+		if err != nil {
+			continue
+		}
+		t, err := p.Times()
+		if err != nil {
+			continue
+		}
+		times[pid] = t.User + t.System
+	}
+	return times, nil
+}
@@ -0,0 +1,178 @@
+package monitor
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+)
+
+// socketCounters — счётчики байт одного TCP-сокета
+type socketCounters struct {
+	rx uint64 // Получено (tcp_info.bytes_received)
+	tx uint64 // Отправлено и подтверждено (tcp_info.bytes_acked)
+}
+
+// trafficTracker считает трафик процессов по разнице счётчиков их сокетов между измерениями
+type trafficTracker struct {
+	mutex    sync.Mutex
+	prev     map[uint64]socketCounters // Счётчики сокетов в предыдущем измерении
+	prevTime time.Time
+	err      error     // Ошибка последнего измерения (например, ОС не поддерживается)
+	errTime  time.Time // Когда получена ошибка
+
+	rates     map[int32]ProcessTraffic   // Скорость процессов за последний интервал
+	pidTotals map[int32]*ProcessTraffic  // Трафик живых процессов с момента запуска бота
+	totals    map[string]*ProcessTraffic // Трафик по именам приложений с момента запуска бота
+}
+
+// traffic — общий учёт трафика процессов
+var traffic = &trafficTracker{
+	pidTotals: make(map[int32]*ProcessTraffic),
+	totals:    make(map[string]*ProcessTraffic),
+}
+
+// sample снимает счётчики сокетов и распределяет прирост трафика по процессам
+func (t *trafficTracker) sample(now time.Time) {
+	counters, err := readSocketCounters()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.err = err
+	if err != nil {
+		t.errTime = now
+		return
+	}
+
+	// Первое измерение служит только базой для разницы
+	if t.prev == nil {
+		t.prev, t.prevTime = counters, now
+		return
+	}
+	seconds := now.Sub(t.prevTime).Seconds()
+	if seconds <= 0 {
+		return
+	}
+
+	owners := socketOwners()
+	deltas := make(map[int32]socketCounters)
+	for inode, c := range counters {
+		pid, ok := owners[inode]
+		if !ok {
+			continue
+		}
+		// Новый сокет появился после прошлого измерения — весь его трафик новый
+		prev, seen := t.prev[inode]
+		if seen && (c.rx < prev.rx || c.tx < prev.tx) {
+			seen = false
+		}
+		d := deltas[pid]
+		if seen {
+			d.rx += c.rx - prev.rx
+			d.tx += c.tx - prev.tx
+		} else {
+			d.rx += c.rx
+			d.tx += c.tx
+		}
+		deltas[pid] = d
+	}
+
+	t.rates = make(map[int32]ProcessTraffic, len(deltas))
+	for pid, d := range deltas {
+		if d.rx == 0 && d.tx == 0 {
+			continue
+		}
+		name := processNameByPID(pid)
+		downMB, upMB := float64(d.rx)/1024/1024, float64(d.tx)/1024/1024
+
+		t.rates[pid] = ProcessTraffic{
+			PID:        pid,
+			Name:       name,
+			DownSpeed:  downMB / seconds,
+			UpSpeed:    upMB / seconds,
+			DownloadMB: downMB,
+			UploadMB:   upMB,
+		}
+
+		total, ok := t.pidTotals[pid]
+		if !ok {
+			total = &ProcessTraffic{PID: pid, Name: name}
+			t.pidTotals[pid] = total
+		}
+		total.DownloadMB += downMB
+		total.UploadMB += upMB
+
+		byName, ok := t.totals[name]
+		if !ok {
+			byName = &ProcessTraffic{Name: name}
+			t.totals[name] = byName
+		}
+		byName.DownloadMB += downMB
+		byName.UploadMB += upMB
+	}
+
+	// Забываем завершившиеся процессы (итоги по именам сохраняются)
+	for pid := range t.pidTotals {
+		if exists, _ := process.PidExists(pid); !exists {
+			delete(t.pidTotals, pid)
+		}
+	}
+
+	t.prev, t.prevTime = counters, now
+}
+
+// trafficRetryInterval — через сколько повторять измерение после ошибки. Без поддержки ОС
+// или без прав на sock_diag ошибка постоянна, и повторять её на каждый запрос незачем
+const trafficRetryInterval = time.Minute
+
+// ensureFresh делает два измерения подряд, если фоновый сборщик давно не обновлял данные.
+// Недавнюю ошибку возвращает сразу, без новых измерений и ожидания
+func (t *trafficTracker) ensureFresh() error {
+	t.mutex.Lock()
+	if t.err != nil && time.Since(t.errTime) < trafficRetryInterval {
+		err := t.err
+		t.mutex.Unlock()
+		return err
+	}
+	stale := t.prev == nil || time.Since(t.prevTime) > 3*metrics.interval+time.Second
+	t.mutex.Unlock()
+
+	if stale {
+		t.sample(time.Now())
+		t.mutex.Lock()
+		failed := t.err != nil
+		t.mutex.Unlock()
+		if !failed {
+			time.Sleep(time.Second)
+			t.sample(time.Now())
+		}
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.err
+}
+
+// processNameByPID возвращает имя процесса или "pid <N>", если имя недоступно
+func processNameByPID(pid int32) string {
+	if p, err := process.NewProcess(pid); err == nil {
+		if name, err := p.Name(); err == nil {
+			return name
+		}
+	}
+	return fmt.Sprintf("pid %d", pid)
+}
+
+// topTraffic сортирует по сумме трафика или скорости и обрезает список
+func topTraffic(list []ProcessTraffic, limit int, value func(p ProcessTraffic) float64) []ProcessTraffic {
+	sort.Slice(list, func(i, j int) bool {
+		return value(list[i]) > value(list[j])
+	})
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
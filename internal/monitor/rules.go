@@ -0,0 +1,385 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+)
+
+// Уровни важности правил
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Rule — правило уведомления: метрика, условие, порог, длительность и важность
+type Rule struct {
+	Name       string     `json:"name"`                  // Уникальное в пределах чата имя правила
+	Metric     string     `json:"metric"`                // Селектор метрики: cpu, disk:/home, net:eth0, proc_cpu:nginx...
+	Op         string     `json:"op"`                    // Оператор сравнения: >, >=, <, <=, ==, !=
+	Threshold  float64    `json:"threshold"`             // Порог срабатывания
+	Clear      *float64   `json:"clear,omitempty"`       // Порог возврата в норму (гистерезис); не задан — без гистерезиса
+	Hold       int        `json:"hold,omitempty"`        // Сколько секунд условие должно выполняться до уведомления
+	Severity   string     `json:"severity"`              // Важность: info, warning, critical
+	MutedUntil *time.Time `json:"muted_until,omitempty"` // До какого момента уведомления правила заглушены
+}
+
+// ruleOperators — поддерживаемые операторы сравнения
+var ruleOperators = map[string]func(value, threshold float64) bool{
+	">":  func(v, t float64) bool { return v > t },
+	">=": func(v, t float64) bool { return v >= t },
+	"<":  func(v, t float64) bool { return v < t },
+	"<=": func(v, t float64) bool { return v <= t },
+	"==": func(v, t float64) bool { return v == t },
+	"!=": func(v, t float64) bool { return v != t },
+}
+
+// ruleNamePattern ограничивает имена правил, чтобы их было удобно набирать в командах
+var ruleNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,32}$`)
+
+// Префиксы селекторов, вычисляемых по процессам и интерфейсам
+const (
+	selectorNet       = "net:"        // Суммарная скорость интерфейса (вход + выход, МБ/с)
+	selectorProcCPU   = "proc_cpu:"   // Загрузка CPU процессами с именем (% от всех ядер)
+	selectorProcMem   = "proc_mem:"   // Использование памяти процессами с именем (%)
+	selectorProcCount = "proc_count:" // Количество запущенных процессов с именем
+)
+
+// legacyMetrics сопоставляет старые параметры /alarm_set с метриками
+var legacyMetrics = map[string]string{
+	"cpu_temp":      MetricCPUTemp,
+	"gpu_temp":      MetricGPUTemp,
+	"gpu_tmp":       MetricGPUTemp, // Старое написание, оставлено для совместимости
+	"cpu_usage":     MetricCPU,
+	"gpu_usage":     MetricGPU,
+	"memory_usage":  MetricMemory,
+	"network_usage": MetricNetDown,
+	"disk_usage":    MetricDisk,
+}
+
+// LegacyParam возвращает каноническое имя старого параметра /alarm_set и его метрику
+func LegacyParam(param string) (string, string, bool) {
+	metric, ok := legacyMetrics[param]
+	if param == "gpu_tmp" {
+		param = "gpu_temp"
+	}
+	return param, metric, ok
+}
+
+// Validate проверяет правило и подставляет значения по умолчанию
+func (r *Rule) Validate() error {
+	if !ruleNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("некорректное имя правила %q: допустимы латинские буквы, цифры, _ . - (до 32 символов)", r.Name)
+	}
+	if _, ok := ruleOperators[r.Op]; !ok {
+		return fmt.Errorf("некорректный оператор %q: допустимы > >= < <= == !=", r.Op)
+	}
+	if err := validateSelector(r.Metric); err != nil {
+		return err
+	}
+	if r.Hold < 0 {
+		return fmt.Errorf("длительность не может быть отрицательной")
+	}
+	if r.Severity == "" {
+		r.Severity = SeverityWarning
+	}
+	switch r.Severity {
+	case SeverityInfo, SeverityWarning, SeverityCritical:
+	default:
+		return fmt.Errorf("некорректная важность %q: допустимы info, warning, critical", r.Severity)
+	}
+	if r.Clear != nil {
+		if (r.Op == ">" || r.Op == ">=") && *r.Clear > r.Threshold {
+			return fmt.Errorf("порог сброса должен быть не больше порога срабатывания")
+		}
+		if (r.Op == "<" || r.Op == "<=") && *r.Clear < r.Threshold {
+			return fmt.Errorf("порог сброса должен быть не меньше порога срабатывания")
+		}
+	}
+	return nil
+}
+
+// validateSelector проверяет селектор метрики
+func validateSelector(selector string) error {
+	for _, prefix := range []string{selectorNet, selectorProcCPU, selectorProcMem, selectorProcCount,
+		MetricDisk + ":", MetricNetDown + ":", MetricNetUp + ":",
+		MetricGPU + ":", MetricGPUTemp + ":", MetricGPUMem + ":"} {
+		if strings.HasPrefix(selector, prefix) {
+			if strings.TrimPrefix(selector, prefix) == "" {
+				return fmt.Errorf("в селекторе %q не указан объект", selector)
+			}
+			return nil
+		}
+	}
+	switch selector {
+	case MetricCPU, MetricCPUTemp, MetricGPU, MetricGPUTemp, MetricGPUMem, MetricMemory, MetricDisk, MetricNetDown, MetricNetUp:
+		return nil
+	}
+	return fmt.Errorf("неизвестная метрика %q", selector)
+}
+
+// Breached сообщает, выполняется ли условие правила для значения
+func (r Rule) Breached(value float64) bool {
+	compare, ok := ruleOperators[r.Op]
+	return ok && compare(value, r.Threshold)
+}
+
+// Recovered сообщает, вернулось ли значение в норму с учётом гистерезиса
+func (r Rule) Recovered(value float64) bool {
+	if r.Clear == nil {
+		return !r.Breached(value)
+	}
+	switch r.Op {
+	case ">", ">=":
+		return value <= *r.Clear
+	case "<", "<=":
+		return value >= *r.Clear
+	}
+	return !r.Breached(value)
+}
+
+// Muted сообщает, заглушено ли правило в момент now
+func (r Rule) Muted(now time.Time) bool {
+	return r.MutedUntil != nil && now.Before(*r.MutedUntil)
+}
+
+// Unit возвращает единицу измерения метрики правила
+func (r Rule) Unit() string {
+	switch {
+	case strings.HasPrefix(r.Metric, selectorNet):
+		return " МБ/с"
+	case strings.HasPrefix(r.Metric, selectorProcCPU), strings.HasPrefix(r.Metric, selectorProcMem):
+		return "%"
+	case strings.HasPrefix(r.Metric, selectorProcCount):
+		return ""
+	}
+	return MetricUnit(r.Metric)
+}
+
+// String описывает условие правила: "cpu_temp > 85°C дольше 1m0s (сброс 75°C)"
+func (r Rule) String() string {
+	unit := r.Unit()
+	text := fmt.Sprintf("%s %s %s%s", r.Metric, r.Op, strconv.FormatFloat(r.Threshold, 'f', -1, 64), unit)
+	if r.Hold > 0 {
+		text += fmt.Sprintf(" дольше %s", time.Duration(r.Hold)*time.Second)
+	}
+	if r.Clear != nil {
+		text += fmt.Sprintf(" (сброс %s%s)", strconv.FormatFloat(*r.Clear, 'f', -1, 64), unit)
+	}
+	return text
+}
+
+// SeverityIcon возвращает значок уровня важности
+func SeverityIcon(severity string) string {
+	switch severity {
+	case SeverityInfo:
+		return "ℹ️"
+	case SeverityCritical:
+		return "🔥"
+	default:
+		return "⚠️"
+	}
+}
+
+// ruleValues вычисляет текущие значения всех селекторов из правил подписок. Метрик, которых
+// на этой машине нет (датчика, видеокарты, раздела, интерфейса), в результате нет
+func ruleValues(subs []Subscription) map[string]float64 {
+	values := make(map[string]float64)
+	checked := make(map[string]bool)
+	var procSelectors []string
+	for _, sub := range subs {
+		for _, rule := range sub.Rules {
+			if checked[rule.Metric] {
+				continue
+			}
+			checked[rule.Metric] = true
+			switch {
+			case strings.HasPrefix(rule.Metric, selectorProcCPU),
+				strings.HasPrefix(rule.Metric, selectorProcMem),
+				strings.HasPrefix(rule.Metric, selectorProcCount):
+				// Отсутствие процесса — значение 0, а не отсутствие метрики: на этом основаны правила proc_count < 1
+				procSelectors = append(procSelectors, rule.Metric)
+				values[rule.Metric] = 0
+			case strings.HasPrefix(rule.Metric, selectorNet):
+				iface := strings.TrimPrefix(rule.Metric, selectorNet)
+				down, okDown := LookupValue(MetricNetDown + ":" + iface)
+				up, okUp := LookupValue(MetricNetUp + ":" + iface)
+				if okDown && okUp {
+					values[rule.Metric] = down + up
+				}
+			default:
+				if value, ok := LookupValue(rule.Metric); ok {
+					values[rule.Metric] = value
+				}
+			}
+		}
+	}
+
+	if len(procSelectors) > 0 {
+		procUsage.fill(procSelectors, values)
+	}
+	return values
+}
+
+// procTracker хранит объекты процессов между проверками, чтобы считать загрузку CPU по разнице времени
+type procTracker struct {
+	mutex     sync.Mutex
+	processes map[int32]*process.Process
+}
+
+// procUsage — общий трекер процессов для правил proc_*
+var procUsage = &procTracker{processes: make(map[int32]*process.Process)}
+
+// fill суммирует показатели процессов с указанными в селекторах именами
+func (t *procTracker) fill(selectors []string, values map[string]float64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	pids, err := process.Pids()
+	if err != nil {
+		return
+	}
+
+	alive := make(map[int32]*process.Process, len(pids))
+	for _, pid := range pids {
+		p, ok := t.processes[pid]
+		if !ok {
+			if p, err = process.NewProcess(pid); err != nil {
+				continue
+			}
+		}
+		alive[pid] = p
+
+		name, err := p.Name()
+		if err != nil {
+			continue
+		}
+		// Загрузку считаем для каждого процесса, чтобы к следующей проверке была база для разницы
+		cpuPercent, _ := p.Percent(0)
+		cpuPercent /= float64(runtime.NumCPU())
+
+		for _, selector := range selectors {
+			switch {
+			case strings.EqualFold(selector, selectorProcCPU+name):
+				values[selector] += cpuPercent
+			case strings.EqualFold(selector, selectorProcMem+name):
+				memPercent, _ := p.MemoryPercent()
+				values[selector] += float64(memPercent)
+			case strings.EqualFold(selector, selectorProcCount+name):
+				values[selector]++
+			}
+		}
+	}
+	t.processes = alive
+}
+
+var (
+	rulesFile            = "alarm_rules.json"      // Файл с правилами по умолчанию для новых подписок
+	legacyThresholdsFile = "alarm_thresholds.json" // Старый файл с порогами по умолчанию
+)
+
+// legacyThresholds — старый формат порогов, поддерживается только для загрузки
+type legacyThresholds struct {
+	CPUTemp      float64 `json:"cpu_temp"`
+	GPUTemp      float64 `json:"gpu_temp"`
+	CPUUsage     float64 `json:"cpu_usage"`
+	GPUUsage     float64 `json:"gpu_usage"`
+	MemoryUsage  float64 `json:"memory_usage"`
+	NetworkUsage float64 `json:"network_usage"`
+	DiskUsage    float64 `json:"disk_usage"`
+}
+
+// legacyTuning — старые настройки гистерезиса и длительности параметра
+type legacyTuning struct {
+	Clear float64 `json:"clear,omitempty"`
+	Hold  int     `json:"hold,omitempty"`
+}
+
+// clear возвращает старый порог сброса, в котором 0 означал отсутствие гистерезиса
+func (t legacyTuning) clear() *float64 {
+	if t.Clear == 0 {
+		return nil
+	}
+	return &t.Clear
+}
+
+// toRules преобразует старые пороги в правила "больше порога"
+func (t legacyThresholds) toRules(tuning map[string]legacyTuning) []Rule {
+	params := []struct {
+		name  string
+		value float64
+	}{
+		{"cpu_temp", t.CPUTemp},
+		{"gpu_temp", t.GPUTemp},
+		{"cpu_usage", t.CPUUsage},
+		{"gpu_usage", t.GPUUsage},
+		{"memory_usage", t.MemoryUsage},
+		{"network_usage", t.NetworkUsage},
+		{"disk_usage", t.DiskUsage},
+	}
+
+	var rules []Rule
+	for _, param := range params {
+		if param.value <= 0 {
+			continue
+		}
+		rules = append(rules, Rule{
+			Name:      param.name,
+			Metric:    legacyMetrics[param.name],
+			Op:        ">",
+			Threshold: param.value,
+			Clear:     tuning[param.name].clear(),
+			Hold:      tuning[param.name].Hold,
+			Severity:  SeverityWarning,
+		})
+	}
+	return rules
+}
+
+// LoadDefaultRules загружает правила по умолчанию для новых подписок.
+// Если файла с правилами нет, преобразует пороги из старого alarm_thresholds.json
+func LoadDefaultRules() error {
+	file, err := os.ReadFile(rulesFile)
+	if err == nil {
+		var rules []Rule
+		if err := json.Unmarshal(file, &rules); err != nil {
+			return err
+		}
+		for i := range rules {
+			if err := rules[i].Validate(); err != nil {
+				return fmt.Errorf("правило %q: %w", rules[i].Name, err)
+			}
+		}
+		DefaultRules = rules
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	file, err = os.ReadFile(legacyThresholdsFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Println("Файл с правилами по умолчанию не найден. Новые подписки создаются без правил.")
+			return nil
+		}
+		return err
+	}
+	var thresholds legacyThresholds
+	if err := json.Unmarshal(file, &thresholds); err != nil {
+		return err
+	}
+	DefaultRules = thresholds.toRules(nil)
+	log.Printf("Пороги из %s преобразованы в правила по умолчанию: %d", legacyThresholdsFile, len(DefaultRules))
+	return nil
+}
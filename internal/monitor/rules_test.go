@@ -0,0 +1,93 @@
+package monitor
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRuleClearJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantClear *float64
+	}{
+		{"без гистерезиса", `{"name":"hot","metric":"cpu_temp","op":">","threshold":85}`, nil},
+		{"сохранённый порог сброса", `{"name":"hot","metric":"cpu_temp","op":">","threshold":85,"clear":75}`, ptr(75.0)},
+		{"порог сброса 0", `{"name":"cold","metric":"cpu_temp","op":"<","threshold":-5,"clear":0}`, ptr(0.0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rule Rule
+			if err := json.Unmarshal([]byte(tt.data), &rule); err != nil {
+				t.Fatal(err)
+			}
+			if (rule.Clear == nil) != (tt.wantClear == nil) || rule.Clear != nil && *rule.Clear != *tt.wantClear {
+				t.Fatalf("Clear = %v, ожидалось %v", rule.Clear, tt.wantClear)
+			}
+
+			// Порог сброса переживает сохранение, а его отсутствие не превращается в 0
+			data, err := json.Marshal(rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if has := strings.Contains(string(data), `"clear"`); has != (tt.wantClear != nil) {
+				t.Errorf("сохранено %s", data)
+			}
+		})
+	}
+}
+
+func TestRuleRecoveredZeroClear(t *testing.T) {
+	rule := Rule{Name: "cold", Metric: MetricCPUTemp, Op: "<", Threshold: -5, Clear: ptr(0.0)}
+	if err := rule.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if rule.Recovered(-2) {
+		t.Error("значение между порогом и сбросом 0 считается нормой")
+	}
+	if !rule.Recovered(0) {
+		t.Error("значение на пороге сброса 0 не считается нормой")
+	}
+	if got := rule.String(); !strings.Contains(got, "(сброс 0°C)") {
+		t.Errorf("String() = %q", got)
+	}
+
+	rule.Clear = nil
+	if !rule.Recovered(-2) {
+		t.Error("без гистерезиса значение выше порога не считается нормой")
+	}
+}
+
+func TestEvaluateSkipsAbsentMetric(t *testing.T) {
+	const chatID = -100
+	sub := Subscription{ChatID: chatID, Enabled: true, Rules: []Rule{
+		{Name: "cold_cpu", Metric: MetricCPUTemp, Op: "<", Threshold: 10, Severity: SeverityWarning},
+		{Name: "busy", Metric: MetricCPU, Op: ">", Threshold: 90, Severity: SeverityWarning},
+	}}
+	now := time.Now()
+
+	alarmStatesMutex.Lock()
+	defer alarmStatesMutex.Unlock()
+	t.Cleanup(func() { delete(alarmStates, chatID) })
+
+	// На машине без датчика температуры cpu_temp отсутствует, а не равен 0
+	text := evaluateSubscription(sub, map[string]float64{MetricCPU: 95}, now)
+	if strings.Contains(text, "cold_cpu") || !strings.Contains(text, "busy") {
+		t.Errorf("уведомление:\n%s", text)
+	}
+	if _, ok := alarmStates[chatID]["cold_cpu"]; ok {
+		t.Error("правило без метрики получило состояние")
+	}
+
+	// Датчик появился — правило проверяется как обычно
+	text = evaluateSubscription(sub, map[string]float64{MetricCPU: 95, MetricCPUTemp: 5}, now.Add(10*time.Second))
+	if !strings.Contains(text, "cold_cpu") {
+		t.Errorf("правило с появившейся метрикой не сработало:\n%s", text)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
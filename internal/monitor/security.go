@@ -0,0 +1,357 @@
+package monitor
+
+import (
+	"TG_BOT_GO/internal/config"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/shirou/gopsutil/process"
+)
+
+// BaselineEntry — известный исполняемый файл
+type BaselineEntry struct {
+	Path    string    `json:"path"`
+	Added   time.Time `json:"added"`
+	Trusted bool      `json:"trusted"` // Добавлен вручную через /trust, а не при первом сканировании
+}
+
+// UnknownExecutable — исполняемый файл, которого нет в базе известных
+type UnknownExecutable struct {
+	Hash      string
+	Path      string
+	Name      string
+	PID       int32
+	FirstSeen time.Time
+}
+
+// hashCacheEntry — SHA-256 файла, действительный, пока не изменились размер и время изменения
+type hashCacheEntry struct {
+	size    int64
+	modTime time.Time
+	hash    string
+}
+
+// kernelThreadName — имена потоков ядра, под которые маскируется вредоносное ПО
+var kernelThreadName = regexp.MustCompile(`^\[?(kworker|ksoftirqd|kthreadd|kswapd\d*|migration|rcu_\w+|jbd2|kauditd|khugepaged|kdevtmpfs|kblockd|cpuhp|watchdog/\d+|irq/\d+)(/.*)?\]?$`)
+
+// defaultSuspiciousDirs — каталоги, доступные всем на запись, откуда не должны запускаться программы
+var defaultSuspiciousDirs = []string{"/tmp", "/var/tmp", "/dev/shm"}
+
+var (
+	baselineFile  = "exec_baseline.json" // Файл базы известных исполняемых файлов
+	baseline      map[string]BaselineEntry
+	unknownExecs  = make(map[string]*UnknownExecutable)
+	hashCache     = make(map[string]hashCacheEntry)
+	securityAlert = make(map[string]bool) // Уже отправленные предупреждения (ключ — причина и PID)
+	securityMutex sync.Mutex
+)
+
+// LoadBaseline загружает базу известных исполняемых файлов. Если файла нет,
+// первое сканирование запомнит все запущенные программы как известные
+func LoadBaseline() error {
+	securityMutex.Lock()
+	defer securityMutex.Unlock()
+
+	file, err := os.ReadFile(baselineFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var entries map[string]BaselineEntry
+	if err := json.Unmarshal(file, &entries); err != nil {
+		return err
+	}
+	baseline = entries
+	return nil
+}
+
+// saveBaseline сохраняет базу известных исполняемых файлов (вызывается под securityMutex)
+func saveBaseline() error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(baseline); err != nil {
+		return err
+	}
+	return os.WriteFile(baselineFile, buf.Bytes(), 0644)
+}
+
+// sha256Hex — полный хэш SHA-256 в шестнадцатеричном виде
+var sha256Hex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// TrustExecutable добавляет исполняемый файл в базу известных. Принимает путь к файлу
+// (хэш считается сразу, файл не обязан быть запущен), полный хэш SHA-256 или начало хэша
+// (не короче 8 символов) одного из замеченных неизвестных файлов
+func TrustExecutable(arg string) (UnknownExecutable, error) {
+	arg = strings.TrimSpace(arg)
+	if filepath.IsAbs(arg) {
+		return trustPath(arg)
+	}
+
+	prefix := strings.ToLower(arg)
+	if len(prefix) < 8 {
+		return UnknownExecutable{}, fmt.Errorf("укажите путь к файлу, хэш SHA-256 или его первые 8 символов")
+	}
+
+	securityMutex.Lock()
+	defer securityMutex.Unlock()
+
+	var found *UnknownExecutable
+	for hash, exe := range unknownExecs {
+		if strings.HasPrefix(hash, prefix) {
+			if found != nil {
+				return UnknownExecutable{}, fmt.Errorf("под %q подходит несколько файлов, укажите хэш длиннее", prefix)
+			}
+			found = exe
+		}
+	}
+	if found == nil {
+		// Полный хэш можно добавить заранее, до первого запуска файла и после перезапуска бота
+		if !sha256Hex.MatchString(prefix) {
+			return UnknownExecutable{}, fmt.Errorf("неизвестный файл с хэшем %q не найден, укажите полный хэш SHA-256 или путь к файлу", prefix)
+		}
+		found = &UnknownExecutable{Hash: prefix}
+	}
+	return *found, trustHash(found.Hash, found.Path)
+}
+
+// trustPath считает хэш файла по пути и добавляет его в базу
+func trustPath(path string) (UnknownExecutable, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return UnknownExecutable{}, fmt.Errorf("не удалось прочитать файл %s: %v", path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return UnknownExecutable{}, fmt.Errorf("%s не является обычным файлом", path)
+	}
+	hash, err := fileHash(path)
+	if err != nil {
+		return UnknownExecutable{}, fmt.Errorf("не удалось посчитать хэш %s: %v", path, err)
+	}
+
+	securityMutex.Lock()
+	defer securityMutex.Unlock()
+	return UnknownExecutable{Hash: hash, Path: path}, trustHash(hash, path)
+}
+
+// trustHash записывает хэш в базу как доверенный (вызывается под securityMutex)
+func trustHash(hash, path string) error {
+	if baseline == nil {
+		baseline = make(map[string]BaselineEntry)
+	}
+	baseline[hash] = BaselineEntry{Path: path, Added: time.Now(), Trusted: true}
+	delete(unknownExecs, hash)
+	return saveBaseline()
+}
+
+// UnknownExecutables возвращает замеченные, но ещё не доверенные исполняемые файлы
+func UnknownExecutables() []UnknownExecutable {
+	securityMutex.Lock()
+	defer securityMutex.Unlock()
+
+	list := make([]UnknownExecutable, 0, len(unknownExecs))
+	for _, exe := range unknownExecs {
+		list = append(list, *exe)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].FirstSeen.Before(list[j].FirstSeen)
+	})
+	return list
+}
+
+// StartSecurityScanner периодически проверяет запущенные процессы и сообщает подписчикам
+// о новых исполняемых файлах и подозрительных процессах. SECURITY_SCAN_INTERVAL=0 отключает проверку
+func StartSecurityScanner(bot *tgbotapi.BotAPI) {
+	interval := config.GetEnvDuration("SECURITY_SCAN_INTERVAL", time.Minute)
+	if interval <= 0 {
+		log.Println("Проверка процессов на подозрительную активность отключена")
+		return
+	}
+	for {
+		alerts, err := scanProcesses()
+		if err != nil {
+			log.Println("Ошибка проверки процессов:", err)
+		}
+		for _, text := range alerts {
+			for _, sub := range activeSubscriptions() {
+				msg := tgbotapi.NewMessage(sub.ChatID, text)
+				if _, err := bot.Send(msg); err != nil {
+					log.Printf("Ошибка при отправке предупреждения в чат %d: %v", sub.ChatID, err)
+				}
+			}
+		}
+		time.Sleep(interval)
+	}
+}
+
+// scanProcesses проверяет все процессы и возвращает тексты новых предупреждений
+func scanProcesses() ([]string, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+	suspiciousDirs := defaultSuspiciousDirs
+	if dirs := config.GetEnv("SUSPICIOUS_DIRS"); dirs != "" {
+		suspiciousDirs = strings.Split(dirs, ",")
+	}
+
+	securityMutex.Lock()
+	defer securityMutex.Unlock()
+
+	// Без базы запоминаем всё, что запущено сейчас, и ни о чём не предупреждаем
+	learning := baseline == nil
+	if learning {
+		baseline = make(map[string]BaselineEntry)
+	}
+
+	var alerts []string
+	alive := make(map[string]bool)
+	hashed := make(map[string]bool) // Пути, хэш которых нужен в этом сканировании
+	alert := func(key, text string) {
+		alive[key] = true
+		if !securityAlert[key] {
+			securityAlert[key] = true
+			alerts = append(alerts, text)
+		}
+	}
+
+	for _, p := range procs {
+		exe, err := p.Exe()
+		if err != nil || exe == "" {
+			continue // Поток ядра или нет прав на чтение
+		}
+		name, _ := p.Name()
+		cmdline, _ := p.Cmdline()
+		title := fmt.Sprintf("%s [PID: %d]", name, p.Pid)
+
+		// Подозрительные признаки проверяем и в режиме обучения
+		path, deleted := strings.CutSuffix(exe, " (deleted)")
+		if deleted {
+			alert(fmt.Sprintf("deleted:%d", p.Pid), fmt.Sprintf(
+				"🚨 Подозрительный процесс %s: исполняемый файл удалён после запуска (%s)", title, path))
+		}
+		if dir, ok := inSuspiciousDir(path, suspiciousDirs); ok {
+			alert(fmt.Sprintf("dir:%d", p.Pid), fmt.Sprintf(
+				"🚨 Подозрительный процесс %s: запущен из каталога, доступного всем на запись (%s)\n📍 %s", title, dir, path))
+		}
+		if kernelThreadName.MatchString(name) || strings.HasPrefix(cmdline, "[") && strings.HasSuffix(cmdline, "]") {
+			alert(fmt.Sprintf("kthread:%d", p.Pid), fmt.Sprintf(
+				"🚨 Подозрительный процесс %s: выдаёт себя за поток ядра, но запущен из файла %s\n💻 %s", title, path, cmdline))
+		}
+
+		hashed[path] = true
+		hash, err := executableHash(p.Pid, path)
+		if err != nil {
+			continue
+		}
+		if _, known := baseline[hash]; known {
+			continue
+		}
+		if learning {
+			baseline[hash] = BaselineEntry{Path: path, Added: time.Now()}
+			continue
+		}
+		if _, seen := unknownExecs[hash]; !seen {
+			unknownExecs[hash] = &UnknownExecutable{Hash: hash, Path: path, Name: name, PID: p.Pid, FirstSeen: time.Now()}
+			alerts = append(alerts, fmt.Sprintf(
+				"🆕 Запущен новый исполняемый файл: %s\n📍 %s\n🔑 SHA-256: %s\nЕсли файл доверенный: /trust %s",
+				title, path, hash, hash[:12]))
+		}
+	}
+
+	// Забываем предупреждения о завершившихся процессах, чтобы сообщить о новом запуске
+	for key := range securityAlert {
+		if !alive[key] {
+			delete(securityAlert, key)
+		}
+	}
+	// Хэши файлов, которые больше не запущены, не храним
+	for path := range hashCache {
+		if !hashed[path] {
+			delete(hashCache, path)
+		}
+	}
+
+	if learning {
+		log.Printf("База исполняемых файлов создана: %d записей", len(baseline))
+		if err := saveBaseline(); err != nil {
+			return alerts, err
+		}
+	}
+	return alerts, nil
+}
+
+// inSuspiciousDir проверяет, лежит ли файл в одном из каталогов dirs или в каталоге, доступном всем на запись
+func inSuspiciousDir(path string, dirs []string) (string, bool) {
+	for _, dir := range dirs {
+		dir = strings.TrimSpace(dir)
+		if dir != "" && (path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, "/")+"/")) {
+			return dir, true
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "", false
+	}
+	dir := filepath.Dir(path)
+	if info, err := os.Stat(dir); err == nil && info.Mode().Perm()&0002 != 0 {
+		return dir, true
+	}
+	return "", false
+}
+
+// executableHash считает SHA-256 исполняемого файла процесса, кэшируя результат по размеру и времени изменения.
+// На Linux файл читается через /proc/<pid>/exe, поэтому доступен даже после удаления с диска
+func executableHash(pid int32, path string) (string, error) {
+	source := path
+	if runtime.GOOS == "linux" {
+		source = filepath.Join("/proc", strconv.Itoa(int(pid)), "exe")
+	}
+	info, err := os.Stat(source)
+	if err != nil {
+		return "", err
+	}
+
+	if cached, ok := hashCache[path]; ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.hash, nil
+	}
+
+	hash, err := fileHash(source)
+	if err != nil {
+		return "", err
+	}
+	hashCache[path] = hashCacheEntry{size: info.Size(), modTime: info.ModTime(), hash: hash}
+	return hash, nil
+}
+
+// fileHash считает SHA-256 содержимого файла
+func fileHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
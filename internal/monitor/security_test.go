@@ -0,0 +1,142 @@
+package monitor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withSecurityState подменяет файл базы и очищает состояние проверки на время теста
+func withSecurityState(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "exec_baseline.json")
+
+	securityMutex.Lock()
+	oldFile, oldBaseline, oldUnknown, oldCache := baselineFile, baseline, unknownExecs, hashCache
+	baselineFile, baseline = path, nil
+	unknownExecs = make(map[string]*UnknownExecutable)
+	hashCache = make(map[string]hashCacheEntry)
+	securityMutex.Unlock()
+
+	t.Cleanup(func() {
+		securityMutex.Lock()
+		baselineFile, baseline, unknownExecs, hashCache = oldFile, oldBaseline, oldUnknown, oldCache
+		securityMutex.Unlock()
+	})
+	return path
+}
+
+// savedBaseline читает базу, записанную на диск
+func savedBaseline(t *testing.T, path string) map[string]BaselineEntry {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries map[string]BaselineEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestTrustExecutableByPath(t *testing.T) {
+	baselinePath := withSecurityState(t)
+
+	exe := filepath.Join(t.TempDir(), "agent")
+	content := []byte("#!/bin/sh\necho ok\n")
+	if err := os.WriteFile(exe, content, 0755); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+	want := hex.EncodeToString(sum[:])
+
+	// Файл ещё ни разу не запускался — его всё равно можно добавить
+	got, err := TrustExecutable(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Hash != want || got.Path != exe {
+		t.Errorf("TrustExecutable() = %+v, ожидался хэш %s", got, want)
+	}
+	if entry, ok := savedBaseline(t, baselinePath)[want]; !ok || !entry.Trusted || entry.Path != exe {
+		t.Errorf("запись в базе = %+v, %v", entry, ok)
+	}
+
+	for _, path := range []string{filepath.Join(t.TempDir(), "missing"), t.TempDir()} {
+		if _, err := TrustExecutable(path); err == nil {
+			t.Errorf("TrustExecutable(%s) без ошибки", path)
+		}
+	}
+}
+
+func TestTrustExecutableByHash(t *testing.T) {
+	baselinePath := withSecurityState(t)
+
+	seen := strings.Repeat("ab", 32)
+	securityMutex.Lock()
+	unknownExecs[seen] = &UnknownExecutable{Hash: seen, Path: "/opt/app/bin", Name: "bin", FirstSeen: time.Now()}
+	unknownExecs[strings.Repeat("ac", 32)] = &UnknownExecutable{Hash: strings.Repeat("ac", 32), Path: "/opt/other"}
+	securityMutex.Unlock()
+
+	// Начало хэша ищется среди замеченных файлов
+	if _, err := TrustExecutable("abab"); err == nil {
+		t.Error("ожидалась ошибка для слишком короткого начала хэша")
+	}
+	got, err := TrustExecutable("ABABABAB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Hash != seen || got.Path != "/opt/app/bin" {
+		t.Errorf("TrustExecutable(начало) = %+v", got)
+	}
+	if len(UnknownExecutables()) != 1 {
+		t.Errorf("доверенный файл остался в списке неизвестных: %+v", UnknownExecutables())
+	}
+
+	// Полный хэш принимается без запуска файла, например после перезапуска бота
+	full := strings.Repeat("0f", 32)
+	if got, err := TrustExecutable(full); err != nil || got.Hash != full || got.Path != "" {
+		t.Errorf("TrustExecutable(полный хэш) = %+v, %v", got, err)
+	}
+	if _, err := TrustExecutable("deadbeefdeadbeef"); err == nil {
+		t.Error("ожидалась ошибка для незнакомого начала хэша")
+	}
+	if _, err := TrustExecutable(strings.Repeat("zz", 32)); err == nil {
+		t.Error("ожидалась ошибка для строки, не являющейся хэшем")
+	}
+
+	entries := savedBaseline(t, baselinePath)
+	for _, hash := range []string{seen, full} {
+		if !entries[hash].Trusted {
+			t.Errorf("хэш %s не сохранён как доверенный: %+v", hash, entries)
+		}
+	}
+}
+
+func TestScanProcessesEvictsHashCache(t *testing.T) {
+	withSecurityState(t)
+	t.Setenv("SUSPICIOUS_DIRS", "/nonexistent")
+
+	securityMutex.Lock()
+	hashCache["/usr/bin/long-gone"] = hashCacheEntry{hash: strings.Repeat("00", 32)}
+	securityMutex.Unlock()
+
+	if _, err := scanProcesses(); err != nil {
+		t.Fatal(err)
+	}
+
+	securityMutex.Lock()
+	defer securityMutex.Unlock()
+	if _, ok := hashCache["/usr/bin/long-gone"]; ok {
+		t.Error("хэш завершившегося процесса остался в кэше")
+	}
+	if len(baseline) == 0 {
+		t.Error("первое сканирование не заполнило базу")
+	}
+}
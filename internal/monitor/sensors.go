@@ -0,0 +1,224 @@
+package monitor
+
+import (
+	"TG_BOT_GO/internal/config"
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shirou/gopsutil/host"
+)
+
+// TempSensor — датчик температуры (°C). Недоступные пороги равны нулю
+type TempSensor struct {
+	ID       string // Идентификатор для CPU_TEMP_SENSOR: "<микросхема>/<подпись>"
+	Label    string
+	Current  float64
+	High     float64 // Порог высокой температуры
+	Critical float64 // Критическая температура
+}
+
+// FanSensor — вентилятор
+type FanSensor struct {
+	Label string
+	RPM   float64
+	Min   float64 // Минимальные обороты, ниже которых вентилятор считается неисправным
+}
+
+// VoltageSensor — напряжение питания (В)
+type VoltageSensor struct {
+	Label string
+	Volts float64
+	Min   float64
+	Max   float64
+}
+
+// SensorChip — микросхема мониторинга hwmon со всеми её датчиками
+type SensorChip struct {
+	Name     string // coretemp, k10temp, nvme, nct6798...
+	Temps    []TempSensor
+	Fans     []FanSensor
+	Voltages []VoltageSensor
+}
+
+// cpuTempChips — микросхемы с температурой процессора в порядке предпочтения
+// и подписи датчиков, которые лучше всего отражают температуру всего процессора
+var cpuTempChips = []struct {
+	chip   string
+	labels []string
+}{
+	{"coretemp", []string{"Package id 0"}}, // Intel
+	{"k10temp", []string{"Tctl", "Tdie"}},  // AMD
+	{"zenpower", []string{"Tdie", "Tctl"}}, // AMD, сторонний драйвер
+	{"cpu_thermal", nil},                   // Raspberry Pi и другие ARM
+	{"soc_thermal", nil},
+}
+
+// cpuSensorWarning выводит в журнал предупреждение о ненайденном CPU_TEMP_SENSOR один раз
+var cpuSensorWarning sync.Once
+
+// hwmonIndex выделяет номер датчика из имени файла, например 2 из temp2_input
+var hwmonIndex = regexp.MustCompile(`^[a-z]+(\d+)_input$`)
+
+// HwmonChips читает все датчики из <SYSFS_ROOT>/class/hwmon. Одноимённые микросхемы
+// (например, несколько NVMe-дисков) различаются порядковым номером: nvme, nvme_2
+func HwmonChips() []SensorChip {
+	dirs, _ := filepath.Glob(filepath.Join(config.GetSysfsRoot(), "class", "hwmon", "hwmon*"))
+	sort.Slice(dirs, func(i, j int) bool {
+		a, _ := strconv.Atoi(strings.TrimPrefix(filepath.Base(dirs[i]), "hwmon"))
+		b, _ := strconv.Atoi(strings.TrimPrefix(filepath.Base(dirs[j]), "hwmon"))
+		return a < b
+	})
+
+	seen := make(map[string]int)
+	var chips []SensorChip
+	for _, dir := range dirs {
+		name := readSysfsString(filepath.Join(dir, "name"))
+		if name == "" {
+			name = filepath.Base(dir)
+		}
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name = fmt.Sprintf("%s_%d", name, n)
+		}
+
+		chip := SensorChip{Name: name}
+		for _, input := range hwmonInputs(dir, "temp") {
+			prefix, label := hwmonSensor(input)
+			current, _ := readSysfsFloat(input)
+			high, _ := readSysfsFloat(prefix + "_max")
+			critical, _ := readSysfsFloat(prefix + "_crit")
+			chip.Temps = append(chip.Temps, TempSensor{
+				ID: name + "/" + label, Label: label,
+				Current: current / 1000, High: high / 1000, Critical: critical / 1000,
+			})
+		}
+		for _, input := range hwmonInputs(dir, "fan") {
+			prefix, label := hwmonSensor(input)
+			rpm, _ := readSysfsFloat(input)
+			minRPM, _ := readSysfsFloat(prefix + "_min")
+			chip.Fans = append(chip.Fans, FanSensor{Label: label, RPM: rpm, Min: minRPM})
+		}
+		for _, input := range hwmonInputs(dir, "in") {
+			prefix, label := hwmonSensor(input)
+			mv, _ := readSysfsFloat(input)
+			minMV, _ := readSysfsFloat(prefix + "_min")
+			maxMV, _ := readSysfsFloat(prefix + "_max")
+			chip.Voltages = append(chip.Voltages, VoltageSensor{Label: label, Volts: mv / 1000, Min: minMV / 1000, Max: maxMV / 1000})
+		}
+		if len(chip.Temps)+len(chip.Fans)+len(chip.Voltages) > 0 {
+			chips = append(chips, chip)
+		}
+	}
+	return chips
+}
+
+// hwmonInputs возвращает файлы <kind>N_input каталога, упорядоченные по номеру датчика
+func hwmonInputs(dir, kind string) []string {
+	inputs, _ := filepath.Glob(filepath.Join(dir, kind+"*_input"))
+	var valid []string
+	for _, input := range inputs {
+		// Шаблон temp* захватывает и другие файлы, проверяем имя целиком
+		if m := hwmonIndex.FindStringSubmatch(filepath.Base(input)); m != nil &&
+			filepath.Base(input) == kind+m[1]+"_input" {
+			valid = append(valid, input)
+		}
+	}
+	sort.Slice(valid, func(i, j int) bool {
+		a, _ := strconv.Atoi(hwmonIndex.FindStringSubmatch(filepath.Base(valid[i]))[1])
+		b, _ := strconv.Atoi(hwmonIndex.FindStringSubmatch(filepath.Base(valid[j]))[1])
+		return a < b
+	})
+	return valid
+}
+
+// hwmonSensor возвращает общий префикс файлов датчика (…/temp1) и его подпись
+func hwmonSensor(input string) (string, string) {
+	prefix := strings.TrimSuffix(input, "_input")
+	label := readSysfsString(prefix + "_label")
+	if label == "" {
+		label = filepath.Base(prefix)
+	}
+	return prefix, label
+}
+
+// CPUTempSensor возвращает датчик, который считается температурой процессора.
+// Датчик задаётся в CPU_TEMP_SENSOR как "<микросхема>/<подпись>" или "<микросхема>"
+// (первый датчик микросхемы); без настройки выбирается по известным драйверам
+func CPUTempSensor(chips []SensorChip) (TempSensor, bool) {
+	if selector := config.GetEnv("CPU_TEMP_SENSOR"); selector != "" {
+		chipName, label, _ := strings.Cut(selector, "/")
+		for _, chip := range chips {
+			if !strings.EqualFold(chip.Name, chipName) {
+				continue
+			}
+			for _, temp := range chip.Temps {
+				if label == "" || strings.EqualFold(temp.Label, label) {
+					return temp, true
+				}
+			}
+		}
+		return TempSensor{}, false
+	}
+
+	for _, preferred := range cpuTempChips {
+		for _, chip := range chips {
+			if chip.Name != preferred.chip || len(chip.Temps) == 0 {
+				continue
+			}
+			for _, label := range preferred.labels {
+				for _, temp := range chip.Temps {
+					if temp.Label == label {
+						return temp, true
+					}
+				}
+			}
+			return chip.Temps[0], true
+		}
+	}
+	return TempSensor{}, false
+}
+
+// cpuTemperature возвращает температуру процессора и название датчика. Если hwmon
+// недоступен (не Linux), используются датчики gopsutil. Если датчик из CPU_TEMP_SENSOR
+// не найден, температура считается недоступной, а не подменяется другим датчиком
+func cpuTemperature() (float64, string, bool) {
+	selector := config.GetEnv("CPU_TEMP_SENSOR")
+	chips := HwmonChips()
+	if sensor, ok := CPUTempSensor(chips); ok {
+		return sensor.Current, sensor.ID, true
+	}
+	if len(chips) > 0 {
+		if selector != "" {
+			warnCPUSensorNotFound(selector)
+		}
+		return 0, "", false
+	}
+
+	temps, err := host.SensorsTemperatures()
+	if err != nil && len(temps) == 0 {
+		return 0, "", false
+	}
+	for _, temp := range temps {
+		if selector != "" && strings.EqualFold(temp.SensorKey, selector) ||
+			selector == "" && (temp.SensorKey == "coretemp" || strings.Contains(temp.SensorKey, "CPU")) {
+			return temp.Temperature, temp.SensorKey, true
+		}
+	}
+	if selector != "" {
+		warnCPUSensorNotFound(selector)
+	}
+	return 0, "", false
+}
+
+// warnCPUSensorNotFound сообщает в журнал, что датчик из CPU_TEMP_SENSOR не найден
+func warnCPUSensorNotFound(selector string) {
+	cpuSensorWarning.Do(func() {
+		log.Printf("Датчик температуры CPU_TEMP_SENSOR=%q не найден, температура CPU недоступна. Список датчиков — в /sensors", selector)
+	})
+}
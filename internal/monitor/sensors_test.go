@@ -0,0 +1,107 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+)
+
+// fakeHwmonTree создаёт в SYSFS_ROOT датчики процессора AMD, двух NVMe-дисков и Super I/O
+func fakeHwmonTree(t *testing.T) {
+	t.Helper()
+	root := t.TempDir()
+	t.Setenv("SYSFS_ROOT", root)
+	writeTree(t, root, map[string]string{
+		"class/hwmon/hwmon10/name":        "nct6798",
+		"class/hwmon/hwmon10/fan1_input":  "0",
+		"class/hwmon/hwmon10/fan1_min":    "300",
+		"class/hwmon/hwmon10/fan2_input":  "1180",
+		"class/hwmon/hwmon10/fan2_label":  "CPU Fan",
+		"class/hwmon/hwmon10/in0_input":   "1352",
+		"class/hwmon/hwmon10/in0_label":   "Vcore",
+		"class/hwmon/hwmon10/in0_min":     "300",
+		"class/hwmon/hwmon10/in0_max":     "1500",
+		"class/hwmon/hwmon2/name":         "k10temp",
+		"class/hwmon/hwmon2/temp1_input":  "63125",
+		"class/hwmon/hwmon2/temp1_label":  "Tctl",
+		"class/hwmon/hwmon2/temp3_input":  "54000",
+		"class/hwmon/hwmon2/temp3_label":  "Tccd1",
+		"class/hwmon/hwmon3/name":         "nvme",
+		"class/hwmon/hwmon3/temp1_input":  "41850",
+		"class/hwmon/hwmon3/temp1_label":  "Composite",
+		"class/hwmon/hwmon3/temp1_max":    "81850",
+		"class/hwmon/hwmon3/temp1_crit":   "84850",
+		"class/hwmon/hwmon3/temp1_alarm":  "0",
+		"class/hwmon/hwmon4/name":         "nvme",
+		"class/hwmon/hwmon4/temp1_input":  "38850",
+		"class/hwmon/hwmon5/name":         "acpi_fan",
+		"class/hwmon/hwmon5/power1_input": "0",
+	})
+}
+
+func TestHwmonChips(t *testing.T) {
+	fakeHwmonTree(t)
+
+	want := []SensorChip{
+		{Name: "k10temp", Temps: []TempSensor{
+			{ID: "k10temp/Tctl", Label: "Tctl", Current: 63.125},
+			{ID: "k10temp/Tccd1", Label: "Tccd1", Current: 54},
+		}},
+		{Name: "nvme", Temps: []TempSensor{
+			{ID: "nvme/Composite", Label: "Composite", Current: 41.85, High: 81.85, Critical: 84.85},
+		}},
+		{Name: "nvme_2", Temps: []TempSensor{
+			{ID: "nvme_2/temp1", Label: "temp1", Current: 38.85},
+		}},
+		{Name: "nct6798",
+			Fans: []FanSensor{
+				{Label: "fan1", RPM: 0, Min: 300},
+				{Label: "CPU Fan", RPM: 1180},
+			},
+			Voltages: []VoltageSensor{{Label: "Vcore", Volts: 1.352, Min: 0.3, Max: 1.5}},
+		},
+	}
+	if got := HwmonChips(); !reflect.DeepEqual(got, want) {
+		t.Errorf("HwmonChips() =\n%+v\nожидалось\n%+v", got, want)
+	}
+}
+
+func TestCPUTempSensor(t *testing.T) {
+	fakeHwmonTree(t)
+	chips := HwmonChips()
+
+	tests := []struct {
+		selector string
+		wantID   string
+		wantOK   bool
+	}{
+		{"", "k10temp/Tctl", true},
+		{"k10temp/Tccd1", "k10temp/Tccd1", true},
+		{"K10TEMP/tccd1", "k10temp/Tccd1", true},
+		{"nvme_2", "nvme_2/temp1", true},
+		{"coretemp", "", false},
+		{"k10temp/Tdie", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			t.Setenv("CPU_TEMP_SENSOR", tt.selector)
+			sensor, ok := CPUTempSensor(chips)
+			if sensor.ID != tt.wantID || ok != tt.wantOK {
+				t.Errorf("CPUTempSensor() = %q, %v, ожидалось %q, %v", sensor.ID, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCPUTemperatureUnknownSensor(t *testing.T) {
+	fakeHwmonTree(t)
+
+	t.Setenv("CPU_TEMP_SENSOR", "coretemp/Package id 0")
+	if temp, sensor, ok := cpuTemperature(); ok {
+		t.Errorf("cpuTemperature() = %.1f, %q, ожидалось отсутствие температуры", temp, sensor)
+	}
+
+	t.Setenv("CPU_TEMP_SENSOR", "")
+	if temp, sensor, ok := cpuTemperature(); !ok || sensor != "k10temp/Tctl" || temp != 63.125 {
+		t.Errorf("cpuTemperature() = %.3f, %q, %v", temp, sensor, ok)
+	}
+}
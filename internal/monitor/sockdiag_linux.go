@@ -0,0 +1,162 @@
+//go:build linux
+
+package monitor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Константы netlink sock_diag (linux/sock_diag.h, linux/inet_diag.h)
+const (
+	netlinkSockDiag   = 4  // NETLINK_SOCK_DIAG
+	sockDiagByFamily  = 20 // SOCK_DIAG_BY_FAMILY
+	inetDiagInfo      = 2  // INET_DIAG_INFO: атрибут со struct tcp_info
+	inetDiagReqV2Size = 56 // sizeof(struct inet_diag_req_v2)
+	inetDiagMsgSize   = 72 // sizeof(struct inet_diag_msg)
+	inetDiagInodeOff  = 68 // Смещение idiag_inode в inet_diag_msg
+
+	// Смещения счётчиков в struct tcp_info (есть с Linux 4.1 и 4.2)
+	tcpInfoBytesAcked    = 120
+	tcpInfoBytesReceived = 128
+	tcpInfoMinSize       = 136
+)
+
+// readSocketCounters возвращает счётчики байт всех TCP-сокетов по номеру inode
+func readSocketCounters() (map[uint64]socketCounters, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, netlinkSockDiag)
+	if err != nil {
+		return nil, fmt.Errorf("sock_diag недоступен: %w", err)
+	}
+	defer syscall.Close(fd)
+
+	counters := make(map[uint64]socketCounters)
+	for _, family := range []uint8{syscall.AF_INET, syscall.AF_INET6} {
+		if err := dumpTCPSockets(fd, family, counters); err != nil {
+			return nil, err
+		}
+	}
+	return counters, nil
+}
+
+// dumpTCPSockets запрашивает у ядра все TCP-сокеты семейства вместе с tcp_info
+func dumpTCPSockets(fd int, family uint8, counters map[uint64]socketCounters) error {
+	req := make([]byte, syscall.NLMSG_HDRLEN+inetDiagReqV2Size)
+	binary.NativeEndian.PutUint32(req[0:4], uint32(len(req)))
+	binary.NativeEndian.PutUint16(req[4:6], sockDiagByFamily)
+	binary.NativeEndian.PutUint16(req[6:8], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	binary.NativeEndian.PutUint32(req[8:12], 1)
+
+	body := req[syscall.NLMSG_HDRLEN:]
+	body[0] = family
+	body[1] = syscall.IPPROTO_TCP
+	body[2] = 1 << (inetDiagInfo - 1)                    // Просим атрибут INET_DIAG_INFO
+	binary.NativeEndian.PutUint32(body[4:8], 0xffffffff) // Сокеты во всех состояниях
+
+	if err := syscall.Sendto(fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return fmt.Errorf("ошибка запроса sock_diag: %w", err)
+	}
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения sock_diag: %w", err)
+		}
+		messages, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return fmt.Errorf("ошибка разбора ответа sock_diag: %w", err)
+		}
+
+		for _, m := range messages {
+			switch m.Header.Type {
+			case syscall.NLMSG_DONE:
+				return nil
+			case syscall.NLMSG_ERROR:
+				if len(m.Data) >= 4 {
+					if errno := int32(binary.NativeEndian.Uint32(m.Data[0:4])); errno != 0 {
+						return fmt.Errorf("ошибка sock_diag: %w", syscall.Errno(-errno))
+					}
+				}
+				return nil
+			case sockDiagByFamily:
+				parseInetDiagMsg(m.Data, counters)
+			}
+		}
+	}
+}
+
+// parseInetDiagMsg извлекает inode сокета и счётчики байт из tcp_info
+func parseInetDiagMsg(data []byte, counters map[uint64]socketCounters) {
+	if len(data) < inetDiagMsgSize {
+		return
+	}
+	inode := uint64(binary.NativeEndian.Uint32(data[inetDiagInodeOff : inetDiagInodeOff+4]))
+	if inode == 0 {
+		return // Сокет в TIME_WAIT уже не принадлежит процессу
+	}
+
+	// Перебираем атрибуты rtattr после inet_diag_msg
+	attrs := data[inetDiagMsgSize:]
+	for len(attrs) >= syscall.SizeofRtAttr {
+		length := int(binary.NativeEndian.Uint16(attrs[0:2]))
+		attrType := binary.NativeEndian.Uint16(attrs[2:4])
+		if length < syscall.SizeofRtAttr || length > len(attrs) {
+			return
+		}
+		if attrType == inetDiagInfo {
+			info := attrs[syscall.SizeofRtAttr:length]
+			if len(info) >= tcpInfoMinSize {
+				counters[inode] = socketCounters{
+					rx: binary.NativeEndian.Uint64(info[tcpInfoBytesReceived : tcpInfoBytesReceived+8]),
+					tx: binary.NativeEndian.Uint64(info[tcpInfoBytesAcked : tcpInfoBytesAcked+8]),
+				}
+			}
+			return
+		}
+		aligned := (length + syscall.RTA_ALIGNTO - 1) &^ (syscall.RTA_ALIGNTO - 1)
+		if aligned > len(attrs) {
+			return
+		}
+		attrs = attrs[aligned:]
+	}
+}
+
+// socketOwners сопоставляет inode сокетов с PID процессов по ссылкам в /proc/<pid>/fd.
+// Без прав root видны только сокеты процессов того же пользователя
+func socketOwners() map[uint64]int32 {
+	owners := make(map[uint64]int32)
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return owners
+	}
+	for _, entry := range entries {
+		pid, err := strconv.ParseInt(entry.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join("/proc", entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]"), 10, 64)
+			if err != nil {
+				continue
+			}
+			owners[inode] = int32(pid)
+		}
+	}
+	return owners
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package monitor
+
+import "errors"
+
+// readSocketCounters на других ОС не поддерживается: счётчики байт сокетов доступны только через sock_diag Linux
+func readSocketCounters() (map[uint64]socketCounters, error) {
+	return nil, errors.New("учёт трафика по процессам поддерживается только в Linux")
+}
+
+// socketOwners на других ОС не поддерживается
+func socketOwners() map[uint64]int32 {
+	return nil
+}
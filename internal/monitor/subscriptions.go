@@ -0,0 +1,190 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Subscription содержит настройки уведомлений для одного чата
+type Subscription struct {
+	ChatID   int64  `json:"chat_id"`  // Идентификатор чата
+	Enabled  bool   `json:"enabled"`  // Флаг включения/выключения уведомлений
+	Rules    []Rule `json:"rules"`    // Правила уведомлений чата
+	Interval int    `json:"interval"` // Интервал напоминаний о продолжающемся превышении (в минутах)
+}
+
+// storedSubscription — формат подписки в файле. Поля thresholds и tuning
+// остались от старого формата и при загрузке преобразуются в правила
+type storedSubscription struct {
+	Subscription
+	Thresholds *legacyThresholds       `json:"thresholds,omitempty"`
+	Tuning     map[string]legacyTuning `json:"tuning,omitempty"`
+}
+
+var (
+	subscriptionsFile  = "alarm_subscriptions.json" // Файл для сохранения подписок
+	subscriptions      = make(map[int64]*Subscription)
+	subscriptionsMutex sync.Mutex
+)
+
+// LoadSubscriptions загружает подписки на уведомления из файла
+func LoadSubscriptions() error {
+	subscriptionsMutex.Lock()
+	defer subscriptionsMutex.Unlock()
+
+	file, err := os.ReadFile(subscriptionsFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Println("Файл с подписками не найден. Подписок пока нет.")
+			return nil
+		}
+		return err
+	}
+
+	var list []storedSubscription
+	if err := json.Unmarshal(file, &list); err != nil {
+		return err
+	}
+
+	subscriptions = make(map[int64]*Subscription, len(list))
+	converted := false
+	for i := range list {
+		sub := &list[i].Subscription
+		if list[i].Thresholds != nil && sub.Rules == nil {
+			sub.Rules = list[i].Thresholds.toRules(list[i].Tuning)
+			converted = true
+		}
+		subscriptions[sub.ChatID] = sub
+	}
+
+	// Сразу переписываем файл в новом формате
+	if converted {
+		log.Println("Пороги подписок преобразованы в правила уведомлений.")
+		return saveSubscriptions()
+	}
+	return nil
+}
+
+// saveSubscriptions сохраняет подписки в файл (вызывается под subscriptionsMutex)
+func saveSubscriptions() error {
+	list := make([]*Subscription, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		list = append(list, sub)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ChatID < list[j].ChatID
+	})
+
+	// Операторы правил (>, <) сохраняем как есть, чтобы файл было удобно править вручную
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(list); err != nil {
+		return err
+	}
+	return os.WriteFile(subscriptionsFile, buf.Bytes(), 0644)
+}
+
+// GetSubscription возвращает копию подписки чата
+func GetSubscription(chatID int64) (Subscription, bool) {
+	subscriptionsMutex.Lock()
+	defer subscriptionsMutex.Unlock()
+
+	sub, ok := subscriptions[chatID]
+	if !ok {
+		return Subscription{}, false
+	}
+	return sub.clone(), true
+}
+
+// UpdateSubscription изменяет подписку чата (создаёт её при необходимости) и сохраняет результат
+func UpdateSubscription(chatID int64, update func(sub *Subscription)) (Subscription, error) {
+	subscriptionsMutex.Lock()
+	defer subscriptionsMutex.Unlock()
+
+	sub, ok := subscriptions[chatID]
+	if !ok {
+		sub = &Subscription{
+			ChatID:   chatID,
+			Rules:    append([]Rule(nil), DefaultRules...),
+			Interval: AlarmInterval,
+		}
+		subscriptions[chatID] = sub
+		log.Printf("Создана подписка на уведомления для чата %d", chatID)
+	}
+	update(sub)
+
+	return sub.clone(), saveSubscriptions()
+}
+
+// Unsubscribe удаляет подписку чата. Возвращает false, если подписки не было
+func Unsubscribe(chatID int64) (bool, error) {
+	subscriptionsMutex.Lock()
+	defer subscriptionsMutex.Unlock()
+
+	if _, ok := subscriptions[chatID]; !ok {
+		return false, nil
+	}
+	delete(subscriptions, chatID)
+	log.Printf("Подписка на уведомления для чата %d удалена", chatID)
+
+	return true, saveSubscriptions()
+}
+
+// activeSubscriptions возвращает копии включённых подписок
+func activeSubscriptions() []Subscription {
+	subscriptionsMutex.Lock()
+	defer subscriptionsMutex.Unlock()
+
+	var list []Subscription
+	for _, sub := range subscriptions {
+		if sub.Enabled {
+			list = append(list, sub.clone())
+		}
+	}
+	return list
+}
+
+// clone возвращает копию подписки, не разделяющую карту настроек с оригиналом
+func (s *Subscription) clone() Subscription {
+	c := *s
+	c.Rules = append([]Rule(nil), s.Rules...)
+	return c
+}
+
+// FindRule возвращает правило подписки по имени
+func (s Subscription) FindRule(name string) (Rule, bool) {
+	for _, rule := range s.Rules {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// SetRule добавляет правило или заменяет правило с тем же именем
+func (s *Subscription) SetRule(rule Rule) {
+	for i := range s.Rules {
+		if s.Rules[i].Name == rule.Name {
+			s.Rules[i] = rule
+			return
+		}
+	}
+	s.Rules = append(s.Rules, rule)
+}
+
+// DeleteRule удаляет правило по имени. Возвращает false, если правила не было
+func (s *Subscription) DeleteRule(name string) bool {
+	for i := range s.Rules {
+		if s.Rules[i].Name == name {
+			s.Rules = append(s.Rules[:i], s.Rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,399 @@
+package monitor
+
+import (
+	"TG_BOT_GO/internal/config"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/shirou/gopsutil/process"
+)
+
+// Состояния отслеживаемого процесса
+const (
+	WatchUnknown  = "unknown"  // Ещё не проверялся
+	WatchRunning  = "running"  // Запущен в допустимом числе экземпляров
+	WatchDown     = "down"     // Не найден
+	WatchMultiple = "multiple" // Экземпляров больше допустимого
+)
+
+// watchCommandPrefix — префикс переменных окружения с командами перезапуска.
+// Из чата на них можно только сослаться по имени, сами команды задаёт администратор сервера
+const watchCommandPrefix = "WATCHDOG_CMD_"
+
+// watchStableTime — сколько процесс должен проработать после перезапуска, чтобы задержка перезапуска сбросилась
+const watchStableTime = 5 * time.Minute
+
+// WatchRule — правило сторожа процессов
+type WatchRule struct {
+	ID       int    `json:"id"`
+	Pattern  string `json:"pattern"`           // Имя процесса или /регулярное выражение/ по имени и командной строке
+	Max      int    `json:"max"`               // Допустимое число экземпляров
+	Restart  string `json:"restart,omitempty"` // Имя команды перезапуска из WATCHDOG_CMD_<ИМЯ>
+	ChatID   int64  `json:"chat_id"`           // Чат, добавивший правило
+	Crashes  int    `json:"crashes"`           // Сколько раз процесс пропадал
+	Restarts int    `json:"restarts"`          // Сколько раз сторож его перезапускал
+}
+
+// WatchStatus — правило вместе с текущим состоянием процесса
+type WatchStatus struct {
+	WatchRule
+	State       string
+	PIDs        []int32
+	NextRestart time.Time // Время следующей попытки перезапуска (если процесс не запущен)
+	LastError   string    // Ошибка последнего перезапуска
+}
+
+// watchState — состояние правила между проверками
+type watchState struct {
+	state        string
+	pids         []int32
+	runningSince time.Time
+	backoff      time.Duration
+	nextRestart  time.Time
+	lastError    string
+}
+
+var (
+	watchRulesFile = "watch_rules.json" // Файл для сохранения правил сторожа
+	watchRules     []*WatchRule
+	watchStates    = make(map[int]*watchState)
+	watchMutex     sync.Mutex
+)
+
+// LoadWatchRules загружает правила сторожа процессов из файла
+func LoadWatchRules() error {
+	watchMutex.Lock()
+	defer watchMutex.Unlock()
+
+	file, err := os.ReadFile(watchRulesFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var list []*WatchRule
+	if err := json.Unmarshal(file, &list); err != nil {
+		return err
+	}
+	commands := WatchRestartCommands()
+	for _, rule := range list {
+		if _, err := compileWatchPattern(rule.Pattern); err != nil {
+			log.Printf("Правило сторожа #%d пропущено: %v", rule.ID, err)
+			continue
+		}
+		// Правила из прежних версий хранили текст команды; выполнять его из файла небезопасно
+		if _, ok := commands[rule.Restart]; rule.Restart != "" && !ok {
+			log.Printf("Правило сторожа #%d: команда перезапуска %q не задана в %s<ИМЯ>, перезапуск отключён",
+				rule.ID, rule.Restart, watchCommandPrefix)
+			rule.Restart = ""
+		}
+		watchRules = append(watchRules, rule)
+	}
+	return nil
+}
+
+// saveWatchRules сохраняет правила сторожа в файл (вызывается под watchMutex)
+func saveWatchRules() error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(watchRules); err != nil {
+		return err
+	}
+	return os.WriteFile(watchRulesFile, buf.Bytes(), 0644)
+}
+
+// WatchRestartCommands возвращает команды перезапуска из переменных окружения WATCHDOG_CMD_<ИМЯ>
+func WatchRestartCommands() map[string]string {
+	return config.GetEnvWithPrefix(watchCommandPrefix)
+}
+
+// AddWatchRule добавляет правило сторожа и возвращает его. restart — имя команды перезапуска
+// из WATCHDOG_CMD_<ИМЯ> или пустая строка
+func AddWatchRule(chatID int64, pattern string, max int, restart string) (WatchRule, error) {
+	if _, err := compileWatchPattern(pattern); err != nil {
+		return WatchRule{}, err
+	}
+	restart = strings.ToLower(restart)
+	if _, ok := WatchRestartCommands()[restart]; restart != "" && !ok {
+		return WatchRule{}, fmt.Errorf("команда перезапуска %q не настроена: её задаёт администратор в переменной %s%s",
+			restart, watchCommandPrefix, strings.ToUpper(restart))
+	}
+	if max < 1 {
+		return WatchRule{}, fmt.Errorf("допустимое число экземпляров должно быть не меньше 1")
+	}
+
+	watchMutex.Lock()
+	defer watchMutex.Unlock()
+
+	id := 1
+	for _, rule := range watchRules {
+		if rule.ID >= id {
+			id = rule.ID + 1
+		}
+	}
+	rule := &WatchRule{ID: id, Pattern: pattern, Max: max, Restart: restart, ChatID: chatID}
+	watchRules = append(watchRules, rule)
+	return *rule, saveWatchRules()
+}
+
+// DeleteWatchRule удаляет правило сторожа. Возвращает false, если правила нет
+func DeleteWatchRule(id int) (bool, error) {
+	watchMutex.Lock()
+	defer watchMutex.Unlock()
+
+	for i, rule := range watchRules {
+		if rule.ID == id {
+			watchRules = append(watchRules[:i], watchRules[i+1:]...)
+			delete(watchStates, id)
+			return true, saveWatchRules()
+		}
+	}
+	return false, nil
+}
+
+// WatchStatuses возвращает правила сторожа с текущим состоянием процессов
+func WatchStatuses() []WatchStatus {
+	watchMutex.Lock()
+	defer watchMutex.Unlock()
+
+	list := make([]WatchStatus, 0, len(watchRules))
+	for _, rule := range watchRules {
+		status := WatchStatus{WatchRule: *rule, State: WatchUnknown}
+		if st, ok := watchStates[rule.ID]; ok {
+			status.State = st.state
+			status.PIDs = append([]int32(nil), st.pids...)
+			status.LastError = st.lastError
+			if st.state == WatchDown && rule.Restart != "" {
+				status.NextRestart = st.nextRestart
+			}
+		}
+		list = append(list, status)
+	}
+	return list
+}
+
+// compileWatchPattern проверяет шаблон правила. Для /регулярного выражения/ возвращает скомпилированное выражение
+func compileWatchPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("укажите имя процесса или /регулярное выражение/")
+	}
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("некорректное регулярное выражение: %v", err)
+		}
+		return re, nil
+	}
+	return nil, nil
+}
+
+// watchedProcess — процесс, проверяемый по правилам сторожа
+type watchedProcess struct {
+	pid     int32
+	name    string
+	cmdline string
+}
+
+// matchWatchRule возвращает PID процессов, подходящих под правило: имя сравнивается целиком
+// без учёта регистра, регулярное выражение проверяется по имени и командной строке
+func matchWatchRule(rule *WatchRule, procs []watchedProcess) []int32 {
+	re, _ := compileWatchPattern(rule.Pattern)
+	var pids []int32
+	for _, p := range procs {
+		if re != nil && (re.MatchString(p.name) || re.MatchString(p.cmdline)) ||
+			re == nil && strings.EqualFold(p.name, rule.Pattern) {
+			pids = append(pids, p.pid)
+		}
+	}
+	return pids
+}
+
+// listWatchedProcesses возвращает все процессы, кроме самого бота
+func listWatchedProcesses() ([]watchedProcess, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+	self := int32(os.Getpid())
+
+	list := make([]watchedProcess, 0, len(procs))
+	for _, p := range procs {
+		if p.Pid == self {
+			continue
+		}
+		name, err := p.Name()
+		if err != nil {
+			continue // Процесс завершился
+		}
+		cmdline, _ := p.Cmdline()
+		list = append(list, watchedProcess{pid: p.Pid, name: name, cmdline: cmdline})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].pid < list[j].pid
+	})
+	return list, nil
+}
+
+// StartWatchdog запускает цикл сторожа процессов: сообщает о падении и дублях отслеживаемых
+// процессов и перезапускает их с нарастающей задержкой
+func StartWatchdog(bot *tgbotapi.BotAPI) {
+	interval := config.GetEnvDuration("WATCHDOG_INTERVAL", 10*time.Second)
+	for {
+		procs, err := listWatchedProcesses()
+		if err != nil {
+			log.Println("Ошибка сторожа процессов:", err)
+		} else {
+			for _, event := range checkWatchRules(procs, time.Now()) {
+				notifyWatchEvent(bot, event)
+			}
+		}
+		time.Sleep(interval)
+	}
+}
+
+// watchEvent — уведомление сторожа для чата-владельца правила и подписчиков
+type watchEvent struct {
+	chatID int64
+	text   string
+}
+
+// checkWatchRules сравнивает найденные процессы с правилами, обновляет состояния,
+// перезапускает упавшие процессы и возвращает уведомления
+func checkWatchRules(procs []watchedProcess, now time.Time) []watchEvent {
+	baseBackoff := config.GetEnvDuration("WATCHDOG_RESTART_BACKOFF", 10*time.Second)
+	maxBackoff := config.GetEnvDuration("WATCHDOG_RESTART_MAX_BACKOFF", 10*time.Minute)
+	commands := WatchRestartCommands()
+
+	watchMutex.Lock()
+	defer watchMutex.Unlock()
+
+	var events []watchEvent
+	changed := false
+	for _, rule := range watchRules {
+		st, ok := watchStates[rule.ID]
+		if !ok {
+			st = &watchState{state: WatchUnknown, backoff: baseBackoff}
+			watchStates[rule.ID] = st
+		}
+		notify := func(format string, args ...any) {
+			events = append(events, watchEvent{chatID: rule.ChatID, text: fmt.Sprintf(format, args...)})
+		}
+
+		pids := matchWatchRule(rule, procs)
+		prev := st.state
+		st.pids = pids
+
+		switch {
+		case len(pids) == 0:
+			st.state = WatchDown
+			if prev == WatchRunning || prev == WatchMultiple {
+				rule.Crashes++
+				changed = true
+				notify("💥 Процесс %s завершился (падений: %d)", rule.Pattern, rule.Crashes)
+			} else if prev == WatchUnknown {
+				notify("⚠️ Процесс %s не запущен", rule.Pattern)
+			}
+			if rule.Restart != "" && !now.Before(st.nextRestart) {
+				rule.Restarts++
+				changed = true
+				if err := startWatchCommand(commands, rule.Restart); err != nil {
+					st.lastError = err.Error()
+					notify("❌ Не удалось перезапустить %s: %v", rule.Pattern, err)
+				} else {
+					st.lastError = ""
+					notify("🔄 Процесс %s перезапущен (перезапусков: %d, следующая попытка не раньше чем через %s)",
+						rule.Pattern, rule.Restarts, st.backoff)
+				}
+				st.nextRestart = now.Add(st.backoff)
+				st.backoff = min(st.backoff*2, maxBackoff)
+			}
+		case len(pids) > rule.Max:
+			st.state = WatchMultiple
+			if prev != WatchMultiple {
+				notify("⚠️ Процесс %s запущен в %d экземплярах (допустимо %d): PID %s",
+					rule.Pattern, len(pids), rule.Max, formatPIDs(pids))
+			}
+		default:
+			st.state = WatchRunning
+			if prev == WatchDown {
+				notify("✅ Процесс %s снова работает (PID %s)", rule.Pattern, formatPIDs(pids))
+			}
+		}
+
+		// Процесс работает — отсчитываем время до сброса задержки перезапуска
+		if st.state == WatchDown {
+			st.runningSince = time.Time{}
+		} else if st.runningSince.IsZero() {
+			st.runningSince = now
+		} else if now.Sub(st.runningSince) >= watchStableTime {
+			st.backoff = baseBackoff
+			st.nextRestart = time.Time{}
+		}
+	}
+
+	if changed {
+		if err := saveWatchRules(); err != nil {
+			log.Println("Ошибка при сохранении правил сторожа:", err)
+		}
+	}
+	return events
+}
+
+// startWatchCommand запускает команду перезапуска с именем name из настроек через оболочку,
+// не дожидаясь её завершения
+func startWatchCommand(commands map[string]string, name string) error {
+	command, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("команда перезапуска %q не задана в %s%s", name, watchCommandPrefix, strings.ToUpper(name))
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	log.Printf("Сторож процессов запустил %q (PID %d)", command, cmd.Process.Pid)
+	go cmd.Wait() // Забираем код завершения, чтобы не копились зомби-процессы
+	return nil
+}
+
+// formatPIDs выводит список PID через запятую
+func formatPIDs(pids []int32) string {
+	parts := make([]string, len(pids))
+	for i, pid := range pids {
+		parts[i] = fmt.Sprint(pid)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// notifyWatchEvent отправляет уведомление сторожа чату-владельцу правила и чатам с включёнными уведомлениями
+func notifyWatchEvent(bot *tgbotapi.BotAPI, event watchEvent) {
+	recipients := map[int64]bool{event.chatID: true}
+	for _, sub := range activeSubscriptions() {
+		recipients[sub.ChatID] = true
+	}
+	for chatID := range recipients {
+		msg := tgbotapi.NewMessage(chatID, "🐕 Сторож процессов: "+event.text)
+		if _, err := bot.Send(msg); err != nil {
+			log.Printf("Ошибка при отправке уведомления сторожа в чат %d: %v", chatID, err)
+		}
+	}
+}
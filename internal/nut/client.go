@@ -0,0 +1,179 @@
+package nut
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// DefaultPort — порт upsd по умолчанию
+const DefaultPort = "3493"
+
+// Client — клиент сетевого протокола upsd из Network UPS Tools.
+// Протокол описан в https://networkupstools.org/docs/developer-guide.chunked/net-protocol.html
+type Client struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+}
+
+// Dial подключается к upsd. Если в адресе не указан порт, используется 3493
+func Dial(addr string, timeout time.Duration) (*Client, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, DefaultPort)
+	}
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, reader: bufio.NewReader(conn), timeout: timeout}, nil
+}
+
+// Close завершает сеанс и закрывает соединение
+func (c *Client) Close() error {
+	c.command("LOGOUT")
+	return c.conn.Close()
+}
+
+// Login передаёт имя пользователя и пароль, если upsd требует авторизацию
+func (c *Client) Login(username, password string) error {
+	if username != "" {
+		if err := c.expectOK("USERNAME " + quote(username)); err != nil {
+			return err
+		}
+	}
+	if password != "" {
+		if err := c.expectOK("PASSWORD " + quote(password)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListUPS возвращает имена и описания ИБП, которые обслуживает upsd
+func (c *Client) ListUPS() (map[string]string, error) {
+	lines, err := c.list("UPS")
+	if err != nil {
+		return nil, err
+	}
+	list := make(map[string]string, len(lines))
+	for _, fields := range lines {
+		// UPS <имя> "<описание>"
+		if len(fields) >= 3 && fields[0] == "UPS" {
+			list[fields[1]] = fields[2]
+		}
+	}
+	return list, nil
+}
+
+// ListVars возвращает все переменные ИБП, например battery.charge и ups.status
+func (c *Client) ListVars(ups string) (map[string]string, error) {
+	lines, err := c.list("VAR " + ups)
+	if err != nil {
+		return nil, err
+	}
+	vars := make(map[string]string, len(lines))
+	for _, fields := range lines {
+		// VAR <ИБП> <переменная> "<значение>"
+		if len(fields) >= 4 && fields[0] == "VAR" {
+			vars[fields[2]] = fields[3]
+		}
+	}
+	return vars, nil
+}
+
+// list выполняет LIST <query> и возвращает разобранные строки между BEGIN и END
+func (c *Client) list(query string) ([][]string, error) {
+	line, err := c.command("LIST " + query)
+	if err != nil {
+		return nil, err
+	}
+	if line != "BEGIN LIST "+query {
+		return nil, fmt.Errorf("неожиданный ответ upsd: %q", line)
+	}
+	var lines [][]string
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "END LIST "+query {
+			return lines, nil
+		}
+		lines = append(lines, splitLine(line))
+	}
+}
+
+// expectOK выполняет команду, на которую upsd отвечает OK
+func (c *Client) expectOK(cmd string) error {
+	line, err := c.command(cmd)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "OK") {
+		return fmt.Errorf("неожиданный ответ upsd: %q", line)
+	}
+	return nil
+}
+
+// command отправляет команду и возвращает первую строку ответа
+func (c *Client) command(cmd string) (string, error) {
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if _, err := fmt.Fprintf(c.conn, "%s\n", cmd); err != nil {
+		return "", err
+	}
+	return c.readLine()
+}
+
+// readLine читает строку ответа и превращает ответ ERR в ошибку
+func (c *Client) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if code, ok := strings.CutPrefix(line, "ERR "); ok {
+		return "", errors.New("upsd: " + code)
+	}
+	return line, nil
+}
+
+// splitLine разбивает строку ответа на поля с учётом кавычек и экранирования \" и \\
+func splitLine(line string) []string {
+	var fields []string
+	var field strings.Builder
+	inQuotes, escaped, started := false, false, false
+	for _, r := range line {
+		switch {
+		case escaped:
+			field.WriteRune(r)
+			escaped = false
+		case r == '\\' && inQuotes:
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+			started = true
+		case r == ' ' && !inQuotes:
+			if started {
+				fields = append(fields, field.String())
+				field.Reset()
+				started = false
+			}
+		default:
+			field.WriteRune(r)
+			started = true
+		}
+	}
+	if started {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+// quote заключает значение в кавычки для передачи upsd
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,202 @@
+package nut
+
+import (
+	"bufio"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeUPSD — upsd, отвечающий на команды по заранее заданной таблице
+type fakeUPSD struct {
+	listener net.Listener
+	replies  map[string]string
+
+	mutex    sync.Mutex
+	commands []string
+}
+
+// startFakeUPSD запускает upsd на свободном порту 127.0.0.1. Неизвестные команды получают ERR UNKNOWN-COMMAND
+func startFakeUPSD(t *testing.T, replies map[string]string) *fakeUPSD {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeUPSD{listener: listener, replies: replies}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *fakeUPSD) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		s.mutex.Lock()
+		s.commands = append(s.commands, cmd)
+		s.mutex.Unlock()
+
+		reply, ok := s.replies[cmd]
+		if !ok {
+			reply = "ERR UNKNOWN-COMMAND"
+		}
+		conn.Write([]byte(reply + "\n"))
+		if cmd == "LOGOUT" {
+			return
+		}
+	}
+}
+
+// received возвращает команды, полученные сервером
+func (s *fakeUPSD) received() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+var upsdReplies = map[string]string{
+	`USERNAME "monuser"`:   "OK",
+	`PASSWORD "pa\"ss\\w"`: "OK",
+	`USERNAME "intruder"`:  "OK",
+	`PASSWORD "wrong"`:     "ERR ACCESS-DENIED",
+	"LIST UPS": "BEGIN LIST UPS\n" +
+		`UPS office "APC Back-UPS 650"` + "\n" +
+		`UPS rack "Eaton \"5P\" 1550"` + "\n" +
+		"END LIST UPS",
+	"LIST VAR office": "BEGIN LIST VAR office\n" +
+		`VAR office battery.charge "87"` + "\n" +
+		`VAR office battery.runtime "1260"` + "\n" +
+		`VAR office ups.status "OB DISCHRG"` + "\n" +
+		`VAR office ups.mfr "American Power Conversion"` + "\n" +
+		"END LIST VAR office",
+	"LIST VAR missing": "ERR UNKNOWN-UPS",
+	"LIST VAR broken":  "OK",
+	"LOGOUT":           "OK Goodbye",
+}
+
+func TestClient(t *testing.T) {
+	server := startFakeUPSD(t, upsdReplies)
+
+	client, err := Dial(server.listener.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Login("monuser", `pa"ss\w`); err != nil {
+		t.Fatalf("Login() = %v", err)
+	}
+
+	list, err := client.ListUPS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantList := map[string]string{"office": "APC Back-UPS 650", "rack": `Eaton "5P" 1550`}
+	if !reflect.DeepEqual(list, wantList) {
+		t.Errorf("ListUPS() = %v, ожидалось %v", list, wantList)
+	}
+
+	vars, err := client.ListVars("office")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantVars := map[string]string{
+		"battery.charge":  "87",
+		"battery.runtime": "1260",
+		"ups.status":      "OB DISCHRG",
+		"ups.mfr":         "American Power Conversion",
+	}
+	if !reflect.DeepEqual(vars, wantVars) {
+		t.Errorf("ListVars() = %v, ожидалось %v", vars, wantVars)
+	}
+
+	// Ответ ERR не должен нарушать дальнейший обмен
+	if _, err := client.ListVars("missing"); err == nil || err.Error() != "upsd: UNKNOWN-UPS" {
+		t.Errorf("ListVars(missing) = %v, ожидалась ошибка upsd: UNKNOWN-UPS", err)
+	}
+	if _, err := client.ListVars("broken"); err == nil || !strings.Contains(err.Error(), "неожиданный ответ") {
+		t.Errorf("ListVars(broken) = %v, ожидалась ошибка о неожиданном ответе", err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+	wantCommands := []string{
+		`USERNAME "monuser"`, `PASSWORD "pa\"ss\\w"`, "LIST UPS", "LIST VAR office",
+		"LIST VAR missing", "LIST VAR broken", "LOGOUT",
+	}
+	if got := server.received(); !reflect.DeepEqual(got, wantCommands) {
+		t.Errorf("команды = %q, ожидалось %q", got, wantCommands)
+	}
+}
+
+func TestClientLoginDenied(t *testing.T) {
+	server := startFakeUPSD(t, upsdReplies)
+
+	client, err := Dial(server.listener.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if err := client.Login("intruder", "wrong"); err == nil || err.Error() != "upsd: ACCESS-DENIED" {
+		t.Errorf("Login() = %v, ожидалась ошибка upsd: ACCESS-DENIED", err)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	// Сервер принимает соединение, но не отвечает
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+
+	client, err := Dial(listener.Addr().String(), 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.conn.Close()
+	if _, err := client.ListUPS(); err == nil {
+		t.Error("ожидалась ошибка по тайм-ауту")
+	}
+}
+
+func TestSplitLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{`VAR ups battery.charge "100"`, []string{"VAR", "ups", "battery.charge", "100"}},
+		{`UPS rack "Eaton \"5P\" \\ 1550"`, []string{"UPS", "rack", `Eaton "5P" \ 1550`}},
+		{`VAR ups ups.id ""`, []string{"VAR", "ups", "ups.id", ""}},
+		{`BEGIN  LIST   UPS`, []string{"BEGIN", "LIST", "UPS"}},
+	}
+	for _, tt := range tests {
+		if got := splitLine(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitLine(%q) = %q, ожидалось %q", tt.line, got, tt.want)
+		}
+	}
+}
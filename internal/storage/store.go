@@ -0,0 +1,446 @@
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Point — агрегированное значение метрики за интервал
+type Point struct {
+	Time  time.Time
+	Avg   float64
+	Min   float64
+	Max   float64
+	Count int
+}
+
+// Retention задаёт, сколько хранить данные каждого уровня детализации
+type Retention struct {
+	Raw    time.Duration // Исходные измерения
+	Minute time.Duration // Минутные агрегаты
+	Hour   time.Duration // Часовые агрегаты
+}
+
+// tier описывает уровень хранения: каталог, длину сегмента и шаг агрегации
+type tier struct {
+	name    string
+	segment time.Duration // Длительность одного файла-сегмента
+	step    time.Duration // Шаг агрегации (0 — исходные измерения)
+}
+
+var (
+	tierRaw    = tier{name: "raw", segment: time.Hour}
+	tierMinute = tier{name: "1m", segment: 24 * time.Hour, step: time.Minute}
+	tierHour   = tier{name: "1h", segment: 7 * 24 * time.Hour, step: time.Hour}
+	tiers      = []tier{tierRaw, tierMinute, tierHour}
+)
+
+// segmentLayout — формат времени начала сегмента в имени файла
+const segmentLayout = "20060102T15"
+
+// Store — журнал метрик на диске: сегменты только дописываются, старые сворачиваются в агрегаты
+type Store struct {
+	dir       string
+	retention Retention
+
+	mutex       sync.Mutex
+	current     *os.File
+	currentName string
+	writer      *bufio.Writer
+}
+
+// Open открывает (или создаёт) хранилище в каталоге dir
+func Open(dir string, retention Retention) (*Store, error) {
+	for _, t := range tiers {
+		if err := os.MkdirAll(filepath.Join(dir, t.name), 0755); err != nil {
+			return nil, err
+		}
+	}
+	return &Store{dir: dir, retention: retention}, nil
+}
+
+// Close закрывает текущий сегмент
+func (s *Store) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.closeCurrent()
+}
+
+// closeCurrent сбрасывает буфер и закрывает открытый сегмент (вызывается под mutex)
+func (s *Store) closeCurrent() error {
+	if s.current == nil {
+		return nil
+	}
+	err := s.writer.Flush()
+	if cerr := s.current.Close(); err == nil {
+		err = cerr
+	}
+	s.current, s.writer, s.currentName = nil, nil, ""
+	return err
+}
+
+// Append дописывает измерения, снятые в момент t, в текущий сегмент
+func (s *Store) Append(t time.Time, values map[string]float64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	name := segmentName(tierRaw, t)
+	if name != s.currentName {
+		if err := s.closeCurrent(); err != nil {
+			log.Printf("Ошибка при закрытии сегмента метрик: %v", err)
+		}
+		file, err := os.OpenFile(s.segmentPath(tierRaw, name), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		s.current, s.currentName, s.writer = file, name, bufio.NewWriter(file)
+	}
+
+	names := make([]string, 0, len(values))
+	for metric := range values {
+		names = append(names, metric)
+	}
+	sort.Strings(names)
+
+	unix := t.Unix()
+	for _, metric := range names {
+		value := values[metric]
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		fmt.Fprintf(s.writer, "%d %s %s\n", unix, escapeMetric(metric), strconv.FormatFloat(value, 'g', 6, 64))
+	}
+	return s.writer.Flush()
+}
+
+// Query возвращает значения метрики в интервале [from, to], сгруппированные по step
+func (s *Store) Query(metric string, from, to time.Time, step time.Duration) ([]Point, error) {
+	if step <= 0 {
+		step = time.Minute
+	}
+	buckets := make(map[int64]*Point)
+
+	for _, t := range tiers {
+		segments, err := s.segments(t)
+		if err != nil {
+			return nil, err
+		}
+		for _, start := range segments {
+			if !start.Before(to) || !start.Add(t.segment).After(from) {
+				continue
+			}
+			points, err := readSegment(s.segmentPath(t, start.Format(segmentLayout)), metric)
+			if err != nil {
+				return nil, err
+			}
+			for _, p := range points[metric] {
+				if p.Time.Before(from) || p.Time.After(to) {
+					continue
+				}
+				key := p.Time.Sub(from).Nanoseconds() / step.Nanoseconds()
+				bucket, ok := buckets[key]
+				if !ok {
+					bucket = &Point{Time: from.Add(time.Duration(key) * step), Min: p.Min, Max: p.Max}
+					buckets[key] = bucket
+				}
+				mergePoint(bucket, p)
+			}
+		}
+	}
+
+	result := make([]Point, 0, len(buckets))
+	for _, p := range buckets {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result, nil
+}
+
+// Compact сворачивает устаревшие исходные данные в минутные, минутные — в часовые и удаляет самые старые
+func (s *Store) Compact(now time.Time) error {
+	if err := s.rollup(tierRaw, tierMinute, now.Add(-s.retention.Raw)); err != nil {
+		return err
+	}
+	if err := s.rollup(tierMinute, tierHour, now.Add(-s.retention.Minute)); err != nil {
+		return err
+	}
+
+	segments, err := s.segments(tierHour)
+	if err != nil {
+		return err
+	}
+	for _, start := range segments {
+		if start.Add(tierHour.segment).Before(now.Add(-s.retention.Hour)) {
+			if err := os.Remove(s.segmentPath(tierHour, start.Format(segmentLayout))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RunCompaction периодически запускает Compact
+func (s *Store) RunCompaction(interval time.Duration) {
+	for {
+		if err := s.Compact(time.Now()); err != nil {
+			log.Printf("Ошибка при сжатии хранилища метрик: %v", err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// rollup агрегирует сегменты src, закончившиеся раньше before, в сегменты dst и удаляет исходные.
+// Результирующий сегмент перезаписывается целиком, поэтому повторный запуск после сбоя не дублирует данные
+func (s *Store) rollup(src, dst tier, before time.Time) error {
+	segments, err := s.segments(src)
+	if err != nil {
+		return err
+	}
+
+	// Группируем устаревшие исходные сегменты по целевому сегменту
+	groups := make(map[time.Time][]time.Time)
+	for _, start := range segments {
+		if start.Add(src.segment).After(before) {
+			continue
+		}
+		target := start.UTC().Truncate(dst.segment)
+		groups[target] = append(groups[target], start)
+	}
+
+	for target, sources := range groups {
+		s.mutex.Lock()
+		// Текущий сегмент мог оказаться среди устаревших, если бот долго не писал данные
+		for _, start := range sources {
+			if start.Format(segmentLayout) == s.currentName {
+				s.closeCurrent()
+			}
+		}
+		s.mutex.Unlock()
+
+		if err := s.rollupGroup(src, dst, target, sources); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollupGroup пересобирает один целевой сегмент из исходных
+func (s *Store) rollupGroup(src, dst tier, target time.Time, sources []time.Time) error {
+	dstPath := s.segmentPath(dst, target.Format(segmentLayout))
+
+	// Сохраняем уже агрегированные точки, не попадающие в пересобираемые исходные сегменты
+	existing, err := readSegment(dstPath, "")
+	if err != nil {
+		return err
+	}
+	covered := func(t time.Time) bool {
+		for _, start := range sources {
+			if !t.Before(start) && t.Before(start.Add(src.segment)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	type key struct {
+		metric string
+		time   int64
+	}
+	merged := make(map[key]*Point)
+	for metric, points := range existing {
+		for _, p := range points {
+			if covered(p.Time) {
+				continue
+			}
+			pt := p
+			merged[key{metric, p.Time.Unix()}] = &pt
+		}
+	}
+
+	for _, start := range sources {
+		points, err := readSegment(s.segmentPath(src, start.Format(segmentLayout)), "")
+		if err != nil {
+			return err
+		}
+		for metric, list := range points {
+			for _, p := range list {
+				k := key{metric, p.Time.Truncate(dst.step).Unix()}
+				bucket, ok := merged[k]
+				if !ok {
+					bucket = &Point{Time: time.Unix(k.time, 0), Min: p.Min, Max: p.Max}
+					merged[k] = bucket
+				}
+				mergePoint(bucket, p)
+			}
+		}
+	}
+
+	keys := make([]key, 0, len(merged))
+	for k := range merged {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].time != keys[j].time {
+			return keys[i].time < keys[j].time
+		}
+		return keys[i].metric < keys[j].metric
+	})
+
+	tmpPath := dstPath + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	for _, k := range keys {
+		p := merged[k]
+		fmt.Fprintf(writer, "%d %s %s %s %s %d\n", k.time, escapeMetric(k.metric),
+			strconv.FormatFloat(p.Avg, 'g', 6, 64),
+			strconv.FormatFloat(p.Min, 'g', 6, 64),
+			strconv.FormatFloat(p.Max, 'g', 6, 64),
+			p.Count)
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, dstPath); err != nil {
+		return err
+	}
+
+	for _, start := range sources {
+		if err := os.Remove(s.segmentPath(src, start.Format(segmentLayout))); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// segments возвращает отсортированные времена начала сегментов уровня
+func (s *Store) segments(t tier) ([]time.Time, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, t.name))
+	if err != nil {
+		return nil, err
+	}
+
+	var starts []time.Time
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".log")
+		if name == entry.Name() {
+			continue // Пропускаем временные и посторонние файлы
+		}
+		start, err := time.ParseInLocation(segmentLayout, name, time.UTC)
+		if err != nil {
+			continue
+		}
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i].Before(starts[j])
+	})
+	return starts, nil
+}
+
+// segmentPath возвращает путь к файлу сегмента
+func (s *Store) segmentPath(t tier, name string) string {
+	return filepath.Join(s.dir, t.name, name+".log")
+}
+
+// segmentName возвращает имя сегмента уровня, в который попадает момент времени
+func segmentName(t tier, at time.Time) string {
+	return at.UTC().Truncate(t.segment).Format(segmentLayout)
+}
+
+// readSegment читает точки из сегмента. Если metric пустая, возвращаются все метрики
+func readSegment(path, metric string) (map[string][]Point, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string][]Point{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	result := make(map[string][]Point)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, p, ok := parseLine(scanner.Text())
+		if !ok || (metric != "" && name != metric) {
+			continue
+		}
+		result[name] = append(result[name], p)
+	}
+	return result, scanner.Err()
+}
+
+// parseLine разбирает строку сегмента: "время метрика значение" или "время метрика сред мин макс количество"
+func parseLine(line string) (string, Point, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 && len(fields) != 6 {
+		return "", Point{}, false // Оборванная при сбое строка
+	}
+
+	unix, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return "", Point{}, false
+	}
+	values := make([]float64, len(fields)-2)
+	for i, f := range fields[2:] {
+		if values[i], err = strconv.ParseFloat(f, 64); err != nil {
+			return "", Point{}, false
+		}
+	}
+
+	p := Point{Time: time.Unix(unix, 0), Avg: values[0], Min: values[0], Max: values[0], Count: 1}
+	if len(values) == 4 {
+		p.Min, p.Max, p.Count = values[1], values[2], int(values[3])
+	}
+	return unescapeMetric(fields[1]), p, true
+}
+
+// mergePoint добавляет точку p в агрегат bucket
+func mergePoint(bucket *Point, p Point) {
+	total := bucket.Count + p.Count
+	if total == 0 {
+		return
+	}
+	bucket.Avg = (bucket.Avg*float64(bucket.Count) + p.Avg*float64(p.Count)) / float64(total)
+	bucket.Count = total
+	if p.Min < bucket.Min {
+		bucket.Min = p.Min
+	}
+	if p.Max > bucket.Max {
+		bucket.Max = p.Max
+	}
+}
+
+// metricEscaper экранирует пробелы в названии метрики (например, в именах интерфейсов Windows)
+var (
+	metricEscaper   = strings.NewReplacer("%", "%25", " ", "%20")
+	metricUnescaper = strings.NewReplacer("%25", "%", "%20", " ")
+)
+
+// escapeMetric подготавливает название метрики для записи в сегмент
+func escapeMetric(metric string) string {
+	return metricEscaper.Replace(metric)
+}
+
+// unescapeMetric восстанавливает название метрики
+func unescapeMetric(metric string) string {
+	return metricUnescaper.Replace(metric)
+}
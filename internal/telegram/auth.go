@@ -0,0 +1,130 @@
+package telegram
+
+import (
+	"TG_BOT_GO/internal/config"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// commandRoles задаёт минимальную роль для каждой команды
+var commandRoles = map[string]config.Role{
+	"start":             config.RoleViewer,
+	"net":               config.RoleViewer,
+	"processes":         config.RoleViewer,
+	"status":            config.RoleViewer,
+	"showproc":          config.RoleViewer,
+	"find":              config.RoleViewer,
+	"kill":              config.RoleAdmin,
+	"proc":              config.RoleViewer,
+	"pstree":            config.RoleViewer,
+	"watch":             config.RoleAdmin,
+	"trust":             config.RoleAdmin,
+	"ports":             config.RoleViewer,
+	"connections":       config.RoleViewer,
+	"sensors":           config.RoleViewer,
+	"history":           config.RoleViewer,
+	"chart":             config.RoleViewer,
+	"alarm":             config.RoleViewer,
+	"alarm_on":          config.RoleViewer,
+	"alarm_off":         config.RoleViewer,
+	"alarm_set":         config.RoleViewer,
+	"alarm_interval":    config.RoleViewer,
+	"alarm_unsubscribe": config.RoleViewer,
+	"rule":              config.RoleViewer,
+}
+
+// callbackRoles задаёт минимальную роль для callback-данных. Шаблон, оканчивающийся на "_",
+// считается префиксом, остальные сравниваются целиком
+var callbackRoles = []struct {
+	pattern string
+	role    config.Role
+}{
+	{"monitoring", config.RoleViewer},
+	{"network_analysis", config.RoleViewer},
+	{"processes", config.RoleViewer},
+	{"status", config.RoleViewer},
+	{"alarm", config.RoleViewer},
+	{"enable_alarm", config.RoleViewer},
+	{"disable_alarm", config.RoleViewer},
+	{"back", config.RoleViewer},
+	{"procsort_", config.RoleViewer},
+	{"showproc_page_", config.RoleViewer},
+	{"showproc_sort_", config.RoleViewer},
+	{"showproc_refresh_", config.RoleViewer},
+	{"pstree_page_", config.RoleViewer},
+	{"chart_", config.RoleViewer},
+	{"management", config.RoleAdmin},
+	{"power_", config.RoleAdmin},
+	{"procinfo_", config.RoleViewer},
+	{"procsig_", config.RoleAdmin},
+	{"procsigok_", config.RoleAdmin},
+	{"procnice_", config.RoleAdmin},
+	{"procniceset_", config.RoleAdmin},
+	{"procniceok_", config.RoleAdmin},
+}
+
+// commandRole возвращает роль, необходимую для команды. Неизвестные команды доступны наблюдателям,
+// чтобы они получали ответ "Неизвестная команда", а посторонние — нет
+func commandRole(command string) config.Role {
+	if role, ok := commandRoles[command]; ok {
+		return role
+	}
+	return config.RoleViewer
+}
+
+// callbackRole возвращает роль, необходимую для callback-данных. Незнакомые данные требуют прав администратора
+func callbackRole(data string) config.Role {
+	for _, rule := range callbackRoles {
+		if data == rule.pattern || (strings.HasSuffix(rule.pattern, "_") && strings.HasPrefix(data, rule.pattern)) {
+			return rule.role
+		}
+	}
+	return config.RoleAdmin
+}
+
+// userID возвращает ID пользователя или 0, если отправитель неизвестен
+func userID(user *tgbotapi.User) int64 {
+	if user == nil {
+		return 0
+	}
+	return user.ID
+}
+
+// authorizeMessage проверяет права на команду и отвечает отказом при их отсутствии
+func authorizeMessage(message *tgbotapi.Message, bot *tgbotapi.BotAPI) bool {
+	command := message.Command()
+	if command == "" {
+		command = "<текст>"
+	}
+
+	id := userID(message.From)
+	required := commandRole(message.Command())
+	if config.GetUserRole(id) >= required {
+		return true
+	}
+
+	log.Printf("Отказано в доступе: пользователь %d, чат %d, команда %q (требуется роль %s)",
+		id, message.Chat.ID, command, required)
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, "⛔ Доступ запрещён.")
+	bot.Send(msg)
+	return false
+}
+
+// authorizeCallback проверяет права на нажатие inline-кнопки и отвечает отказом при их отсутствии
+func authorizeCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) bool {
+	id := userID(callback.From)
+	required := callbackRole(callback.Data)
+	if config.GetUserRole(id) >= required {
+		return true
+	}
+
+	log.Printf("Отказано в доступе: пользователь %d, callback %q (требуется роль %s)",
+		id, callback.Data, required)
+
+	answer := tgbotapi.NewCallbackWithAlert(callback.ID, "⛔ Доступ запрещён.")
+	bot.Request(answer)
+	return false
+}
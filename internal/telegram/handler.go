@@ -12,6 +12,11 @@ import (
 // HandleUpdate обрабатывает входящие сообщения и callback-запросы
 func HandleUpdate(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
 	if update.Message != nil {
+		// Проверяем права пользователя на команду
+		if !authorizeMessage(update.Message, bot) {
+			return
+		}
+
 		// Обработка текстовых команд
 		switch update.Message.Command() {
 		case "start":
@@ -30,15 +35,45 @@ func HandleUpdate(update tgbotapi.Update, bot *tgbotapi.BotAPI) {
 			functions.HandleAlarmOffCommand(update, bot)
 		case "alarm_set":
 			functions.HandleAlarmSetCommand(update, bot)
+		case "alarm_interval":
+			functions.HandleAlarmIntervalCommand(update, bot)
+		case "alarm_unsubscribe":
+			functions.HandleAlarmUnsubscribeCommand(update, bot)
+		case "rule":
+			functions.HandleRuleCommand(update, bot)
 		case "showproc":
 			functions.HandleShowProcCommand(update, bot)
+		case "find":
+			functions.HandleFindCommand(update, bot)
+		case "kill":
+			functions.HandleKillCommand(update, bot)
+		case "proc":
+			functions.HandleProcCommand(update, bot)
+		case "pstree":
+			functions.HandlePsTreeCommand(update, bot)
+		case "watch":
+			functions.HandleWatchCommand(update, bot)
+		case "trust":
+			functions.HandleTrustCommand(update, bot)
+		case "ports":
+			functions.HandlePortsCommand(update, bot)
+		case "connections":
+			functions.HandleConnectionsCommand(update, bot)
+		case "sensors":
+			functions.HandleSensorsCommand(update, bot)
+		case "history":
+			functions.HandleHistoryCommand(update, bot)
+		case "chart":
+			functions.HandleChartCommand(update, bot)
 		default:
 			msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Неизвестная команда")
 			bot.Send(msg)
 		}
 	} else if update.CallbackQuery != nil {
 		// Обработка callback-запросов от inline-кнопок
-
+		if !authorizeCallback(update.CallbackQuery, bot) {
+			return
+		}
 		handleCallbackQuery(update.CallbackQuery, bot)
 	}
 }
@@ -55,7 +90,9 @@ func handleCallbackQuery(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI)
 	case data == "network_analysis":
 		handleNetworkAnalysis(chatID, messageID, bot)
 	case data == "processes":
-		handleProcesses(chatID, messageID, bot)
+		handleProcesses(chatID, messageID, functions.SortByCPU, bot)
+	case strings.HasPrefix(data, "procsort_"):
+		handleProcesses(chatID, messageID, strings.TrimPrefix(data, "procsort_"), bot)
 	case data == "status":
 		handleStatus(chatID, messageID, bot)
 	case data == "alarm":
@@ -66,9 +103,20 @@ func handleCallbackQuery(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI)
 		handleDisableAlarm(chatID, messageID, bot)
 	case data == "back":
 		sendWelcomeMessage(chatID, messageID, bot)
-	case strings.HasPrefix(data, "showproc_page_"): // Теперь это работает
-		page, _ := strconv.Atoi(strings.TrimPrefix(data, "showproc_page_"))
-		handleShowProcPage(chatID, messageID, page, bot)
+	case data == "management":
+		sendManagementMenu(chatID, messageID, bot)
+	case strings.HasPrefix(data, "chart_"):
+		handleChartCallback(chatID, data, bot)
+	case strings.HasPrefix(data, "power_"):
+		handlePowerCallback(chatID, messageID, data, bot)
+	case strings.HasPrefix(data, "procinfo_"),
+		strings.HasPrefix(data, "procsig_"), strings.HasPrefix(data, "procsigok_"),
+		strings.HasPrefix(data, "procnice_"), strings.HasPrefix(data, "procniceset_"), strings.HasPrefix(data, "procniceok_"):
+		handleProcessCallback(chatID, messageID, data, bot)
+	case strings.HasPrefix(data, "showproc_"):
+		handleShowProcCallback(chatID, messageID, data, bot)
+	case strings.HasPrefix(data, "pstree_page_"):
+		handlePsTreePage(chatID, messageID, data, bot)
 	default:
 		// Обработка других callback-запросов
 	}
@@ -178,17 +226,21 @@ func handleNetworkAnalysis(chatID int64, messageID int, bot *tgbotapi.BotAPI) {
 }
 
 // handleProcesses обрабатывает запрос на просмотр процессов
-func handleProcesses(chatID int64, messageID int, bot *tgbotapi.BotAPI) {
+func handleProcesses(chatID int64, messageID int, sortKey string, bot *tgbotapi.BotAPI) {
+	if !functions.ValidProcessSort(sortKey) {
+		return
+	}
+
 	// Отправляем сообщение "Пожалуйста, подождите пару секунд"
 	msg := tgbotapi.NewEditMessageText(chatID, messageID, "⏳ Пожалуйста, подождите пару секунд...")
 	bot.Send(msg)
 
 	// Получаем результат работы /processes
-	output := functions.HandleProcessesCommandOutput()
+	output, keyboard := functions.HandleProcessesCommandOutput(sortKey)
 
-	// Формируем результат
+	// Формируем результат: кнопки сортировки и "Назад"
 	resultMsg := tgbotapi.NewEditMessageText(chatID, messageID, output)
-	keyboard := GetBackKeyboard("monitoring") // Кнопка "Назад"
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, GetBackKeyboard("monitoring").InlineKeyboard...)
 	resultMsg.ReplyMarkup = &keyboard
 
 	// Редактируем сообщение с результатом
@@ -209,7 +261,7 @@ func handleStatus(chatID int64, messageID int, bot *tgbotapi.BotAPI) {
 
 	// Формируем результат
 	resultMsg := tgbotapi.NewEditMessageText(chatID, messageID, output)
-	keyboard := GetBackKeyboard("monitoring") // Кнопка "Назад"
+	keyboard := GetStatusKeyboard() // Кнопки "График" и "Назад"
 	resultMsg.ReplyMarkup = &keyboard
 
 	// Редактируем сообщение с результатом
@@ -222,7 +274,7 @@ func handleStatus(chatID int64, messageID int, bot *tgbotapi.BotAPI) {
 // handleAlarm обрабатывает запрос на настройку предупреждений
 func handleAlarm(chatID int64, messageID int, bot *tgbotapi.BotAPI) {
 	// Получаем результат работы /alarm
-	output := functions.HandleAlarmCommandOutput()
+	output := functions.HandleAlarmCommandOutput(chatID)
 
 	// Редактируем текущее сообщение
 	msg := tgbotapi.NewEditMessageText(chatID, messageID, output)
@@ -235,15 +287,96 @@ func handleAlarm(chatID int64, messageID int, bot *tgbotapi.BotAPI) {
 	}
 }
 
-func handleShowProcPage(chatID int64, messageID int, page int, bot *tgbotapi.BotAPI) {
-	procs, totalPages, err := functions.GetProcessesPage(chatID, page)
-	if err != nil || len(procs) == 0 {
-		msg := tgbotapi.NewMessage(chatID, "❌ Нет данных для отображения")
+// handleChartCallback отправляет меню графиков или сам график новым сообщением
+func handleChartCallback(chatID int64, data string, bot *tgbotapi.BotAPI) {
+	if data == "chart_menu" {
+		text, keyboard := functions.ChartMenu()
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ReplyMarkup = keyboard
 		bot.Send(msg)
 		return
 	}
 
-	message, keyboard := functions.FormatProcessesMessage(procs, page, totalPages)
+	// Формат: chart_<метрика>_<окно>
+	args := strings.TrimPrefix(data, "chart_")
+	idx := strings.LastIndex(args, "_")
+	if idx < 0 {
+		return
+	}
+	window, err := functions.ParseWindow(args[idx+1:])
+	if err != nil {
+		return
+	}
+	functions.SendChart(chatID, args[:idx], window, bot)
+}
+
+// handleShowProcCallback обрабатывает кнопки снимка списка процессов:
+// showproc_page_<id>_<страница>, showproc_sort_<id>_<ключ> и showproc_refresh_<id>
+func handleShowProcCallback(chatID int64, messageID int, data string, bot *tgbotapi.BotAPI) {
+	_, args := splitCallbackData(data)
+	if len(args) < 2 {
+		return
+	}
+	action, id := args[0], args[1]
+
+	snapshot, ok := functions.GetProcessSnapshot(chatID, id)
+	if !ok {
+		editMsg := tgbotapi.NewEditMessageText(chatID, messageID, functions.ExpiredSnapshotMessage)
+		bot.Send(editMsg)
+		return
+	}
+
+	page := 0
+	switch action {
+	case "page":
+		if len(args) < 3 {
+			return
+		}
+		page, _ = strconv.Atoi(args[2])
+	case "sort", "refresh":
+		// Сортировка и обновление строят новый снимок с тем же фильтром
+		sortKey := snapshot.Sort
+		if action == "sort" {
+			if len(args) < 3 || !functions.ValidProcessSort(args[2]) {
+				return
+			}
+			sortKey = args[2]
+		}
+		var err error
+		snapshot, err = functions.NewProcessSnapshot(chatID, snapshot.Filter, sortKey)
+		if err != nil {
+			msg := tgbotapi.NewMessage(chatID, "❌ Ошибка при получении процессов")
+			bot.Send(msg)
+			return
+		}
+	default:
+		return
+	}
+
+	message, keyboard := functions.FormatProcessesMessage(snapshot, page)
+	editMsg := tgbotapi.NewEditMessageText(chatID, messageID, message)
+	editMsg.ReplyMarkup = &keyboard
+	bot.Send(editMsg)
+}
+
+// handlePsTreePage показывает страницу снимка дерева процессов: pstree_page_<id>_<страница>
+func handlePsTreePage(chatID int64, messageID int, data string, bot *tgbotapi.BotAPI) {
+	_, args := splitCallbackData(data)
+	if len(args) < 3 {
+		return
+	}
+	snapshot, ok := functions.GetProcessTreeSnapshot(chatID, args[1])
+	if !ok {
+		editMsg := tgbotapi.NewEditMessageText(chatID, messageID, functions.ExpiredTreeMessage)
+		bot.Send(editMsg)
+		return
+	}
+	page, _ := strconv.Atoi(args[2])
+	message, keyboard, ok := functions.FormatProcessTreePage(snapshot, page)
+	if !ok {
+		return
+	}
+
 	editMsg := tgbotapi.NewEditMessageText(chatID, messageID, message)
 	editMsg.ReplyMarkup = &keyboard
 	bot.Send(editMsg)
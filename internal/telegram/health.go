@@ -0,0 +1,33 @@
+package telegram
+
+import (
+	"sync"
+	"time"
+)
+
+// pollTimeout — таймаут long polling запроса getUpdates (в секундах)
+const pollTimeout = 60
+
+var (
+	lastPoll      time.Time // Время последнего успешного запроса getUpdates
+	lastPollMutex sync.Mutex
+)
+
+// markPoll запоминает время успешного опроса Telegram
+func markPoll() {
+	lastPollMutex.Lock()
+	lastPoll = time.Now()
+	lastPollMutex.Unlock()
+}
+
+// PollerHealth сообщает, жив ли опрос Telegram, и время последнего успешного запроса.
+// Опрос считается живым, если успешный запрос был не дольше двух таймаутов назад
+func PollerHealth() (bool, time.Time) {
+	lastPollMutex.Lock()
+	defer lastPollMutex.Unlock()
+
+	if lastPoll.IsZero() {
+		return false, lastPoll
+	}
+	return time.Since(lastPoll) < 2*pollTimeout*time.Second+30*time.Second, lastPoll
+}
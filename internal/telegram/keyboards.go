@@ -42,6 +42,18 @@ func GetBackKeyboard(backTo string) tgbotapi.InlineKeyboardMarkup {
 	)
 }
 
+// GetStatusKeyboard возвращает клавиатуру под экраном актуального состояния
+func GetStatusKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📈 График", "chart_menu"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "monitoring"),
+		),
+	)
+}
+
 // GetAlarmKeyboard возвращает клавиатуру для раздела предупреждений
 func GetAlarmKeyboard() tgbotapi.InlineKeyboardMarkup {
 	return tgbotapi.NewInlineKeyboardMarkup(
@@ -54,3 +66,53 @@ func GetAlarmKeyboard() tgbotapi.InlineKeyboardMarkup {
 		),
 	)
 }
+
+// GetManagementKeyboard возвращает клавиатуру для раздела управления
+func GetManagementKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⏻ Выключить", "power_shutdown"),
+			tgbotapi.NewInlineKeyboardButtonData("🔄 Перезагрузить", "power_reboot"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("💤 Спящий режим", "power_suspend"),
+			tgbotapi.NewInlineKeyboardButtonData("🔒 Заблокировать", "power_lock"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🚫 Отменить выключение", "power_cancel"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back"),
+		),
+	)
+}
+
+// GetPowerDelayKeyboard возвращает клавиатуру выбора задержки для действия питания
+func GetPowerDelayKeyboard(action string) tgbotapi.InlineKeyboardMarkup {
+	prefix := "power_delay_" + action + "_"
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⚡ Сейчас", prefix+"0"),
+			tgbotapi.NewInlineKeyboardButtonData("1 мин", prefix+"60"),
+			tgbotapi.NewInlineKeyboardButtonData("5 мин", prefix+"300"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("15 мин", prefix+"900"),
+			tgbotapi.NewInlineKeyboardButtonData("30 мин", prefix+"1800"),
+			tgbotapi.NewInlineKeyboardButtonData("60 мин", prefix+"3600"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "management"),
+		),
+	)
+}
+
+// GetConfirmKeyboard возвращает клавиатуру подтверждения действия
+func GetConfirmKeyboard(confirmData, cancelData string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Подтвердить", confirmData),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", cancelData),
+		),
+	)
+}
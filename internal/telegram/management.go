@@ -0,0 +1,128 @@
+package telegram
+
+import (
+	"TG_BOT_GO/internal/commands"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// sendManagementMenu отправляет меню удалённого управления
+func sendManagementMenu(chatID int64, messageID int, bot *tgbotapi.BotAPI) {
+	text := "⚙️ Раздел управления позволяет выключить, перезагрузить, усыпить компьютер или заблокировать экран.\n" +
+		"Каждое действие требует подтверждения."
+
+	if action, at, ok := commands.Power.Pending(); ok {
+		text += fmt.Sprintf("\n\n⏳ Запланировано: %s в %s", action.Title(), at.Format("15:04:05"))
+	}
+
+	keyboard := GetManagementKeyboard()
+	msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	msg.ReplyMarkup = &keyboard
+	_, err := bot.Send(msg)
+	if err != nil {
+		log.Printf("Ошибка при редактировании сообщения: %v", err)
+	}
+}
+
+// handlePowerCallback обрабатывает callback-данные с префиксом power_
+func handlePowerCallback(chatID int64, messageID int, data string, bot *tgbotapi.BotAPI) {
+	switch {
+	case strings.HasPrefix(data, "power_delay_"):
+		action, delay, ok := parsePowerData(strings.TrimPrefix(data, "power_delay_"))
+		if !ok {
+			return
+		}
+		sendPowerConfirmation(chatID, messageID, action, delay, bot)
+	case strings.HasPrefix(data, "power_confirm_"):
+		action, delay, err := commands.ParseConfirmation(strings.TrimPrefix(data, "power_confirm_"), time.Now())
+		if errors.Is(err, commands.ErrConfirmationExpired) {
+			keyboard := GetBackKeyboard("management")
+			msg := tgbotapi.NewEditMessageText(chatID, messageID, "⌛ "+err.Error()+".")
+			msg.ReplyMarkup = &keyboard
+			bot.Send(msg)
+			return
+		}
+		if err != nil {
+			log.Printf("Ошибка в данных подтверждения действия питания: %v", err)
+			return
+		}
+		executePowerAction(chatID, messageID, action, delay, bot)
+	default:
+		action, ok := commands.ParsePowerAction(strings.TrimPrefix(data, "power_"))
+		if !ok {
+			return
+		}
+		// Для отмены задержка не нужна — сразу переходим к подтверждению
+		if action == commands.ActionCancel {
+			sendPowerConfirmation(chatID, messageID, action, 0, bot)
+			return
+		}
+
+		text := fmt.Sprintf("⏱️ %s: выберите задержку перед выполнением.", action.Title())
+		keyboard := GetPowerDelayKeyboard(string(action))
+		msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
+		msg.ReplyMarkup = &keyboard
+		bot.Send(msg)
+	}
+}
+
+// parsePowerData разбирает строку вида "<действие>_<секунды>"
+func parsePowerData(data string) (commands.PowerAction, time.Duration, bool) {
+	idx := strings.LastIndex(data, "_")
+	if idx < 0 {
+		return "", 0, false
+	}
+	action, ok := commands.ParsePowerAction(data[:idx])
+	if !ok {
+		return "", 0, false
+	}
+	seconds, err := strconv.Atoi(data[idx+1:])
+	if err != nil || seconds < 0 {
+		return "", 0, false
+	}
+	return action, time.Duration(seconds) * time.Second, true
+}
+
+// sendPowerConfirmation показывает запрос подтверждения действия питания
+func sendPowerConfirmation(chatID int64, messageID int, action commands.PowerAction, delay time.Duration, bot *tgbotapi.BotAPI) {
+	text := fmt.Sprintf("⚠️ Подтвердите действие: %s", action.Title())
+	if delay > 0 {
+		text += fmt.Sprintf(" через %s", delay)
+	} else if action != commands.ActionCancel {
+		text += " прямо сейчас"
+	}
+
+	confirmData := "power_confirm_" + commands.ConfirmationData(action, delay, time.Now())
+	keyboard := GetConfirmKeyboard(confirmData, "management")
+	msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	msg.ReplyMarkup = &keyboard
+	bot.Send(msg)
+}
+
+// executePowerAction выполняет подтверждённое действие питания и сообщает результат
+func executePowerAction(chatID int64, messageID int, action commands.PowerAction, delay time.Duration, bot *tgbotapi.BotAPI) {
+	log.Printf("Выполнение действия %s с задержкой %s по запросу из чата %d", action, delay, chatID)
+
+	var text string
+	if err := commands.Power.Execute(action, delay); err != nil {
+		log.Printf("Ошибка при выполнении действия %s: %v", action, err)
+		text = fmt.Sprintf("❌ %s: ошибка: %v", action.Title(), err)
+	} else if action == commands.ActionCancel {
+		text = "✅ Запланированное выключение отменено."
+	} else if delay > 0 {
+		text = fmt.Sprintf("✅ %s: запланировано на %s.", action.Title(), time.Now().Add(delay).Format("15:04:05"))
+	} else {
+		text = fmt.Sprintf("✅ %s: команда выполнена.", action.Title())
+	}
+
+	keyboard := GetBackKeyboard("management")
+	msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	msg.ReplyMarkup = &keyboard
+	bot.Send(msg)
+}
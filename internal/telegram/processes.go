@@ -0,0 +1,96 @@
+package telegram
+
+import (
+	"TG_BOT_GO/internal/commands"
+	"TG_BOT_GO/internal/functions"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleProcessCallback обрабатывает кнопки действий над процессами
+func handleProcessCallback(chatID int64, messageID int, data string, bot *tgbotapi.BotAPI) {
+	prefix, args := splitCallbackData(data)
+	if len(args) == 0 {
+		return
+	}
+	pid64, err := strconv.ParseInt(args[0], 10, 32)
+	if err != nil {
+		return
+	}
+	pid := int32(pid64)
+
+	var text string
+	var keyboard tgbotapi.InlineKeyboardMarkup
+
+	switch prefix {
+	case "procinfo":
+		text, keyboard = functions.FormatProcessCard(pid)
+	case "procsig":
+		if len(args) < 2 {
+			return
+		}
+		sig, ok := commands.ParseSignal(args[1])
+		if !ok {
+			return
+		}
+		text, keyboard = functions.FormatSignalConfirmation(pid, sig)
+	case "procnice":
+		text, keyboard = functions.FormatNiceMenu(pid)
+	case "procniceset":
+		if len(args) < 2 {
+			return
+		}
+		nice, err := strconv.Atoi(args[1])
+		if err != nil {
+			return
+		}
+		text, keyboard = functions.FormatNiceConfirmation(pid, nice)
+	case "procsigok", "procniceok":
+		// Подтверждение действует ограниченное время и только для того процесса, для которого запрошено
+		_, arg, err := commands.ParseProcessConfirmation(strings.TrimPrefix(data, prefix+"_"), time.Now())
+		if errors.Is(err, commands.ErrConfirmationExpired) || errors.Is(err, commands.ErrProcessChanged) {
+			text, keyboard = "⌛ "+err.Error()+".", GetBackKeyboard("procinfo_"+args[0])
+			break
+		}
+		if err != nil {
+			log.Printf("Ошибка в данных подтверждения действия над процессом: %v", err)
+			return
+		}
+		keyboard = GetBackKeyboard("procinfo_" + args[0])
+		if prefix == "procsigok" {
+			sig, ok := commands.ParseSignal(arg)
+			if !ok {
+				return
+			}
+			log.Printf("Отправка %s процессу %d по запросу из чата %d", sig.Title(), pid, chatID)
+			text = functions.ExecuteSignal(pid, sig)
+		} else {
+			nice, err := strconv.Atoi(arg)
+			if err != nil {
+				return
+			}
+			log.Printf("Изменение nice процесса %d на %d по запросу из чата %d", pid, nice, chatID)
+			text = functions.ExecuteRenice(pid, nice)
+		}
+	default:
+		return
+	}
+
+	msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	msg.ReplyMarkup = &keyboard
+	_, err = bot.Send(msg)
+	if err != nil {
+		log.Printf("Ошибка при редактировании сообщения: %v", err)
+	}
+}
+
+// splitCallbackData разделяет callback-данные вида "префикс_арг1_арг2" на префикс и аргументы
+func splitCallbackData(data string) (string, []string) {
+	parts := strings.Split(data, "_")
+	return parts[0], parts[1:]
+}
@@ -3,15 +3,13 @@ package telegram
 import (
 	"TG_BOT_GO/internal/config"
 	"TG_BOT_GO/internal/monitor"
+	"TG_BOT_GO/internal/storage"
 	"log"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-var (
-	chatID int64 // Глобальная переменная для хранения chatID
-)
-
 // StartBot запускает телеграм бота
 func StartBot() error {
 	// Получаем токен бота из конфигурации
@@ -31,34 +29,82 @@ func StartBot() error {
 
 	log.Printf("Бот %s успешно запущен", bot.Self.UserName)
 
-	// Загружаем пороговые значения
-	if err := monitor.LoadThresholds(); err != nil {
-		log.Println("Ошибка при загрузке пороговых значений:", err)
+	// Загружаем правила уведомлений по умолчанию
+	if err := monitor.LoadDefaultRules(); err != nil {
+		log.Println("Ошибка при загрузке правил уведомлений:", err)
+	}
+
+	// Подключаем хранилище истории метрик на диске
+	metricsDir := config.GetEnv("METRICS_DIR")
+	if metricsDir == "" {
+		metricsDir = "metrics"
+	}
+	store, err := storage.Open(metricsDir, storage.Retention{
+		Raw:    config.GetEnvDuration("METRICS_RAW_RETENTION", 24*time.Hour),
+		Minute: config.GetEnvDuration("METRICS_MINUTE_RETENTION", 7*24*time.Hour),
+		Hour:   config.GetEnvDuration("METRICS_HOUR_RETENTION", 365*24*time.Hour),
+	})
+	if err != nil {
+		log.Println("Ошибка при открытии хранилища метрик:", err)
+	} else {
+		monitor.SetMetricsStore(store)
+		go store.RunCompaction(time.Hour)
 	}
 
-	// Запускаем мониторинг уведомлений
-	go monitor.StartAlarmMonitor(bot, chatID)
+	// Определяем видеокарты один раз при запуске, чтобы не вызывать lspci на каждом такте
+	monitor.GPUProviders()
 
-	// Настраиваем канал для получения обновлений
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
+	// Запускаем фоновый сбор метрик, из которого читают /status, /net и уведомления
+	monitor.StartCollector(
+		config.GetEnvDuration("METRICS_INTERVAL", 10*time.Second),
+		config.GetEnvDuration("METRICS_HISTORY", time.Hour),
+	)
 
-	updates := bot.GetUpdatesChan(u)
+	// Загружаем подписки чатов на уведомления
+	if err := monitor.LoadSubscriptions(); err != nil {
+		log.Println("Ошибка при загрузке подписок на уведомления:", err)
+	}
 
-	// Обрабатываем входящие сообщения
-	for update := range updates {
-		if update.Message != nil {
-			// Сохраняем chatID при первом взаимодействии
-			if chatID == 0 {
-				chatID = update.Message.Chat.ID
-				log.Printf("ChatID сохранен: %d", chatID)
+	// Запускаем единый цикл мониторинга уведомлений для всех подписчиков
+	go monitor.StartAlarmMonitor(bot)
+
+	// Загружаем правила сторожа процессов и запускаем его цикл
+	if err := monitor.LoadWatchRules(); err != nil {
+		log.Println("Ошибка при загрузке правил сторожа процессов:", err)
+	}
+	go monitor.StartWatchdog(bot)
 
-				// Перезапускаем мониторинг уведомлений с новым chatID
-				go monitor.StartAlarmMonitor(bot, chatID)
+	// Загружаем базу известных исполняемых файлов и запускаем поиск подозрительных процессов
+	if err := monitor.LoadBaseline(); err != nil {
+		log.Println("Ошибка при загрузке базы исполняемых файлов:", err)
+	}
+	go monitor.StartSecurityScanner(bot)
+
+	// Запускаем слежение за слушающими портами
+	go monitor.StartPortWatcher(bot)
+
+	// Запускаем слежение за батареями и ИБП
+	go monitor.StartPowerMonitor(bot)
+
+	// Получаем обновления сами, чтобы отслеживать работоспособность опроса для /healthz
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = pollTimeout
+
+	for {
+		updates, err := bot.GetUpdates(u)
+		if err != nil {
+			log.Printf("Ошибка при получении обновлений: %v. Повтор через 3 секунды...", err)
+			time.Sleep(3 * time.Second)
+			continue
+		}
+		markPoll()
+
+		// Обрабатываем входящие сообщения
+		for _, update := range updates {
+			if update.UpdateID >= u.Offset {
+				u.Offset = update.UpdateID + 1
+				HandleUpdate(update, bot)
 			}
 		}
-		HandleUpdate(update, bot)
 	}
-
-	return nil
 }